@@ -0,0 +1,199 @@
+// Package apperror: lỗi trả cho client gồm mã ổn định (STORY_NOT_FOUND, VALIDATION_FAILED...),
+// HTTP status và chi tiết theo từng trường. Câu thông báo được dịch theo Accept-Language khi render.
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// Code: mã lỗi ổn định cho client. Bản thân Code cũng là error để handler viết gọn
+// apperror.Abort(c, apperror.StoryNotFound).
+type Code string
+
+func (code Code) Error() string { return string(code) }
+
+// Status: HTTP status mặc định của mã lỗi
+func (code Code) Status() int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldCode: lý do một trường không hợp lệ
+type FieldCode string
+
+const (
+	Required     FieldCode = "REQUIRED"
+	Invalid      FieldCode = "INVALID"
+	TooShort     FieldCode = "TOO_SHORT"
+	TooLong      FieldCode = "TOO_LONG"
+	TooManyBytes FieldCode = "TOO_MANY_BYTES"
+	TooFew       FieldCode = "TOO_FEW"
+	TooMany      FieldCode = "TOO_MANY"
+	MustBeFuture FieldCode = "MUST_BE_FUTURE"
+	NotAllowed   FieldCode = "NOT_ALLOWED"
+	NotExist     FieldCode = "NOT_EXIST"
+)
+
+// FieldCodes: mọi FieldCode, dùng cho tài liệu API và kiểm tra bộ thông báo
+var FieldCodes = []FieldCode{Required, Invalid, TooShort, TooLong, TooManyBytes, TooFew, TooMany, MustBeFuture, NotAllowed, NotExist}
+
+// FieldError: chi tiết lỗi của một trường; Params dùng để điền vào câu thông báo (vd {min})
+type FieldError struct {
+	Field  string
+	Code   FieldCode
+	Params map[string]any
+}
+
+// Field tạo chi tiết lỗi cho một trường
+func Field(name string, code FieldCode) FieldError {
+	return FieldError{Field: name, Code: code}
+}
+
+// With thêm tham số cho câu thông báo
+func (f FieldError) With(key string, value any) FieldError {
+	params := make(map[string]any, len(f.Params)+1)
+	for k, v := range f.Params {
+		params[k] = v
+	}
+	params[key] = value
+	f.Params = params
+	return f
+}
+
+// Error: lỗi đầy đủ để render. Cause chỉ ghi log, không bao giờ trả cho client.
+type Error struct {
+	Code   Code
+	Status int
+	Params map[string]any
+	Fields []FieldError
+	// Meta: trường bổ sung ở cấp ngoài của response (vd retry_after)
+	Meta  map[string]any
+	Cause error
+}
+
+func New(code Code) *Error {
+	return &Error{Code: code, Status: code.Status()}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return string(e.Code) + ": " + e.Cause.Error()
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// WithStatus đổi HTTP status cho trường hợp đặc biệt
+func (e *Error) WithStatus(status int) *Error {
+	e.Status = status
+	return e
+}
+
+// WithParam thêm tham số cho câu thông báo (vd {provider})
+func (e *Error) WithParam(key string, value any) *Error {
+	if e.Params == nil {
+		e.Params = map[string]any{}
+	}
+	e.Params[key] = value
+	return e
+}
+
+// WithMeta thêm trường vào response
+func (e *Error) WithMeta(key string, value any) *Error {
+	if e.Meta == nil {
+		e.Meta = map[string]any{}
+	}
+	e.Meta[key] = value
+	return e
+}
+
+// Wrap gắn nguyên nhân gốc để ghi log
+func (e *Error) Wrap(cause error) *Error {
+	e.Cause = cause
+	return e
+}
+
+// Validation: VALIDATION_FAILED kèm chi tiết từng trường
+func Validation(fields ...FieldError) *Error {
+	e := New(ValidationFailed)
+	e.Fields = fields
+	return e
+}
+
+// InvalidID: ID trong param/body sai định dạng ObjectID
+func InvalidID(field string) *Error {
+	return Validation(Field(field, Invalid))
+}
+
+// Internal: lỗi phía server, cause được ghi log kèm request_id
+func Internal(cause error) *Error {
+	return New(InternalError).Wrap(cause)
+}
+
+// From chuyển error bất kỳ thành *Error; lỗi lạ được coi là INTERNAL_ERROR
+func From(err error) *Error {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+	var code Code
+	if errors.As(err, &code) {
+		return New(code)
+	}
+	return Internal(err)
+}
+
+// Abort ghi lỗi vào context cho middleware render và dừng các handler phía sau
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// Bind: body/query không đọc được vào struct (sai JSON, sai kiểu dữ liệu...).
+// Lỗi từ tag binding được đổi thành chi tiết theo từng trường.
+func Bind(err error) *Error {
+	var invalid validator.ValidationErrors
+	if !errors.As(err, &invalid) {
+		return New(ValidationFailed).Wrap(err)
+	}
+	fields := make([]FieldError, 0, len(invalid))
+	for _, fe := range invalid {
+		fields = append(fields, fieldFromValidator(fe))
+	}
+	return Validation(fields...).Wrap(err)
+}
+
+// fieldFromValidator đổi tag validator sang FieldCode; tên trường là tên JSON
+// (dto đăng ký RegisterTagNameFunc), min/max phân biệt chuỗi với danh sách
+func fieldFromValidator(fe validator.FieldError) FieldError {
+	isList := fe.Kind() == reflect.Slice || fe.Kind() == reflect.Array || fe.Kind() == reflect.Map
+	switch fe.Tag() {
+	case "required", "required_without", "notblank":
+		return Field(fe.Field(), Required)
+	case "min":
+		if isList {
+			return Field(fe.Field(), TooFew).With("min", fe.Param())
+		}
+		return Field(fe.Field(), TooShort).With("min", fe.Param())
+	case "max":
+		if isList {
+			return Field(fe.Field(), TooMany).With("max", fe.Param())
+		}
+		return Field(fe.Field(), TooLong).With("max", fe.Param())
+	case "maxbytes":
+		return Field(fe.Field(), TooManyBytes).With("max", fe.Param())
+	case "oneof", "genre":
+		return Field(fe.Field(), NotAllowed)
+	case "future":
+		return Field(fe.Field(), MustBeFuture)
+	}
+	return Field(fe.Field(), Invalid)
+}
@@ -0,0 +1,253 @@
+package apperror
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage: ngôn ngữ khi client không gửi Accept-Language hoặc gửi ngôn ngữ chưa hỗ trợ
+const DefaultLanguage = "vi"
+
+var messages = map[string]map[Code]string{
+	"vi": {
+		ValidationFailed: "Dữ liệu đầu vào không hợp lệ",
+		NothingToUpdate:  "Không có thông tin nào để cập nhật",
+		InternalError:    "Đã có lỗi xảy ra, vui lòng thử lại sau",
+		RouteNotFound:    "Không tìm thấy đường dẫn",
+		FileTooLarge:     "File quá lớn",
+		TooManyAttempts:  "Đăng nhập sai quá nhiều lần, vui lòng thử lại sau",
+		Forbidden:        "Bạn không có quyền thực hiện thao tác này",
+		SelfAction:       "Không thể tự thực hiện thao tác này với chính mình",
+
+		Unauthenticated:       "Bạn chưa đăng nhập",
+		TokenInvalid:          "Token không hợp lệ",
+		SessionExpired:        "Phiên đăng nhập đã hết hạn hoặc bị thu hồi",
+		InvalidCredentials:    "Sai tài khoản hoặc mật khẩu",
+		WrongPassword:         "Mật khẩu không đúng",
+		ReauthRequired:        "Vui lòng xác nhận bằng mã 2FA hoặc đăng nhập lại qua tài khoản đã liên kết",
+		RefreshTokenInvalid:   "Refresh token không hợp lệ",
+		AccountBanned:         "Tài khoản đã bị khóa",
+		AdminProtected:        "Bạn không thể khoá tài khoản quản trị",
+		EmailNotVerified:      "Bạn cần xác minh email trước",
+		EmailAlreadyVerified:  "Email đã được xác minh",
+		EmailMissing:          "Tài khoản chưa có email",
+		EmailChanged:          "Email đã thay đổi, vui lòng yêu cầu link mới",
+		LinkInvalid:           "Link không hợp lệ hoặc đã hết hạn",
+		UsernameTaken:         "Username đã tồn tại",
+		EmailTaken:            "Email đã được sử dụng",
+		AccountExists:         "Username hoặc email đã tồn tại",
+		AccountBeingCreated:   "Tài khoản đang được tạo, vui lòng đăng nhập lại",
+		OwnsStories:           "Bạn cần xoá hoặc chuyển các truyện của mình trước khi xoá tài khoản",
+		MFAPending:            "Chưa hoàn tất xác thực 2 bước",
+		MFATokenExpired:       "Phiên xác thực 2 bước đã hết hạn, vui lòng đăng nhập lại",
+		InvalidTOTPCode:       "Mã xác thực không đúng",
+		TwoFactorRequired:     "Tài khoản của bạn phải bật xác thực 2 bước",
+		TwoFactorNotSetUp:     "Chưa khởi tạo xác thực 2 bước",
+		TwoFactorEnabled:      "Xác thực 2 bước đã được bật",
+		TwoFactorDisabled:     "Tài khoản chưa bật xác thực 2 bước",
+		APIKeyInvalid:         "API key không hợp lệ, đã hết hạn hoặc bị thu hồi",
+		APIKeyScopeDenied:     "API key không có scope cho thao tác này",
+		APIKeyNotAllowed:      "Thao tác này không dùng được với API key",
+		TooManyAPIKeys:        "Bạn đã có quá nhiều API key, hãy thu hồi bớt",
+		OIDCAuthFailed:        "Xác thực với provider thất bại",
+		OIDCStateInvalid:      "State không hợp lệ hoặc đã hết hạn, vui lòng thử lại",
+		OIDCStateMismatch:     "State không dùng được cho thao tác này",
+		OIDCProviderNotFound:  "Không hỗ trợ đăng nhập qua provider này",
+		IdentityNotFound:      "Chưa liên kết tài khoản {provider}",
+		IdentityAlreadyLinked: "Tài khoản {provider} này đã liên kết, hoặc bạn đã liên kết một tài khoản {provider} khác",
+		EmailBelongsToAccount: "Email này đã thuộc một tài khoản, hãy đăng nhập và liên kết {provider} trong phần cài đặt",
+		LastLoginMethod:       "Đây là cách đăng nhập duy nhất của tài khoản, không thể gỡ",
+		AlreadyAuthor:         "Tài khoản của bạn đã có quyền tác giả",
+		ApplicationPending:    "Bạn đã có đơn đang chờ duyệt",
+		RoleNotPromotable:     "Role hiện tại có quyền mà role tác giả không có, cần quản trị viên đổi role trực tiếp",
+
+		StoryNotFound:          "Không tìm thấy truyện",
+		ChapterNotFound:        "Không tìm thấy chương",
+		CommentNotFound:        "Không tìm thấy bình luận",
+		UserNotFound:           "Không tìm thấy người dùng",
+		SessionNotFound:        "Không tìm thấy phiên đăng nhập",
+		APIKeyNotFound:         "Không tìm thấy API key",
+		InvitationNotFound:     "Không tìm thấy lời mời đang chờ",
+		TransferNotFound:       "Không tìm thấy đề nghị chuyển đang chờ",
+		ApplicationNotFound:    "Không tìm thấy đơn đăng ký tác giả",
+		CollaboratorNotFound:   "Không tìm thấy cộng tác viên trong truyện",
+		BookshelfEntryNotFound: "Không tìm thấy truyện trong tủ sách",
+		FollowNotFound:         "Bạn chưa theo dõi người này",
+		LockoutNotFound:        "Tài khoản hoặc IP không bị khoá đăng nhập",
+		StoryDeleteForbidden:   "Bạn không có quyền xóa truyện này hoặc truyện chưa bị ẩn",
+		StoryChanged:           "Truyện đã thay đổi hoặc không còn tồn tại, vui lòng thử lại",
+		InvitationPending:      "Người này đã có lời mời đang chờ",
+		AlreadyCollaborator:    "Người này đã là cộng tác viên của truyện",
+		TransferPending:        "Truyện đã có đề nghị chuyển đang chờ",
+		AlreadyOwner:           "Người này đã là chủ truyện",
+		RecipientNotAuthor:     "Người nhận phải là tác giả",
+		AuthorOnly:             "Chỉ tác giả mới nhận được truyện",
+	},
+	"en": {
+		ValidationFailed: "The request data is invalid",
+		NothingToUpdate:  "There is nothing to update",
+		InternalError:    "Something went wrong, please try again later",
+		RouteNotFound:    "Route not found",
+		FileTooLarge:     "File is too large",
+		TooManyAttempts:  "Too many failed login attempts, please try again later",
+		Forbidden:        "You are not allowed to perform this action",
+		SelfAction:       "You cannot perform this action on yourself",
+
+		Unauthenticated:       "Authentication required",
+		TokenInvalid:          "Invalid token",
+		SessionExpired:        "Your session has expired or been revoked",
+		InvalidCredentials:    "Incorrect username or password",
+		WrongPassword:         "Incorrect password",
+		ReauthRequired:        "Please confirm with a 2FA code or by signing in again with a linked account",
+		RefreshTokenInvalid:   "Invalid refresh token",
+		AccountBanned:         "This account has been banned",
+		AdminProtected:        "Administrator accounts cannot be banned",
+		EmailNotVerified:      "Please verify your email first",
+		EmailAlreadyVerified:  "Email is already verified",
+		EmailMissing:          "This account has no email address",
+		EmailChanged:          "Your email has changed, please request a new link",
+		LinkInvalid:           "The link is invalid or has expired",
+		UsernameTaken:         "Username is already taken",
+		EmailTaken:            "Email is already in use",
+		AccountExists:         "Username or email already exists",
+		AccountBeingCreated:   "The account is still being created, please sign in again",
+		OwnsStories:           "Delete or transfer your stories before deleting your account",
+		MFAPending:            "Two-factor authentication has not been completed",
+		MFATokenExpired:       "The two-factor session has expired, please sign in again",
+		InvalidTOTPCode:       "Incorrect verification code",
+		TwoFactorRequired:     "Your account must have two-factor authentication enabled",
+		TwoFactorNotSetUp:     "Two-factor authentication has not been set up",
+		TwoFactorEnabled:      "Two-factor authentication is already enabled",
+		TwoFactorDisabled:     "Two-factor authentication is not enabled",
+		APIKeyInvalid:         "The API key is invalid, expired or revoked",
+		APIKeyScopeDenied:     "The API key does not have the scope for this action",
+		APIKeyNotAllowed:      "This action cannot be performed with an API key",
+		TooManyAPIKeys:        "You have too many API keys, please revoke some",
+		OIDCAuthFailed:        "Authentication with the provider failed",
+		OIDCStateInvalid:      "The state is invalid or has expired, please try again",
+		OIDCStateMismatch:     "The state cannot be used for this action",
+		OIDCProviderNotFound:  "Sign-in with this provider is not supported",
+		IdentityNotFound:      "No linked {provider} account",
+		IdentityAlreadyLinked: "This {provider} account is already linked, or you have linked another {provider} account",
+		EmailBelongsToAccount: "This email belongs to an existing account, sign in and link {provider} in your settings",
+		LastLoginMethod:       "This is the account's only sign-in method and cannot be removed",
+		AlreadyAuthor:         "Your account already has the author role",
+		ApplicationPending:    "You already have a pending application",
+		RoleNotPromotable:     "The current role has permissions the author role lacks, an administrator must change the role directly",
+
+		StoryNotFound:          "Story not found",
+		ChapterNotFound:        "Chapter not found",
+		CommentNotFound:        "Comment not found",
+		UserNotFound:           "User not found",
+		SessionNotFound:        "Session not found",
+		APIKeyNotFound:         "API key not found",
+		InvitationNotFound:     "No pending invitation found",
+		TransferNotFound:       "No pending transfer found",
+		ApplicationNotFound:    "Author application not found",
+		CollaboratorNotFound:   "Collaborator not found on this story",
+		BookshelfEntryNotFound: "Story not found in your bookshelf",
+		FollowNotFound:         "You are not following this user",
+		LockoutNotFound:        "The account or IP is not locked out",
+		StoryDeleteForbidden:   "You cannot delete this story, or it has not been hidden yet",
+		StoryChanged:           "The story has changed or no longer exists, please try again",
+		InvitationPending:      "This user already has a pending invitation",
+		AlreadyCollaborator:    "This user is already a collaborator on the story",
+		TransferPending:        "The story already has a pending transfer",
+		AlreadyOwner:           "This user already owns the story",
+		RecipientNotAuthor:     "The recipient must be an author",
+		AuthorOnly:             "Only authors can receive stories",
+	},
+}
+
+var fieldMessages = map[string]map[FieldCode]string{
+	"vi": {
+		Required:     "Bắt buộc",
+		Invalid:      "Không hợp lệ",
+		TooShort:     "Phải có ít nhất {min} ký tự",
+		TooLong:      "Không được dài quá {max} ký tự",
+		TooManyBytes: "Không được dài quá {max} byte (chữ có dấu chiếm 2-3 byte)",
+		TooFew:       "Phải có ít nhất {min} mục",
+		TooMany:      "Không được nhiều hơn {max} mục",
+		MustBeFuture: "Phải là thời điểm trong tương lai",
+		NotAllowed:   "Giá trị không được hỗ trợ",
+		NotExist:     "Không tồn tại",
+	},
+	"en": {
+		Required:     "Is required",
+		Invalid:      "Is invalid",
+		TooShort:     "Must be at least {min} characters",
+		TooLong:      "Must be at most {max} characters",
+		TooManyBytes: "Must be at most {max} bytes (accented letters take 2-3 bytes)",
+		TooFew:       "Must have at least {min} items",
+		TooMany:      "Must have at most {max} items",
+		MustBeFuture: "Must be in the future",
+		NotAllowed:   "Is not a supported value",
+		NotExist:     "Does not exist",
+	},
+}
+
+// Message: câu thông báo của mã lỗi theo ngôn ngữ, điền tham số {tên}
+func Message(lang string, code Code, params map[string]any) string {
+	msg, ok := messages[lang][code]
+	if !ok {
+		msg, ok = messages[DefaultLanguage][code]
+	}
+	if !ok {
+		msg = messages[lang][InternalError]
+	}
+	return fill(msg, params)
+}
+
+// FieldMessage: câu thông báo cho lỗi của một trường
+func FieldMessage(lang string, f FieldError) string {
+	msg, ok := fieldMessages[lang][f.Code]
+	if !ok {
+		msg = fieldMessages[DefaultLanguage][f.Code]
+	}
+	return fill(msg, f.Params)
+}
+
+func fill(msg string, params map[string]any) string {
+	if len(params) == 0 {
+		return msg
+	}
+	pairs := make([]string, 0, len(params)*2)
+	for key, value := range params {
+		pairs = append(pairs, "{"+key+"}", fmt.Sprint(value))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// Language chọn ngôn ngữ hỗ trợ theo header Accept-Language (có xét trọng số q)
+func Language(acceptLanguage string) string {
+	type candidate struct {
+		lang string
+		q    float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := messages[primary]; !ok {
+			continue
+		}
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{primary, q})
+		}
+	}
+	if len(candidates) == 0 {
+		return DefaultLanguage
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].lang
+}
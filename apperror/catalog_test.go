@@ -0,0 +1,51 @@
+package apperror
+
+import "testing"
+
+// Mỗi mã lỗi phải có status và câu thông báo ở mọi ngôn ngữ hỗ trợ
+func TestCatalogIsComplete(t *testing.T) {
+	for lang, catalog := range messages {
+		for code := range statuses {
+			if catalog[code] == "" {
+				t.Errorf("%s: thiếu câu thông báo cho %s", lang, code)
+			}
+		}
+		for code := range catalog {
+			if _, ok := statuses[code]; !ok {
+				t.Errorf("%s: %s có câu thông báo nhưng chưa có status", lang, code)
+			}
+		}
+		for _, field := range FieldCodes {
+			if fieldMessages[lang][field] == "" {
+				t.Errorf("%s: thiếu câu thông báo cho trường %s", lang, field)
+			}
+		}
+	}
+}
+
+func TestLanguage(t *testing.T) {
+	cases := map[string]string{
+		"":                       "vi",
+		"en":                     "en",
+		"en-GB,en;q=0.8":         "en",
+		"fr, en;q=0.5, vi;q=0.9": "vi",
+		"vi;q=0, en;q=0.1":       "en",
+		"de":                     "vi",
+	}
+	for header, want := range cases {
+		if got := Language(header); got != want {
+			t.Errorf("Language(%q) = %q, muốn %q", header, got, want)
+		}
+	}
+}
+
+func TestMessageFillsParams(t *testing.T) {
+	got := Message("en", IdentityNotFound, map[string]any{"provider": "google"})
+	if got != "No linked google account" {
+		t.Fatalf("Message = %q", got)
+	}
+	field := Field("new_password", TooShort).With("min", 6)
+	if got := FieldMessage("vi", field); got != "Phải có ít nhất 6 ký tự" {
+		t.Fatalf("FieldMessage = %q", got)
+	}
+}
@@ -0,0 +1,173 @@
+package apperror
+
+import (
+	"net/http"
+	"sort"
+)
+
+// Mã lỗi dùng chung
+const (
+	ValidationFailed Code = "VALIDATION_FAILED"
+	NothingToUpdate  Code = "NOTHING_TO_UPDATE"
+	InternalError    Code = "INTERNAL_ERROR"
+	RouteNotFound    Code = "ROUTE_NOT_FOUND"
+	FileTooLarge     Code = "FILE_TOO_LARGE"
+	TooManyAttempts  Code = "TOO_MANY_ATTEMPTS"
+	Forbidden        Code = "FORBIDDEN"
+	SelfAction       Code = "SELF_ACTION"
+)
+
+// Xác thực và tài khoản
+const (
+	Unauthenticated       Code = "UNAUTHENTICATED"
+	TokenInvalid          Code = "TOKEN_INVALID"
+	SessionExpired        Code = "SESSION_EXPIRED"
+	InvalidCredentials    Code = "INVALID_CREDENTIALS"
+	WrongPassword         Code = "WRONG_PASSWORD"
+	ReauthRequired        Code = "REAUTH_REQUIRED"
+	RefreshTokenInvalid   Code = "REFRESH_TOKEN_INVALID"
+	AccountBanned         Code = "ACCOUNT_BANNED"
+	AdminProtected        Code = "ADMIN_PROTECTED"
+	EmailNotVerified      Code = "EMAIL_NOT_VERIFIED"
+	EmailAlreadyVerified  Code = "EMAIL_ALREADY_VERIFIED"
+	EmailMissing          Code = "EMAIL_MISSING"
+	EmailChanged          Code = "EMAIL_CHANGED"
+	LinkInvalid           Code = "LINK_INVALID"
+	UsernameTaken         Code = "USERNAME_TAKEN"
+	EmailTaken            Code = "EMAIL_TAKEN"
+	AccountExists         Code = "ACCOUNT_EXISTS"
+	AccountBeingCreated   Code = "ACCOUNT_BEING_CREATED"
+	OwnsStories           Code = "OWNS_STORIES"
+	MFAPending            Code = "MFA_PENDING"
+	MFATokenExpired       Code = "MFA_TOKEN_EXPIRED"
+	InvalidTOTPCode       Code = "INVALID_TOTP_CODE"
+	TwoFactorRequired     Code = "TWO_FACTOR_REQUIRED"
+	TwoFactorNotSetUp     Code = "TWO_FACTOR_NOT_SET_UP"
+	TwoFactorEnabled      Code = "TWO_FACTOR_ALREADY_ENABLED"
+	TwoFactorDisabled     Code = "TWO_FACTOR_NOT_ENABLED"
+	APIKeyInvalid         Code = "API_KEY_INVALID"
+	APIKeyScopeDenied     Code = "API_KEY_SCOPE_DENIED"
+	APIKeyNotAllowed      Code = "API_KEY_NOT_ALLOWED"
+	TooManyAPIKeys        Code = "TOO_MANY_API_KEYS"
+	OIDCAuthFailed        Code = "OIDC_AUTH_FAILED"
+	OIDCStateInvalid      Code = "OIDC_STATE_INVALID"
+	OIDCStateMismatch     Code = "OIDC_STATE_MISMATCH"
+	OIDCProviderNotFound  Code = "OIDC_PROVIDER_NOT_FOUND"
+	IdentityNotFound      Code = "IDENTITY_NOT_FOUND"
+	IdentityAlreadyLinked Code = "IDENTITY_ALREADY_LINKED"
+	EmailBelongsToAccount Code = "EMAIL_BELONGS_TO_ACCOUNT"
+	LastLoginMethod       Code = "LAST_LOGIN_METHOD"
+	AlreadyAuthor         Code = "ALREADY_AUTHOR"
+	ApplicationPending    Code = "APPLICATION_PENDING"
+	RoleNotPromotable     Code = "ROLE_NOT_PROMOTABLE"
+)
+
+// Truyện, chương, cộng tác
+const (
+	StoryNotFound          Code = "STORY_NOT_FOUND"
+	ChapterNotFound        Code = "CHAPTER_NOT_FOUND"
+	CommentNotFound        Code = "COMMENT_NOT_FOUND"
+	UserNotFound           Code = "USER_NOT_FOUND"
+	SessionNotFound        Code = "SESSION_NOT_FOUND"
+	APIKeyNotFound         Code = "API_KEY_NOT_FOUND"
+	InvitationNotFound     Code = "INVITATION_NOT_FOUND"
+	TransferNotFound       Code = "TRANSFER_NOT_FOUND"
+	ApplicationNotFound    Code = "APPLICATION_NOT_FOUND"
+	CollaboratorNotFound   Code = "COLLABORATOR_NOT_FOUND"
+	BookshelfEntryNotFound Code = "BOOKSHELF_ENTRY_NOT_FOUND"
+	FollowNotFound         Code = "FOLLOW_NOT_FOUND"
+	LockoutNotFound        Code = "LOCKOUT_NOT_FOUND"
+	StoryDeleteForbidden   Code = "STORY_DELETE_FORBIDDEN"
+	StoryChanged           Code = "STORY_CHANGED"
+	InvitationPending      Code = "INVITATION_PENDING"
+	AlreadyCollaborator    Code = "ALREADY_COLLABORATOR"
+	TransferPending        Code = "TRANSFER_PENDING"
+	AlreadyOwner           Code = "ALREADY_OWNER"
+	RecipientNotAuthor     Code = "RECIPIENT_NOT_AUTHOR"
+	AuthorOnly             Code = "AUTHOR_ONLY"
+)
+
+var statuses = map[Code]int{
+	ValidationFailed: http.StatusBadRequest,
+	NothingToUpdate:  http.StatusBadRequest,
+	InternalError:    http.StatusInternalServerError,
+	RouteNotFound:    http.StatusNotFound,
+	FileTooLarge:     http.StatusRequestEntityTooLarge,
+	TooManyAttempts:  http.StatusTooManyRequests,
+	Forbidden:        http.StatusForbidden,
+	SelfAction:       http.StatusBadRequest,
+
+	Unauthenticated:       http.StatusUnauthorized,
+	TokenInvalid:          http.StatusUnauthorized,
+	SessionExpired:        http.StatusUnauthorized,
+	InvalidCredentials:    http.StatusUnauthorized,
+	WrongPassword:         http.StatusUnauthorized,
+	ReauthRequired:        http.StatusUnauthorized,
+	RefreshTokenInvalid:   http.StatusUnauthorized,
+	AccountBanned:         http.StatusForbidden,
+	AdminProtected:        http.StatusForbidden,
+	EmailNotVerified:      http.StatusForbidden,
+	EmailAlreadyVerified:  http.StatusBadRequest,
+	EmailMissing:          http.StatusBadRequest,
+	EmailChanged:          http.StatusBadRequest,
+	LinkInvalid:           http.StatusBadRequest,
+	UsernameTaken:         http.StatusConflict,
+	EmailTaken:            http.StatusConflict,
+	AccountExists:         http.StatusConflict,
+	AccountBeingCreated:   http.StatusConflict,
+	OwnsStories:           http.StatusConflict,
+	MFAPending:            http.StatusUnauthorized,
+	MFATokenExpired:       http.StatusUnauthorized,
+	InvalidTOTPCode:       http.StatusUnauthorized,
+	TwoFactorRequired:     http.StatusForbidden,
+	TwoFactorNotSetUp:     http.StatusBadRequest,
+	TwoFactorEnabled:      http.StatusBadRequest,
+	TwoFactorDisabled:     http.StatusBadRequest,
+	APIKeyInvalid:         http.StatusUnauthorized,
+	APIKeyScopeDenied:     http.StatusForbidden,
+	APIKeyNotAllowed:      http.StatusForbidden,
+	TooManyAPIKeys:        http.StatusBadRequest,
+	OIDCAuthFailed:        http.StatusUnauthorized,
+	OIDCStateInvalid:      http.StatusBadRequest,
+	OIDCStateMismatch:     http.StatusBadRequest,
+	OIDCProviderNotFound:  http.StatusNotFound,
+	IdentityNotFound:      http.StatusNotFound,
+	IdentityAlreadyLinked: http.StatusConflict,
+	EmailBelongsToAccount: http.StatusConflict,
+	LastLoginMethod:       http.StatusBadRequest,
+	AlreadyAuthor:         http.StatusBadRequest,
+	ApplicationPending:    http.StatusConflict,
+	RoleNotPromotable:     http.StatusConflict,
+
+	StoryNotFound:          http.StatusNotFound,
+	ChapterNotFound:        http.StatusNotFound,
+	CommentNotFound:        http.StatusNotFound,
+	UserNotFound:           http.StatusNotFound,
+	SessionNotFound:        http.StatusNotFound,
+	APIKeyNotFound:         http.StatusNotFound,
+	InvitationNotFound:     http.StatusNotFound,
+	TransferNotFound:       http.StatusNotFound,
+	ApplicationNotFound:    http.StatusNotFound,
+	CollaboratorNotFound:   http.StatusNotFound,
+	BookshelfEntryNotFound: http.StatusNotFound,
+	FollowNotFound:         http.StatusNotFound,
+	LockoutNotFound:        http.StatusNotFound,
+	StoryDeleteForbidden:   http.StatusForbidden,
+	StoryChanged:           http.StatusConflict,
+	InvitationPending:      http.StatusConflict,
+	AlreadyCollaborator:    http.StatusConflict,
+	TransferPending:        http.StatusConflict,
+	AlreadyOwner:           http.StatusBadRequest,
+	RecipientNotAuthor:     http.StatusBadRequest,
+	AuthorOnly:             http.StatusForbidden,
+}
+
+// Codes: mọi mã lỗi đã khai báo, sắp theo tên
+func Codes() []Code {
+	codes := make([]Code, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i] < codes[j] })
+	return codes
+}
@@ -2,21 +2,46 @@ package main
 
 import (
 	"Truyen_BE/config"
+	"Truyen_BE/logging"
+	"Truyen_BE/mailer"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
 	"Truyen_BE/routes"
+	"Truyen_BE/sso"
+	"Truyen_BE/throttle"
 	"Truyen_BE/utils"
+	"context"
 	"log"
+	"net/http"
 	"os"
-	"path/filepath"
+	"os/signal"
+	"syscall"
 
 	"time"
-
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
 )
 
 func main() {
-	// Load các biến môi trường
-	config.LoadEnv()
+	// Nạp cấu hình: mặc định → file (--config) → biến môi trường/.env → flag
+	printConfig, err := config.Load(os.Args[1:])
+	if err != nil {
+		log.Fatal("❌ Không đọc được cấu hình:", err)
+	}
+	logging.Init(config.App.Log)
+	if printConfig {
+		config.App.PrintRedacted(os.Stdout)
+		if err := config.App.Validate(); err != nil {
+			log.Fatal("❌ Cấu hình không hợp lệ:\n", err)
+		}
+		return
+	}
+	if err := config.App.Validate(); err != nil {
+		log.Fatal("❌ Cấu hình không hợp lệ:\n", err)
+	}
+
+	// Thư mục upload phải có sẵn và ghi được, không đợi lần upload đầu tiên mới phát hiện lỗi
+	if err := os.MkdirAll(config.App.Upload.Dir, 0o755); err != nil {
+		log.Fatal("❌ Không thể tạo thư mục upload:", err)
+	}
 
 	// Kết nối MongoDB
 	config.ConnectDB()
@@ -25,32 +50,53 @@ func main() {
 	if config.MongoDB == nil {
 		log.Fatal("❌ Kết nối MongoDB không thành công!")
 	}
-	var FE_URL = os.Getenv("FE_URL")
-	// Khởi tạo Gin
-	r := gin.Default()
-	// Cấu hình CORS
-	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{FE_URL},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With"},
-		ExposeHeaders:    []string{"Content-Length", "Content-Type"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}))
-
-	// Gắn các routes
-	routes.StoryRoutes(r)
-	routes.ChapterRoutes(r)
-	routes.UserRoutes(r)
-	routes.BookshelfRoutes(r)
-	uploadDir, _ := filepath.Abs("./uploads")
-	r.Static("/static", uploadDir)
-	r.POST("/upload", utils.UploadImage)
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
-	}
-
-	r.Run(":" + port)
-	log.Fatal(r.Run(port))
+	config.EnsureIndexes()
+	if err := utils.LoadJWTKeys(config.App.JWT); err != nil {
+		log.Fatal("❌ Không thể nạp khoá ký JWT:", err)
+	}
+	mailer.Init(config.App.Mail)
+	throttle.Init(config.MongoDB.Collection("LoginAttempts"))
+	sso.Init()
+
+	// Nạp phân quyền từ DB (ghi đè cấu hình mặc định) và tự nạp lại mỗi phút
+	rolesCollection := config.MongoDB.Collection("Roles")
+	if err := policy.Load(context.Background(), rolesCollection); err != nil {
+		log.Fatal("❌ Không thể nạp phân quyền:", err)
+	}
+	// Role tác giả chỉ biết được sau khi nạp phân quyền (role có thể định nghĩa trong DB)
+	if role, ok := policy.Get(config.App.Auth.AuthorRole); !ok || !role.Has(policy.StoryCreate) {
+		log.Fatalf("❌ Cấu hình không hợp lệ: AUTHOR_ROLE %q không tồn tại hoặc không có quyền %s", config.App.Auth.AuthorRole, policy.StoryCreate)
+	}
+	policy.StartAutoReload(rolesCollection, time.Minute)
+	// Khởi tạo Gin với đầy đủ middleware và routes
+	r := routes.NewRouter(repository.NewMongo(config.MongoDB))
+
+	srv := &http.Server{
+		Addr:         ":" + config.App.Server.Port,
+		Handler:      r,
+		ReadTimeout:  config.App.Server.ReadTimeout,
+		WriteTimeout: config.App.Server.WriteTimeout,
+		IdleTimeout:  config.App.Server.IdleTimeout,
+	}
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal("❌ Lỗi khởi động server:", err)
+		}
+	}()
+	log.Printf("🚀 Server đang chạy tại cổng %s", config.App.Server.Port)
+
+	// Chờ SIGINT/SIGTERM rồi tắt êm: ngừng nhận request mới, chờ request đang chạy xong
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+	stop()
+	log.Println("⏳ Đang tắt server...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.App.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Println("❌ Hết thời gian chờ request đang xử lý:", err)
+	}
+	config.DisconnectDB(shutdownCtx)
+	log.Println("👋 Server đã dừng")
 }
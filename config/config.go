@@ -1,11 +1,10 @@
 package config
 
 import (
+	"Truyen_BE/metrics"
 	"context"
-	"fmt"
 	"log"
 	"os"
-	"time"
 
 	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -20,28 +19,32 @@ func LoadEnv() {
 	if err != nil {
 		log.Println("❌ Không thể lấy thư mục hiện tại:", err)
 	} else {
-		fmt.Println("📂 Đang chạy tại thư mục:", dir)
+		log.Println("📂 Đang chạy tại thư mục:", dir)
 	}
 
 	err = godotenv.Load()
 	if err != nil {
 		log.Println("⚠️ Không tìm thấy .env → dùng ENV của hệ thống")
 	} else {
-		fmt.Println("✅ Đã load file .env")
+		log.Println("✅ Đã load file .env")
 	}
 }
 func ConnectDB() {
-	uri := os.Getenv("MONGO_URI")
-	dbName := os.Getenv("DB_NAME")
+	uri := App.Mongo.URI
+	dbName := App.Mongo.DBName
 
 	ctx := context.Background()
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	client, err := mongo.Connect(ctx, options.Client().
+		ApplyURI(uri).
+		SetConnectTimeout(App.Mongo.ConnectTimeout).
+		SetServerSelectionTimeout(App.Mongo.ConnectTimeout).
+		SetMonitor(metrics.MongoMonitor()))
 	if err != nil {
 		log.Fatal("❌ Lỗi kết nối MongoDB:", err)
 	}
 
-	ctxPing, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctxPing, cancel := context.WithTimeout(context.Background(), App.Mongo.ConnectTimeout)
 	defer cancel()
 
 	err = client.Ping(ctxPing, nil)
@@ -53,3 +56,15 @@ func ConnectDB() {
 	MongoDB = client.Database(dbName)
 	log.Println("✅ Đã kết nối MongoDB thành công!")
 }
+
+// DisconnectDB đóng kết nối MongoDB (gọi khi tắt server)
+func DisconnectDB(ctx context.Context) {
+	if MongoClient == nil {
+		return
+	}
+	if err := MongoClient.Disconnect(ctx); err != nil {
+		log.Println("❌ Lỗi khi đóng kết nối MongoDB:", err)
+		return
+	}
+	log.Println("✅ Đã đóng kết nối MongoDB")
+}
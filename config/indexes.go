@@ -0,0 +1,93 @@
+package config
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// EnsureIndexes tạo các index cần thiết (idempotent, chạy mỗi lần khởi động)
+func EnsureIndexes() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	indexes := map[string][]mongo.IndexModel{
+		"Users": {
+			{Keys: bson.D{{Key: "username", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// email không bắt buộc → unique chỉ áp dụng cho document có email
+			{Keys: bson.D{{Key: "email", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"email": bson.M{"$type": "string"}})},
+		},
+		"AuthorApplications": {
+			// mỗi user chỉ có tối đa một đơn đang chờ duyệt
+			{Keys: bson.D{{Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "pending"})},
+			{Keys: bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		"APIKeys": {
+			{Keys: bson.D{{Key: "key_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+		},
+		"EmailVerifications": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"UserAuditLogs": {
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"Sessions": {
+			{Keys: bson.D{{Key: "refresh_token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "previous_token_hash", Value: 1}}},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			// MongoDB tự xoá phiên khi quá expires_at
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"Follows": {
+			{Keys: bson.D{{Key: "follower_id", Value: 1}, {Key: "followee_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "followee_id", Value: 1}}},
+		},
+		"Stories": {
+			{Keys: bson.D{{Key: "collaborators.user_id", Value: 1}}},
+		},
+		"StoryInvitations": {
+			// mỗi người chỉ có tối đa một lời mời đang chờ cho mỗi truyện
+			{Keys: bson.D{{Key: "story_id", Value: 1}, {Key: "invitee_id", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "pending"})},
+			{Keys: bson.D{{Key: "invitee_id", Value: 1}, {Key: "status", Value: 1}}},
+		},
+		"StoryTransfers": {
+			// mỗi truyện chỉ có tối đa một đề nghị chuyển đang chờ
+			{Keys: bson.D{{Key: "story_id", Value: 1}}, Options: options.Index().SetUnique(true).
+				SetPartialFilterExpression(bson.M{"status": "pending"})},
+			{Keys: bson.D{{Key: "to_user_id", Value: 1}, {Key: "status", Value: 1}}},
+		},
+		"UserIdentities": {
+			{Keys: bson.D{{Key: "provider", Value: 1}, {Key: "subject", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// mỗi user liên kết tối đa một tài khoản cho mỗi provider
+			{Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "provider", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"OIDCStates": {
+			{Keys: bson.D{{Key: "state_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"LoginAttempts": {
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+		"PasswordResets": {
+			{Keys: bson.D{{Key: "token_hash", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: bson.D{{Key: "user_id", Value: 1}}},
+			{Keys: bson.D{{Key: "expires_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(0)},
+		},
+	}
+
+	for collection, models := range indexes {
+		if _, err := MongoDB.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			log.Printf("⚠️ Không thể tạo index cho %s: %v", collection, err)
+		}
+	}
+}
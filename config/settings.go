@@ -0,0 +1,384 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config: toàn bộ cấu hình ứng dụng. Thứ tự ưu tiên (sau ghi đè trước):
+// giá trị mặc định → file JSON (--config hoặc CONFIG_FILE) → biến môi trường (kể cả .env) → flag dòng lệnh.
+//
+// Tag: json = đường dẫn trong file, env = tên biến môi trường, flag = tên flag (nếu có),
+// secret = không in ra ở --print-config. Cấu hình OIDC theo từng provider (OIDC_<TÊN>_*) vẫn do package sso đọc.
+type Config struct {
+	Env         string `json:"env" env:"APP_ENV" flag:"env"` // "development" | "production"
+	FrontendURL string `json:"frontend_url" env:"FE_URL"`    // dùng để tạo link trong email
+
+	Server ServerConfig `json:"server"`
+	CORS   CORSConfig   `json:"cors"`
+	Mongo  MongoConfig  `json:"mongo"`
+	JWT    JWTConfig    `json:"jwt"`
+	Upload UploadConfig `json:"upload"`
+	Mail   MailConfig   `json:"mail"`
+	Auth   AuthConfig   `json:"auth"`
+	Log    LogConfig    `json:"log"`
+}
+
+type ServerConfig struct {
+	Port         string        `json:"port" env:"PORT" flag:"port"`
+	ReadTimeout  time.Duration `json:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout time.Duration `json:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout  time.Duration `json:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout: thời gian tối đa chờ các request đang chạy khi nhận SIGTERM
+	ShutdownTimeout time.Duration `json:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT"`
+}
+
+type CORSConfig struct {
+	AllowedOrigins []string `json:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" flag:"cors-origins"` // mặc định = FE_URL
+}
+
+type MongoConfig struct {
+	URI            string        `json:"uri" env:"MONGO_URI" flag:"mongo-uri" secret:"true"` // URI thường chứa mật khẩu
+	DBName         string        `json:"db_name" env:"DB_NAME" flag:"db-name"`
+	ConnectTimeout time.Duration `json:"connect_timeout" env:"MONGO_CONNECT_TIMEOUT"`
+}
+
+type JWTConfig struct {
+	SigningKeyFile string   `json:"signing_key_file" env:"JWT_SIGNING_KEY_FILE"`
+	VerifyKeyFiles []string `json:"verify_key_files" env:"JWT_VERIFY_KEY_FILES"`
+}
+
+type UploadConfig struct {
+	Dir      string `json:"dir" env:"UPLOAD_DIR"`
+	MaxBytes int64  `json:"max_bytes" env:"UPLOAD_MAX_BYTES"`
+}
+
+type MailConfig struct {
+	Driver       string `json:"driver" env:"MAIL_DRIVER"` // "log" | "smtp"
+	LogFile      string `json:"log_file" env:"MAIL_LOG_FILE"`
+	SMTPHost     string `json:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     string `json:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `json:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `json:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From         string `json:"from" env:"MAIL_FROM"`
+}
+
+type AuthConfig struct {
+	RequireVerifiedEmail bool   `json:"require_verified_email" env:"REQUIRE_VERIFIED_EMAIL"`
+	RequireAdmin2FA      bool   `json:"require_admin_2fa" env:"REQUIRE_ADMIN_2FA"`
+	TOTPIssuer           string `json:"totp_issuer" env:"TOTP_ISSUER"`
+	LoginThrottleStore   string `json:"login_throttle_store" env:"LOGIN_THROTTLE_STORE"` // "mongo" | "memory"
+	AuthorRole           string `json:"author_role" env:"AUTHOR_ROLE"`                   // role được gán khi duyệt đơn đăng ký tác giả
+}
+
+type LogConfig struct {
+	Level string `json:"level" env:"LOG_LEVEL" flag:"log-level"` // "debug" | "info" | "warn" | "error"
+}
+
+// Defaults: cấu hình khi không khai báo gì
+func Defaults() Config {
+	return Config{
+		Env: "development",
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 20 * time.Second,
+		},
+		Mongo:  MongoConfig{ConnectTimeout: 10 * time.Second},
+		Upload: UploadConfig{Dir: "./uploads", MaxBytes: 5 << 20},
+		Mail:   MailConfig{Driver: "log", SMTPPort: "587"},
+		Auth:   AuthConfig{TOTPIssuer: "Truyen", LoginThrottleStore: "mongo", AuthorRole: "author"},
+		Log:    LogConfig{Level: "info"},
+	}
+}
+
+// App là cấu hình đang dùng, được nạp trong Load()
+var App = Defaults()
+
+// setting: một trường lá của Config cùng các tag
+type setting struct {
+	path   string // "server.port"
+	env    string
+	flag   string
+	secret bool
+	value  reflect.Value
+}
+
+func collectSettings(v reflect.Value, prefix string, out *[]setting) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		path := strings.Split(field.Tag.Get("json"), ",")[0]
+		if prefix != "" {
+			path = prefix + "." + path
+		}
+		if field.Type.Kind() == reflect.Struct {
+			collectSettings(v.Field(i), path, out)
+			continue
+		}
+		*out = append(*out, setting{
+			path:   path,
+			env:    field.Tag.Get("env"),
+			flag:   field.Tag.Get("flag"),
+			secret: field.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+}
+
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch {
+	case s.value.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%s: thời lượng không hợp lệ %q (vd. 15s, 2m)", s.path, raw)
+		}
+		s.value.SetInt(int64(d))
+	case s.value.Kind() == reflect.String:
+		s.value.SetString(raw)
+	case s.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%s: cần true/false, nhận %q", s.path, raw)
+		}
+		s.value.SetBool(b)
+	case s.value.Kind() == reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: cần số nguyên, nhận %q", s.path, raw)
+		}
+		s.value.SetInt(n)
+	case s.value.Kind() == reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		s.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("%s: kiểu %s chưa được hỗ trợ", s.path, s.value.Type())
+	}
+	return nil
+}
+
+// applyFile đọc file JSON lồng nhau theo tag json, vd. {"server": {"port": "8080"}}
+func applyFile(settings []setting, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("đọc file cấu hình: %w", err)
+	}
+	var tree map[string]any
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return fmt.Errorf("file cấu hình %s: %w", path, err)
+	}
+
+	for _, s := range settings {
+		var node any = tree
+		for _, key := range strings.Split(s.path, ".") {
+			m, ok := node.(map[string]any)
+			if !ok {
+				node = nil
+				break
+			}
+			node = m[key]
+		}
+		if node == nil {
+			continue
+		}
+
+		var raw string
+		switch value := node.(type) {
+		case []any:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				items = append(items, fmt.Sprint(item))
+			}
+			raw = strings.Join(items, ",")
+		case float64:
+			raw = strconv.FormatFloat(value, 'f', -1, 64)
+		default:
+			raw = fmt.Sprint(value)
+		}
+		if err := s.set(raw); err != nil {
+			return fmt.Errorf("file cấu hình %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// Load nạp cấu hình vào App từ mọi nguồn. Trả printOnly = true khi chạy với --print-config.
+// Lỗi trả về là lỗi đọc cấu hình; kiểm tra giá trị nằm ở Validate().
+func Load(args []string) (printOnly bool, err error) {
+	LoadEnv()
+
+	cfg := Defaults()
+	var settings []setting
+	collectSettings(reflect.ValueOf(&cfg).Elem(), "", &settings)
+
+	fs := flag.NewFlagSet("truyen", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "đường dẫn file cấu hình JSON")
+	fs.BoolVar(&printOnly, "print-config", false, "in cấu hình (đã ẩn bí mật) rồi thoát")
+	flagValues := map[string]*string{}
+	for _, s := range settings {
+		if s.flag != "" {
+			flagValues[s.flag] = fs.String(s.flag, "", "ghi đè "+s.env)
+		}
+	}
+	if err := fs.Parse(args); err != nil {
+		return false, err
+	}
+
+	if *configFile != "" {
+		if err := applyFile(settings, *configFile); err != nil {
+			return printOnly, err
+		}
+	}
+
+	for _, s := range settings {
+		if raw, ok := os.LookupEnv(s.env); ok && s.env != "" {
+			if err := s.set(raw); err != nil {
+				return printOnly, fmt.Errorf("biến môi trường %s: %w", s.env, err)
+			}
+		}
+	}
+
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				flagErr = s.set(*flagValues[f.Name])
+			}
+		}
+	})
+	if flagErr != nil {
+		return printOnly, fmt.Errorf("flag: %w", flagErr)
+	}
+
+	if len(cfg.CORS.AllowedOrigins) == 0 && cfg.FrontendURL != "" {
+		cfg.CORS.AllowedOrigins = []string{cfg.FrontendURL}
+	}
+	if os.Getenv("JWT_SECRET") != "" {
+		log.Println("⚠️ JWT_SECRET không còn được dùng, token được ký bằng JWT_SIGNING_KEY_FILE")
+	}
+
+	App = cfg
+	return printOnly, nil
+}
+
+// Validate kiểm tra cấu hình trước khi khởi động, trả về mọi lỗi cùng lúc
+func (c Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) { errs = append(errs, fmt.Errorf(format, args...)) }
+
+	if c.Env != "development" && c.Env != "production" {
+		add("APP_ENV phải là development hoặc production, nhận %q", c.Env)
+	}
+	if c.Mongo.URI == "" {
+		add("MONGO_URI là bắt buộc")
+	}
+	if c.Mongo.DBName == "" {
+		add("DB_NAME là bắt buộc")
+	}
+	// Production không được chạy với khoá ký tạm (mỗi lần khởi động là một khoá khác)
+	if c.Env == "production" && c.JWT.SigningKeyFile == "" {
+		add("JWT_SIGNING_KEY_FILE là bắt buộc khi APP_ENV=production")
+	}
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		add("PORT không hợp lệ: %q", c.Server.Port)
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"HTTP_READ_TIMEOUT", c.Server.ReadTimeout},
+		{"HTTP_WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"HTTP_IDLE_TIMEOUT", c.Server.IdleTimeout},
+		{"HTTP_SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"MONGO_CONNECT_TIMEOUT", c.Mongo.ConnectTimeout},
+	} {
+		if timeout.value <= 0 {
+			add("%s phải lớn hơn 0", timeout.name)
+		}
+	}
+	if len(c.CORS.AllowedOrigins) == 0 {
+		add("cần FE_URL hoặc CORS_ALLOWED_ORIGINS")
+	}
+	for _, origin := range c.CORS.AllowedOrigins {
+		// Cho phép gửi cookie/Authorization nên không được dùng "*"
+		if u, err := url.Parse(origin); origin == "*" || err != nil || u.Scheme == "" || u.Host == "" {
+			add("CORS origin không hợp lệ: %q", origin)
+		}
+	}
+	if c.Upload.Dir == "" {
+		add("UPLOAD_DIR không được rỗng")
+	}
+	if c.Upload.MaxBytes <= 0 {
+		add("UPLOAD_MAX_BYTES phải lớn hơn 0")
+	}
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		if c.Mail.SMTPHost == "" || c.Mail.From == "" {
+			add("MAIL_DRIVER=smtp cần SMTP_HOST và MAIL_FROM")
+		}
+	default:
+		add("MAIL_DRIVER phải là log hoặc smtp, nhận %q", c.Mail.Driver)
+	}
+	if c.Auth.LoginThrottleStore != "mongo" && c.Auth.LoginThrottleStore != "memory" {
+		add("LOGIN_THROTTLE_STORE phải là mongo hoặc memory, nhận %q", c.Auth.LoginThrottleStore)
+	}
+	switch c.Log.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		add("LOG_LEVEL phải là debug, info, warn hoặc error, nhận %q", c.Log.Level)
+	}
+
+	return errors.Join(errs...)
+}
+
+// PrintRedacted in cấu hình dạng JSON, giá trị bí mật được thay bằng "***"
+func (c Config) PrintRedacted(w io.Writer) error {
+	var settings []setting
+	collectSettings(reflect.ValueOf(&c).Elem(), "", &settings)
+
+	tree := map[string]any{}
+	for _, s := range settings {
+		var value any = s.value.Interface()
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		if s.secret && !s.value.IsZero() {
+			value = "***"
+		}
+
+		node := tree
+		keys := strings.Split(s.path, ".")
+		for _, key := range keys[:len(keys)-1] {
+			child, ok := node[key].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[key] = child
+			}
+			node = child
+		}
+		node[keys[len(keys)-1]] = value
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(tree)
+}
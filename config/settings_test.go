@@ -0,0 +1,272 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Các test ở đây đổi biến môi trường và App nên không chạy song song
+
+// isolate xoá mọi biến môi trường Load đọc và khôi phục App sau test
+func isolate(t *testing.T) {
+	t.Helper()
+	cfg := Defaults()
+	var settings []setting
+	collectSettings(reflect.ValueOf(&cfg).Elem(), "", &settings)
+
+	names := []string{"CONFIG_FILE", "JWT_SECRET"}
+	for _, s := range settings {
+		if s.env != "" {
+			names = append(names, s.env)
+		}
+	}
+	for _, name := range names {
+		t.Setenv(name, "") // để t khôi phục giá trị cũ khi kết thúc
+		os.Unsetenv(name)
+	}
+
+	previous := App
+	t.Cleanup(func() { App = previous })
+}
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := `{
+		"server": {"port": "7001", "read_timeout": "20s"},
+		"mongo": {"db_name": "file_db", "uri": "mongodb://file"},
+		"cors": {"allowed_origins": ["https://file.example", "https://file2.example"]},
+		"upload": {"max_bytes": 1024},
+		"auth": {"require_verified_email": true},
+		"log": {"level": "warn"}
+	}`
+
+	cases := []struct {
+		name string
+		file string
+		env  map[string]string
+		args []string
+		got  func(Config) any
+		want any
+	}{
+		{name: "mặc định", got: func(c Config) any { return c.Server.Port }, want: "8080"},
+		{name: "file ghi đè mặc định", file: file,
+			got: func(c Config) any { return c.Server.Port }, want: "7001"},
+		{name: "env ghi đè file", file: file, env: map[string]string{"PORT": "7002"},
+			got: func(c Config) any { return c.Server.Port }, want: "7002"},
+		{name: "flag ghi đè env", file: file, env: map[string]string{"PORT": "7002"}, args: []string{"--port", "7003"},
+			got: func(c Config) any { return c.Server.Port }, want: "7003"},
+		{name: "trường không khai báo giữ mặc định", file: file, env: map[string]string{"PORT": "7002"},
+			got: func(c Config) any { return c.Server.WriteTimeout }, want: 30 * time.Second},
+		{name: "thời lượng từ file", file: file,
+			got: func(c Config) any { return c.Server.ReadTimeout }, want: 20 * time.Second},
+		{name: "thời lượng từ env", file: file, env: map[string]string{"HTTP_READ_TIMEOUT": "2m"},
+			got: func(c Config) any { return c.Server.ReadTimeout }, want: 2 * time.Minute},
+		{name: "số từ file", file: file,
+			got: func(c Config) any { return c.Upload.MaxBytes }, want: int64(1024)},
+		{name: "bool từ file", file: file,
+			got: func(c Config) any { return c.Auth.RequireVerifiedEmail }, want: true},
+		{name: "bool từ env ghi đè file", file: file, env: map[string]string{"REQUIRE_VERIFIED_EMAIL": "false"},
+			got: func(c Config) any { return c.Auth.RequireVerifiedEmail }, want: false},
+		{name: "mảng từ file", file: file,
+			got: func(c Config) any { return c.CORS.AllowedOrigins }, want: []string{"https://file.example", "https://file2.example"}},
+		{name: "danh sách từ env bỏ khoảng trắng và phần tử rỗng", file: file,
+			env: map[string]string{"CORS_ALLOWED_ORIGINS": " https://a.example, ,https://b.example "},
+			got: func(c Config) any { return c.CORS.AllowedOrigins }, want: []string{"https://a.example", "https://b.example"}},
+		{name: "danh sách từ flag", file: file, env: map[string]string{"CORS_ALLOWED_ORIGINS": "https://env.example"},
+			args: []string{"--cors-origins", "https://flag.example"},
+			got:  func(c Config) any { return c.CORS.AllowedOrigins }, want: []string{"https://flag.example"}},
+		{name: "flag ghi đè file", file: file, args: []string{"--log-level", "debug"},
+			got: func(c Config) any { return c.Log.Level }, want: "debug"},
+		{name: "env ghi đè file cho chuỗi", file: file, env: map[string]string{"LOG_LEVEL": "error"},
+			got: func(c Config) any { return c.Log.Level }, want: "error"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t)
+			if tc.file != "" {
+				t.Setenv("CONFIG_FILE", writeConfigFile(t, tc.file))
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			printOnly, err := Load(tc.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if printOnly {
+				t.Fatal("printOnly phải là false khi không có --print-config")
+			}
+			if got := tc.got(App); !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("= %#v, muốn %#v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFileFlagOverridesEnv(t *testing.T) {
+	isolate(t)
+	t.Setenv("CONFIG_FILE", writeConfigFile(t, `{"mongo": {"db_name": "env_file"}}`))
+	flagFile := writeConfigFile(t, `{"mongo": {"db_name": "flag_file"}}`)
+
+	if _, err := Load([]string{"--config", flagFile}); err != nil {
+		t.Fatal(err)
+	}
+	if App.Mongo.DBName != "flag_file" {
+		t.Fatalf("db_name = %q, muốn flag_file", App.Mongo.DBName)
+	}
+}
+
+func TestLoadParseErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		file    string
+		env     map[string]string
+		args    []string
+		wantErr string
+	}{
+		{name: "thời lượng thiếu đơn vị trong env", env: map[string]string{"HTTP_READ_TIMEOUT": "15"}, wantErr: "server.read_timeout"},
+		{name: "thời lượng sai trong file", file: `{"mongo": {"connect_timeout": "nhanh"}}`, wantErr: "mongo.connect_timeout"},
+		{name: "thời lượng dạng số trong file", file: `{"server": {"idle_timeout": 60}}`, wantErr: "server.idle_timeout"},
+		{name: "bool sai trong env", env: map[string]string{"REQUIRE_ADMIN_2FA": "có"}, wantErr: "auth.require_admin_2fa"},
+		{name: "bool sai trong file", file: `{"auth": {"require_verified_email": "yes"}}`, wantErr: "auth.require_verified_email"},
+		{name: "số sai trong env", env: map[string]string{"UPLOAD_MAX_BYTES": "5MB"}, wantErr: "upload.max_bytes"},
+		{name: "số thực trong file", file: `{"upload": {"max_bytes": 1.5}}`, wantErr: "upload.max_bytes"},
+		{name: "file không phải JSON", file: `{"server":`, wantErr: "file cấu hình"},
+		{name: "flag lạ", args: []string{"--khong-co"}, wantErr: "khong-co"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t)
+			if tc.file != "" {
+				t.Setenv("CONFIG_FILE", writeConfigFile(t, tc.file))
+			}
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			before := App
+			_, err := Load(tc.args)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("lỗi = %v, muốn chứa %q", err, tc.wantErr)
+			}
+			if !reflect.DeepEqual(App, before) {
+				t.Fatal("Load lỗi không được thay App")
+			}
+		})
+	}
+}
+
+func TestLoadMissingConfigFile(t *testing.T) {
+	isolate(t)
+	_, err := Load([]string{"--config", filepath.Join(t.TempDir(), "khong-co.json")})
+	if err == nil || !strings.Contains(err.Error(), "đọc file cấu hình") {
+		t.Fatalf("lỗi = %v", err)
+	}
+}
+
+func TestCORSDefaultsToFrontendURL(t *testing.T) {
+	cases := []struct {
+		name string
+		env  map[string]string
+		want []string
+	}{
+		{name: "chỉ có FE_URL", env: map[string]string{"FE_URL": "https://truyen.example"}, want: []string{"https://truyen.example"}},
+		{name: "CORS khai báo riêng", env: map[string]string{
+			"FE_URL":               "https://truyen.example",
+			"CORS_ALLOWED_ORIGINS": "https://admin.example",
+		}, want: []string{"https://admin.example"}},
+		{name: "không có cả hai", want: nil},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			isolate(t)
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			if _, err := Load(nil); err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(App.CORS.AllowedOrigins, tc.want) {
+				t.Fatalf("allowed_origins = %v, muốn %v", App.CORS.AllowedOrigins, tc.want)
+			}
+		})
+	}
+}
+
+func TestPrintConfigRedactsSecrets(t *testing.T) {
+	isolate(t)
+	const mongoURI = "mongodb://admin:mat-khau-mongo@db:27017"
+	const smtpPassword = "mat-khau-smtp"
+	t.Setenv("SMTP_PASSWORD", smtpPassword)
+	t.Setenv("SMTP_USERNAME", "mailer")
+
+	printOnly, err := Load([]string{"--print-config", "--mongo-uri", mongoURI})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !printOnly {
+		t.Fatal("--print-config phải trả printOnly = true")
+	}
+
+	var out bytes.Buffer
+	if err := App.PrintRedacted(&out); err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{mongoURI, "mat-khau-mongo", smtpPassword} {
+		if strings.Contains(out.String(), secret) {
+			t.Fatalf("lộ bí mật %q:\n%s", secret, out.String())
+		}
+	}
+
+	var printed struct {
+		Server struct {
+			ReadTimeout string `json:"read_timeout"`
+		} `json:"server"`
+		Mongo struct {
+			URI string `json:"uri"`
+		} `json:"mongo"`
+		Mail struct {
+			SMTPUsername string `json:"smtp_username"`
+			SMTPPassword string `json:"smtp_password"`
+		} `json:"mail"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("output không phải JSON: %v\n%s", err, out.String())
+	}
+	if printed.Mongo.URI != "***" || printed.Mail.SMTPPassword != "***" {
+		t.Fatalf("bí mật phải in thành ***: %+v", printed)
+	}
+	// Giá trị không bí mật in nguyên, thời lượng in dạng chuỗi
+	if printed.Mail.SMTPUsername != "mailer" || printed.Server.ReadTimeout != "15s" {
+		t.Fatalf("giá trị thường in sai: %+v", printed)
+	}
+}
+
+func TestPrintRedactedLeavesEmptySecretsEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := Defaults().PrintRedacted(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "***") {
+		t.Fatalf("bí mật rỗng không cần che:\n%s", out.String())
+	}
+}
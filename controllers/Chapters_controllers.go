@@ -1,75 +1,61 @@
 package controllers
 
 import (
-	"Truyen_BE/config"
+	"Truyen_BE/apperror"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/metrics"
 	"Truyen_BE/models"
+	"Truyen_BE/repository"
 	"context"
 	"fmt"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-type ChapterInput struct {
-	StoryID string `json:"story_id"`
-	Title   string `json:"title"`
-	Content string `json:"content"`
-}
-
 // POST /chapters
-func InsertChapter(c *gin.Context) {
-	var newChapter models.Chapter
-	if err := c.ShouldBindJSON(&newChapter); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Lỗi dữ liệu đầu vào"})
+func (h *Handler) InsertChapter(c *gin.Context) {
+	var input dto.CreateChapter
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
 		return
 	}
 
-	if newChapter.StoryID.IsZero() {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu story_id"})
-		return
+	storyID, _ := primitive.ObjectIDFromHex(input.StoryID) // đã kiểm tra bằng tag objectid
+	newChapter := models.Chapter{
+		ID:        primitive.NewObjectID(),
+		StoryID:   storyID,
+		Title:     strings.TrimSpace(input.Title),
+		Content:   input.Content,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 
-	newChapter.ID = primitive.NewObjectID()
-	newChapter.CreatedAt = time.Now()
-	newChapter.UpdatedAt = time.Now()
-	newChapter.ViewCount = 0
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	storyCollection := config.MongoDB.Collection("Stories")
-
-	chapterCount, err := chapterCollection.CountDocuments(ctx, bson.M{"story_id": newChapter.StoryID})
+	// Đánh số tiếp theo số lớn nhất (không theo số lượng chương) để không trùng số
+	lastNumber, err := h.repos.Chapters.MaxNumber(ctx, newChapter.StoryID)
 	if err != nil {
-		log.Printf("❌ Lỗi khi đếm số chương: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể xác định số chương"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
-	newChapter.ChapterNumber = int(chapterCount) + 1
+	newChapter.ChapterNumber = lastNumber + 1
 
-	_, err = chapterCollection.InsertOne(ctx, newChapter)
+	err = h.repos.Chapters.Insert(ctx, newChapter)
 	if err != nil {
-		log.Printf("❌ Lỗi khi chèn chương: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể thêm chương"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
-	_, _ = storyCollection.UpdateOne(ctx,
-		bson.M{"_id": newChapter.StoryID},
-		bson.M{
-			"$inc": bson.M{"chapters_count": 1},
-			"$set": bson.M{"updated_at": time.Now()},
-		},
-	)
+	_ = h.repos.Stories.IncrementChapterCount(ctx, newChapter.StoryID, 1)
+	metrics.ChaptersPublished.Inc()
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":        "✅ Đã thêm chương mới",
@@ -78,18 +64,19 @@ func InsertChapter(c *gin.Context) {
 	})
 }
 
-// PUT /chapters/:id
-func UpdateChapter(c *gin.Context) {
+// PUT, PATCH /chapters/:id (JSON merge patch)
+func (h *Handler) UpdateChapter(c *gin.Context) {
 	chapterIDStr := c.Param("id")
 	chapterID, err := primitive.ObjectIDFromHex(chapterIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Lỗi dữ liệu đầu vào"})
+	// Truyện và số chương không sửa được, thứ tự chương do server quản lý
+	updates, err := dto.BindPatch(c, &dto.ChapterPatch{})
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 	updates["updated_at"] = time.Now()
@@ -97,18 +84,13 @@ func UpdateChapter(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	result, err := chapterCollection.UpdateOne(ctx,
-		bson.M{"_id": chapterID},
-		bson.M{"$set": updates},
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể cập nhật chương"})
+	err = h.repos.Chapters.Update(ctx, chapterID, updates)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.ChapterNotFound)
 		return
 	}
-
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Không tìm thấy chương"})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -116,47 +98,101 @@ func UpdateChapter(c *gin.Context) {
 }
 
 // DELETE /chapters/:id
-func DeleteChapter(c *gin.Context) {
+func (h *Handler) DeleteChapter(c *gin.Context) {
 	chapterIDStr := c.Param("id")
 	chapterID, err := primitive.ObjectIDFromHex(chapterIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	storyCollection := config.MongoDB.Collection("Stories")
-
 	// Tìm chương để lấy story_id
-	var chapter models.Chapter
-	err = chapterCollection.FindOne(ctx, bson.M{"_id": chapterID}).Decode(&chapter)
+	chapter, err := h.repos.Chapters.FindByID(ctx, chapterID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chương"})
+		apperror.Abort(c, apperror.ChapterNotFound)
 		return
 	}
 
 	// Xoá chương
-	result, err := chapterCollection.DeleteOne(ctx, bson.M{"_id": chapterID})
-	if err != nil || result.DeletedCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể xoá chương"})
+	err = h.repos.Chapters.Delete(ctx, chapterID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
+	// Lùi số các chương sau cho liền mạch
+	if err := h.repos.Chapters.CloseGap(ctx, chapter.StoryID, chapter.ChapterNumber); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi đánh lại số chương sau khi xoá", "error", err)
+	}
+
 	// Giảm chapters_count
-	_, _ = storyCollection.UpdateOne(ctx,
-		bson.M{"_id": chapter.StoryID},
-		bson.M{"$inc": bson.M{"chapters_count": -1}},
-	)
+	_ = h.repos.Stories.IncrementChapterCount(ctx, chapter.StoryID, -1)
 
 	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã xoá chương"})
 }
-func GetChapterByStoryAndNumber(c *gin.Context) {
+
+// PUT /stories/:id/chapters/order
+func (h *Handler) ReorderChapters(c *gin.Context) {
+	storyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	var input dto.ReorderChapters
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	chapters, err := h.repos.Chapters.FindByStory(ctx, storyID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	// Danh sách phải gồm đúng mỗi chương của truyện một lần
+	remaining := make(map[primitive.ObjectID]bool, len(chapters))
+	for _, chapter := range chapters {
+		remaining[chapter.ID] = true
+	}
+	order := make([]primitive.ObjectID, 0, len(input.ChapterIDs))
+	for _, hex := range input.ChapterIDs {
+		id, _ := primitive.ObjectIDFromHex(hex) // đã kiểm tra bằng tag objectid
+		if !remaining[id] {
+			apperror.Abort(c, apperror.Validation(apperror.Field("chapter_ids", apperror.Invalid)))
+			return
+		}
+		delete(remaining, id)
+		order = append(order, id)
+	}
+	if len(remaining) > 0 {
+		apperror.Abort(c, apperror.Validation(apperror.Field("chapter_ids", apperror.Invalid)))
+		return
+	}
+
+	if err := h.repos.Chapters.Renumber(ctx, storyID, order); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	chapters, err = h.repos.Chapters.FindByStory(ctx, storyID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã sắp xếp lại chương", "chapters": chapters})
+}
+func (h *Handler) GetChapterByStoryAndNumber(c *gin.Context) {
 	storyID, err := primitive.ObjectIDFromHex(c.Param("story_id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID truyện không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("story_id"))
 		return
 	}
 
@@ -164,95 +200,49 @@ func GetChapterByStoryAndNumber(c *gin.Context) {
 	var chapterNumber int
 	_, err = fmt.Sscanf(number, "%d", &chapterNumber)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Số chương không hợp lệ"})
+		apperror.Abort(c, apperror.Validation(apperror.Field("number", apperror.Invalid)))
 		return
 	}
 
-	chapterCollection := config.MongoDB.Collection("Chapters")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var chapter models.Chapter
-	err = chapterCollection.FindOne(ctx, bson.M{
-		"story_id":       storyID,
-		"chapter_number": chapterNumber,
-		"is_Banned":      false,
-		"is_hidden":      false,
-	}).Decode(&chapter)
+	chapter, err := h.repos.Chapters.FindByStoryAndNumber(ctx, storyID, chapterNumber)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chương hoặc chương đã bị ẩn/bị cấm"})
+		apperror.Abort(c, apperror.ChapterNotFound)
 		return
 	}
-	_, _ = chapterCollection.UpdateOne(ctx, bson.M{"_id": chapter.ID}, bson.M{"$inc": bson.M{"view_count": 1}})
-	storyCollection := config.MongoDB.Collection("Stories")
-	_, _ = storyCollection.UpdateOne(ctx,
-		bson.M{"_id": chapter.StoryID},
-		bson.M{"$inc": bson.M{"view_count": 1}},
-	)
+	_, _ = h.repos.Chapters.IncrementViews(ctx, chapter.ID)
+	_ = h.repos.Stories.IncrementViews(ctx, chapter.StoryID)
 	c.JSON(http.StatusOK, chapter)
 }
 
 // GET /chapters/id/:id
-func GetChapterByID(c *gin.Context) {
+func (h *Handler) GetChapterByID(c *gin.Context) {
 	chapterIDHex := c.Param("id")
 	chapterID, err := primitive.ObjectIDFromHex(chapterIDHex)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID chương không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	storyCollection := config.MongoDB.Collection("Stories")
-
-	var chapter models.Chapter
-	err = chapterCollection.FindOneAndUpdate(
-		ctx,
-		bson.M{"_id": chapterID},
-		bson.M{"$inc": bson.M{"view_count": 1}},
-		options.FindOneAndUpdate().SetReturnDocument(options.After),
-	).Decode(&chapter)
+	chapter, err := h.repos.Chapters.IncrementViews(ctx, chapterID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Không tìm thấy chương"})
+		apperror.Abort(c, apperror.ChapterNotFound)
 		return
 	}
 
-	// Tăng view_count cho truyện
-	_, err = storyCollection.UpdateOne(
-		ctx,
-		bson.M{"_id": chapter.StoryID},
-		bson.M{"$inc": bson.M{"view_count": 1}},
-	)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi cập nhật lượt xem cho truyện"})
+	// Tăng view_count cho truyện (truyện đã bị xoá thì bỏ qua)
+	err = h.repos.Stories.IncrementViews(ctx, chapter.StoryID)
+	if err != nil && err != repository.ErrNotFound {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
-	var previousChapter *models.Chapter = nil
-	previousFilter := bson.M{
-		"story_id":       chapter.StoryID,
-		"chapter_number": bson.M{"$lt": chapter.ChapterNumber},
-	}
-	prevOptions := options.FindOne().SetSort(bson.D{{Key: "chapter_number", Value: -1}})
-	var tempPrev models.Chapter
-	err = chapterCollection.FindOne(ctx, previousFilter, prevOptions).Decode(&tempPrev)
-	if err == nil {
-		previousChapter = &tempPrev
-	}
-
-	var nextChapter *models.Chapter = nil
-	nextFilter := bson.M{
-		"story_id":       chapter.StoryID,
-		"chapter_number": bson.M{"$gt": chapter.ChapterNumber},
-	}
-	nextOptions := options.FindOne().SetSort(bson.D{{Key: "chapter_number", Value: 1}})
-	var tempNext models.Chapter
-	err = chapterCollection.FindOne(ctx, nextFilter, nextOptions).Decode(&tempNext)
-	if err == nil {
-		nextChapter = &tempNext
-	}
+	previousChapter, nextChapter, _ := h.repos.Chapters.FindAdjacent(ctx, chapter.StoryID, chapter.ChapterNumber)
 
 	c.JSON(http.StatusOK, gin.H{
 		"chapter":  chapter,
@@ -260,29 +250,18 @@ func GetChapterByID(c *gin.Context) {
 		"next":     nextChapter,
 	})
 }
-func GetNewestChapters(c *gin.Context) {
+func (h *Handler) GetNewestChapters(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	storyCollection := config.MongoDB.Collection("Stories")
-
-	cursor, err := chapterCollection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(5))
+	chapters, err := h.repos.Chapters.Newest(ctx, 5)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể lấy danh sách chương"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var chapters []models.Chapter
-	if err = cursor.All(ctx, &chapters); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể lấy danh sách chương"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	for i := range chapters {
-		var story models.Story
-		err = storyCollection.FindOne(ctx, bson.M{"_id": chapters[i].StoryID}).Decode(&story)
+		story, err := h.repos.Stories.FindByID(ctx, chapters[i].StoryID)
 		if err == nil {
 			chapters[i].Title = story.Title 
 		}
@@ -290,39 +269,36 @@ func GetNewestChapters(c *gin.Context) {
 
 	c.JSON(http.StatusOK, chapters)
 }
-func InsertComment(c *gin.Context) {
-	var comment models.Comment
-	if err := c.ShouldBindJSON(&comment); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Lỗi dữ liệu đầu vào"})
+func (h *Handler) InsertComment(c *gin.Context) {
+	var input dto.CreateComment
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
 		return
 	}
 
 	userIDVal, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Chưa đăng nhập"})
+		apperror.Abort(c, apperror.Unauthenticated)
 		return
 	}
-	comment.UserID = userIDVal.(primitive.ObjectID)
-
-	comment.Content = strings.TrimSpace(comment.Content)
-	if comment.Content == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Nội dung không được để trống"})
-		return
-	}
-	if len(comment.Content) > 1000 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Nội dung quá dài"})
-		return
+	storyID, _ := primitive.ObjectIDFromHex(input.StoryID)
+	chapterID, _ := primitive.ObjectIDFromHex(input.ChapterID)
+	comment := models.Comment{
+		StoryID:   storyID,
+		ChapterID: chapterID,
+		UserID:    userIDVal.(primitive.ObjectID),
+		Content:   strings.TrimSpace(input.Content),
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	if err := config.MongoDB.Collection("Stories").FindOne(ctx, bson.M{"_id": comment.StoryID}).Err(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Truyện không tồn tại"})
+	if _, err := h.repos.Stories.FindByID(ctx, comment.StoryID); err != nil {
+		apperror.Abort(c, apperror.Validation(apperror.Field("story_id", apperror.NotExist)))
 		return
 	}
-	if err := config.MongoDB.Collection("Chapters").FindOne(ctx, bson.M{"_id": comment.ChapterID}).Err(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Chương không tồn tại"})
+	if _, err := h.repos.Chapters.FindByID(ctx, comment.ChapterID); err != nil {
+		apperror.Abort(c, apperror.Validation(apperror.Field("chapter_id", apperror.NotExist)))
 		return
 	}
 
@@ -331,12 +307,12 @@ func InsertComment(c *gin.Context) {
 	comment.CreatedAt = time.Now()
 	comment.UpdatedAt = time.Now()
 
-	_, err := config.MongoDB.Collection("Comments").InsertOne(ctx, comment)
+	err := h.repos.Comments.Insert(ctx, comment)
 	if err != nil {
-		log.Printf("❌ Lỗi khi chèn bình luận: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể thêm bình luận"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
+	metrics.CommentsPosted.Inc()
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "✅ Đã thêm bình luận",
@@ -350,11 +326,11 @@ func InsertComment(c *gin.Context) {
 		},
 	})
 }
-func GetCommentsByChapterID(c *gin.Context) {
+func (h *Handler) GetCommentsByChapterID(c *gin.Context) {
 	chapterIDStr := c.Param("chapter_id")
 	chapterID, err := primitive.ObjectIDFromHex(chapterIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "ID chương không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("chapter_id"))
 		return
 	}
 
@@ -372,23 +348,9 @@ func GetCommentsByChapterID(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	commentCollection := config.MongoDB.Collection("Comments")
-
-	opts := options.Find().
-		SetSort(bson.D{{Key: "created_at", Value: 1}}).
-		SetSkip(int64(skip)).
-		SetLimit(int64(limit))
-
-	cursor, err := commentCollection.Find(ctx, bson.M{"chapter_id": chapterID}, opts)
+	comments, err := h.repos.Comments.FindByChapter(ctx, chapterID, repository.Page{Skip: int64(skip), Limit: int64(limit)})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể lấy danh sách bình luận"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var comments []models.Comment
-	if err = cursor.All(ctx, &comments); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi xử lý bình luận"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -399,3 +361,27 @@ func GetCommentsByChapterID(c *gin.Context) {
 		"comments":   comments,
 	})
 }
+
+// DELETE /stories/chapters/comments/:id
+func (h *Handler) DeleteComment(c *gin.Context) {
+	commentID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = h.repos.Comments.Delete(ctx, commentID)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.CommentNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã xoá bình luận"})
+}
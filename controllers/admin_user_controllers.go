@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/models"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// writeUserAudit ghi lại thao tác quản trị, lỗi ghi log không chặn thao tác chính
+func writeUserAudit(ctx context.Context, c *gin.Context, userID primitive.ObjectID, action, reason string, details bson.M) {
+	actorName, _ := c.Get("username")
+	name, _ := actorName.(string)
+	entry := models.UserAuditLog{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Action:    action,
+		ActorID:   c.MustGet("user_id").(primitive.ObjectID),
+		ActorName: name,
+		Reason:    reason,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if _, err := config.MongoDB.Collection("UserAuditLogs").InsertOne(ctx, entry); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi ghi audit log", "action", action, "user_id", userID.Hex(), "error", err)
+	}
+}
+
+// parseTargetUser đọc :id và chặn admin tự thao tác lên chính mình
+func parseTargetUser(c *gin.Context) (primitive.ObjectID, bool) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return primitive.NilObjectID, false
+	}
+	if userID == c.MustGet("user_id").(primitive.ObjectID) {
+		apperror.Abort(c, apperror.SelfAction)
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
+
+// GET /admin/users?q=&role=&status=&page=&limit=
+func (h *Handler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := repository.UserFilter{
+		Query:  strings.TrimSpace(c.Query("q")),
+		Role:   c.Query("role"),
+		Status: c.Query("status"),
+		Now:    time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	total, err := h.repos.Users.Count(ctx, filter)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	users, err := h.repos.Users.Find(ctx, filter, repository.Page{Skip: int64((page - 1) * limit), Limit: int64(limit)})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":  page,
+		"limit": limit,
+		"total": total,
+		"users": users,
+	})
+}
+
+// GET /admin/users/:id → thông tin user kèm lịch sử thao tác quản trị
+func (h *Handler) GetUserDetail(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+
+	cursor, err := config.MongoDB.Collection("UserAuditLogs").Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(50),
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	history := []models.UserAuditLog{}
+	if err := cursor.All(ctx, &history); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": user, "history": history})
+}
+
+// PUT /admin/users/:id/ban
+func (h *Handler) BanUser(c *gin.Context) {
+	userID, ok := parseTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var input dto.BanUser
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Moderator không được khoá tài khoản quản trị (role có quyền role:manage)
+	target, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if policy.Can(target.Role, policy.RoleManage) && !policy.Can(c.GetString("user_role"), policy.RoleManage) {
+		apperror.Abort(c, apperror.AdminProtected)
+		return
+	}
+
+	err = h.repos.Users.Ban(ctx, userID, input.Reason, input.ExpiresAt)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	// Ban có hiệu lực ngay: thu hồi mọi phiên thay vì chờ token hết hạn
+	revoked, err := h.repos.Sessions.RevokeAll(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	writeUserAudit(ctx, c, userID, "ban", input.Reason, bson.M{"expires_at": input.ExpiresAt})
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã khoá tài khoản", "revoked_sessions": revoked})
+}
+
+// PUT /admin/users/:id/unban
+func (h *Handler) UnbanUser(c *gin.Context) {
+	userID, ok := parseTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var input dto.UnbanUser
+	// body không bắt buộc, nhưng có body thì phải đúng định dạng
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := h.repos.Users.Unban(ctx, userID)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	writeUserAudit(ctx, c, userID, "unban", strings.TrimSpace(input.Reason), nil)
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã mở khoá tài khoản"})
+}
+
+// PUT /admin/users/:id/role
+func (h *Handler) ChangeUserRole(c *gin.Context) {
+	userID, ok := parseTargetUser(c)
+	if !ok {
+		return
+	}
+
+	var input dto.ChangeRole
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+	if _, exists := policy.Get(input.Role); !exists {
+		apperror.Abort(c, apperror.Validation(apperror.Field("role", apperror.NotAllowed)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	previous, err := h.repos.Users.SetRole(ctx, userID, input.Role)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if previous != input.Role {
+		writeUserAudit(ctx, c, userID, "change_role", strings.TrimSpace(input.Reason),
+			bson.M{"from": previous, "to": input.Role})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã đổi vai trò", "role": input.Role})
+}
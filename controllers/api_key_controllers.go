@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/models"
+	"Truyen_BE/policy"
+	"Truyen_BE/utils"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	apiKeyPrefix       = "trk_"
+	maxActiveAPIKeys   = 20
+	apiKeyDisplayChars = 8
+)
+
+// POST /users/me/api-keys → key gốc chỉ trả về một lần trong response này
+func CreateAPIKey(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	var input dto.CreateAPIKey
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+	input.Name = strings.TrimSpace(input.Name)
+	scopes := []string{}
+	seen := map[string]bool{}
+	for _, scope := range input.Scopes {
+		if !policy.IsValidScope(scope) {
+			apperror.Abort(c, apperror.Validation(apperror.Field("scopes", apperror.NotAllowed).With("value", scope)))
+			return
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keyCollection := config.MongoDB.Collection("APIKeys")
+	active, err := keyCollection.CountDocuments(ctx, bson.M{"user_id": userID, "revoked_at": nil})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if active >= maxActiveAPIKeys {
+		apperror.Abort(c, apperror.TooManyAPIKeys)
+		return
+	}
+
+	secret, err := utils.GenerateRandomToken()
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	rawKey := apiKeyPrefix + secret
+
+	key := models.APIKey{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Name:      input.Name,
+		Prefix:    rawKey[:len(apiKeyPrefix)+apiKeyDisplayChars],
+		KeyHash:   utils.HashToken(rawKey),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: input.ExpiresAt,
+	}
+	if _, err := keyCollection.InsertOne(ctx, key); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "✅ Đã tạo API key, hãy lưu lại vì key chỉ hiện một lần",
+		"key":     rawKey,
+		"api_key": key,
+	})
+}
+
+// GET /users/me/api-keys
+func GetMyAPIKeys(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := config.MongoDB.Collection("APIKeys").Find(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	keys := []models.APIKey{}
+	if err := cursor.All(ctx, &keys); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"api_keys": keys})
+}
+
+// DELETE /users/me/api-keys/:id
+func RevokeAPIKey(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	keyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := config.MongoDB.Collection("APIKeys").UpdateOne(ctx,
+		bson.M{"_id": keyID, "user_id": userID, "revoked_at": nil},
+		bson.M{"$set": bson.M{"revoked_at": time.Now()}},
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if result.MatchedCount == 0 {
+		apperror.Abort(c, apperror.APIKeyNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã thu hồi API key"})
+}
@@ -1,65 +1,133 @@
 package controllers
 
 import (
-	"Truyen_BE/config"
+	"Truyen_BE/apperror"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/metrics"
 	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/throttle"
 	"Truyen_BE/utils"
 	"context"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func LoginUser(c *gin.Context) {
-	var input struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
+func (h *Handler) LoginUser(c *gin.Context) {
+	var input dto.Login
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu đầu vào không hợp lệ"})
+		apperror.Abort(c, apperror.Bind(err))
 		return
 	}
 
 	input.Username = strings.TrimSpace(input.Username)
 
-	userCollection := config.MongoDB.Collection("Users")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	var user models.User
-	err := userCollection.FindOne(ctx, bson.M{"username": input.Username}).Decode(&user)
+	// Chống dò mật khẩu: đang bị khoá tạm thì không kiểm tra mật khẩu
+	if !checkLoginThrottle(ctx, c, input.Username) {
+		return
+	}
+
+	user, err := h.repos.Users.FindByUsername(ctx, input.Username)
 	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sai tài khoản hoặc mật khẩu"})
+		recordLoginFailure(ctx, c, input.Username)
+		apperror.Abort(c, apperror.InvalidCredentials)
 		return
 	}
 
 	// Kiểm tra mật khẩu
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Sai tài khoản hoặc mật khẩu"})
+		recordLoginFailure(ctx, c, input.Username)
+		apperror.Abort(c, apperror.InvalidCredentials)
 		return
 	}
 
 	// Check bị ban
-	if user.Status == "banned" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Tài khoản đã bị khóa"})
+	if user.IsBanned(time.Now()) {
+		apperror.Abort(c, apperror.AccountBanned)
 		return
 	}
 
-	// Tạo token
-	token, err := utils.GenerateToken(user.ID.Hex(), user.Role)
+	h.beginLogin(ctx, c, user)
+}
+
+// beginLogin: đã xác thực bước 1 (mật khẩu hoặc provider ngoài); bật 2FA thì chờ bước 2
+func (h *Handler) beginLogin(ctx context.Context, c *gin.Context, user models.User) {
+	// Bật 2FA → chưa cấp token thật, trả token tạm để làm bước 2
+	if user.TOTPEnabled {
+		mfaToken, err := utils.GenerateMFAToken(user.ID.Hex())
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"message":      "Vui lòng nhập mã xác thực 2 bước",
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+			"expires_in":   int(utils.MFATokenTTL.Seconds()),
+		})
+		return
+	}
+
+	h.completeLogin(ctx, c, user)
+}
+
+// checkLoginThrottle trả 429 + Retry-After nếu tài khoản hoặc IP đang bị khoá tạm
+func checkLoginThrottle(ctx context.Context, c *gin.Context, username string) bool {
+	wait, err := throttle.Default.Check(ctx, username, c.ClientIP())
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return false
+	}
+	if wait > 0 {
+		retryAfter := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		apperror.Abort(c, apperror.New(apperror.TooManyAttempts).WithMeta("retry_after", retryAfter))
+		return false
+	}
+	return true
+}
+
+func recordLoginFailure(ctx context.Context, c *gin.Context, username string) {
+	metrics.LoginFailures.Inc()
+	if _, err := throttle.Default.Fail(ctx, username, c.ClientIP()); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi ghi nhận đăng nhập sai", "error", err)
+	}
+}
+
+// completeLogin tạo phiên đăng nhập + cặp token và trả response đăng nhập thành công
+func (h *Handler) completeLogin(ctx context.Context, c *gin.Context, user models.User) {
+	if err := throttle.Default.Succeed(ctx, user.Username); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi reset bộ đếm đăng nhập", "error", err)
+	}
+
+	session, refreshToken, err := h.createSession(ctx, c, user.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể tạo token"})
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	token, err := utils.GenerateToken(user.ID.Hex(), user.Role, session.ID.Hex())
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message": "✅ Đăng nhập thành công",
-		"token":   token,
+		"message":       "✅ Đăng nhập thành công",
+		"token":         token,
+		"refresh_token": refreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
 		"user": gin.H{
 			"id":       user.ID.Hex(),
 			"username": user.Username,
@@ -68,3 +136,126 @@ func LoginUser(c *gin.Context) {
 		},
 	})
 }
+
+// createSession lưu một phiên mới và trả về refresh token dạng thô (chỉ trả cho client một lần)
+func (h *Handler) createSession(ctx context.Context, c *gin.Context, userID primitive.ObjectID) (models.Session, string, error) {
+	refreshToken, err := utils.GenerateRandomToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		ID:               primitive.NewObjectID(),
+		UserID:           userID,
+		RefreshTokenHash: utils.HashToken(refreshToken),
+		UserAgent:        c.Request.UserAgent(),
+		IP:               c.ClientIP(),
+		CreatedAt:        now,
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(utils.RefreshTokenTTL),
+	}
+
+	if err := h.repos.Sessions.Insert(ctx, session); err != nil {
+		return models.Session{}, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// POST /users/auth/refresh
+func (h *Handler) RefreshToken(c *gin.Context) {
+	var input dto.RefreshToken
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	tokenHash := utils.HashToken(input.RefreshToken)
+	now := time.Now()
+
+	session, err := h.repos.Sessions.FindByRefreshToken(ctx, tokenHash)
+	if err == repository.ErrNotFound {
+		// Token cũ đã xoay vòng mà bị dùng lại → nghi bị lộ, thu hồi luôn phiên đó
+		_ = h.repos.Sessions.RevokeByPreviousToken(ctx, tokenHash)
+		apperror.Abort(c, apperror.RefreshTokenInvalid)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if !session.IsActive(now) {
+		apperror.Abort(c, apperror.SessionExpired)
+		return
+	}
+
+	user, err := h.repos.Users.FindByID(ctx, session.UserID)
+	if err != nil {
+		apperror.Abort(c, apperror.New(apperror.UserNotFound).WithStatus(http.StatusUnauthorized))
+		return
+	}
+	if user.IsBanned(time.Now()) {
+		apperror.Abort(c, apperror.AccountBanned)
+		return
+	}
+
+	// Xoay vòng refresh token (lọc theo hash cũ để hai request đồng thời không cùng thành công)
+	newRefreshToken, err := utils.GenerateRandomToken()
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	err = h.repos.Sessions.Rotate(ctx, session.ID, tokenHash, models.Session{
+		RefreshTokenHash: utils.HashToken(newRefreshToken),
+		LastSeenAt:       now,
+		ExpiresAt:        now.Add(utils.RefreshTokenTTL),
+		UserAgent:        c.Request.UserAgent(),
+		IP:               c.ClientIP(),
+	})
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.RefreshTokenInvalid)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	token, err := utils.GenerateToken(user.ID.Hex(), user.Role, session.ID.Hex())
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":         token,
+		"refresh_token": newRefreshToken,
+		"expires_in":    int(utils.AccessTokenTTL.Seconds()),
+	})
+}
+
+// POST /users/auth/logout
+func (h *Handler) LogoutUser(c *gin.Context) {
+	sessionIDVal, exists := c.Get("session_id")
+	if !exists {
+		apperror.Abort(c, apperror.Unauthenticated)
+		return
+	}
+	sessionID := sessionIDVal.(primitive.ObjectID)
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Phiên đã bị thu hồi trước đó vẫn coi như đăng xuất thành công
+	err := h.repos.Sessions.Revoke(ctx, sessionID, userID)
+	if err != nil && err != repository.ErrNotFound {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã đăng xuất"})
+}
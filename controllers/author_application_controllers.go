@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/mailer"
+	"Truyen_BE/models"
+	"Truyen_BE/policy"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// POST /users/me/author-application
+func SubmitAuthorApplication(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	username := c.MustGet("username").(string)
+
+	// Role đã đăng được truyện (author, admin hay role tự định nghĩa) thì không cần nộp đơn
+	role := c.GetString("user_role")
+	if policy.Can(role, policy.StoryCreate) {
+		apperror.Abort(c, apperror.AlreadyAuthor)
+		return
+	}
+	// Duyệt đơn sẽ thay role: role có quyền mà role tác giả không có (moderator...) thì không nộp được
+	if !policy.Covers(config.App.Auth.AuthorRole, role) {
+		apperror.Abort(c, apperror.RoleNotPromotable)
+		return
+	}
+
+	var input dto.AuthorApplication
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+	input.PenName = strings.TrimSpace(input.PenName)
+	input.Sample = strings.TrimSpace(input.Sample)
+	input.Note = strings.TrimSpace(input.Note)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	application := models.AuthorApplication{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Username:  username,
+		PenName:   input.PenName,
+		Sample:    input.Sample,
+		Note:      input.Note,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+	}
+	_, err := config.MongoDB.Collection("AuthorApplications").InsertOne(ctx, application)
+	if mongo.IsDuplicateKeyError(err) {
+		apperror.Abort(c, apperror.ApplicationPending)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã gửi đơn đăng ký tác giả", "application": application})
+}
+
+// GET /users/me/author-application → đơn gần nhất của user
+func GetMyAuthorApplication(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var application models.AuthorApplication
+	err := config.MongoDB.Collection("AuthorApplications").FindOne(ctx,
+		bson.M{"user_id": userID},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	).Decode(&application)
+	if err == mongo.ErrNoDocuments {
+		apperror.Abort(c, apperror.ApplicationNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, application)
+}
+
+// GET /admin/author-applications?status=pending&page=1&limit=20 (cũ nhất lên trước)
+func ListAuthorApplications(c *gin.Context) {
+	status := c.DefaultQuery("status", "pending")
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	collection := config.MongoDB.Collection("AuthorApplications")
+	filter := bson.M{"status": status}
+
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	applications := []models.AuthorApplication{}
+	if err := cursor.All(ctx, &applications); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":         page,
+		"limit":        limit,
+		"total":        total,
+		"applications": applications,
+	})
+}
+
+// PUT /admin/author-applications/:id/approve
+func (h *Handler) ApproveAuthorApplication(c *gin.Context) {
+	h.reviewAuthorApplication(c, "approved")
+}
+
+// PUT /admin/author-applications/:id/reject
+func (h *Handler) RejectAuthorApplication(c *gin.Context) {
+	h.reviewAuthorApplication(c, "rejected")
+}
+
+func (h *Handler) reviewAuthorApplication(c *gin.Context, decision string) {
+	applicationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	var input dto.ReviewApplication
+	_ = c.ShouldBindJSON(&input)
+	input.Message = strings.TrimSpace(input.Message)
+	if decision == "rejected" && input.Message == "" {
+		apperror.Abort(c, apperror.Validation(apperror.Field("message", apperror.Required)))
+		return
+	}
+
+	reviewerID := c.MustGet("user_id").(primitive.ObjectID)
+	now := time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	applicationCollection := config.MongoDB.Collection("AuthorApplications")
+	var application models.AuthorApplication
+	err = applicationCollection.FindOne(ctx, bson.M{"_id": applicationID, "status": "pending"}).Decode(&application)
+	if err == mongo.ErrNoDocuments {
+		apperror.Abort(c, apperror.ApplicationNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	// Nâng quyền trước khi đóng đơn: lỗi giữa chừng thì đơn vẫn pending để duyệt lại
+	if decision == "approved" {
+		if !h.promoteToAuthor(ctx, c, application) {
+			return
+		}
+	}
+
+	// Chỉ đóng đơn đang pending → hai admin bấm cùng lúc thì chỉ một người thành công
+	err = applicationCollection.FindOneAndUpdate(ctx,
+		bson.M{"_id": applicationID, "status": "pending"},
+		bson.M{"$set": bson.M{
+			"status":         decision,
+			"reviewer_id":    reviewerID,
+			"review_message": input.Message,
+			"reviewed_at":    now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&application)
+	if err == mongo.ErrNoDocuments {
+		apperror.Abort(c, apperror.ApplicationNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	h.notifyAuthorApplicationResult(ctx, c, application)
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã xử lý đơn", "application": application})
+}
+
+// promoteToAuthor gán role tác giả (config auth.author_role) cho người nộp đơn.
+// Role đã đăng được truyện thì giữ nguyên; role có quyền mà role tác giả không có
+// (moderator...) thì từ chối thay, tránh hạ quyền khi duyệt đơn.
+func (h *Handler) promoteToAuthor(ctx context.Context, c *gin.Context, application models.AuthorApplication) bool {
+	authorRole := config.App.Auth.AuthorRole
+	if !policy.Can(authorRole, policy.StoryCreate) {
+		apperror.Abort(c, apperror.Internal(fmt.Errorf("role tác giả %q không tồn tại hoặc không có quyền %s", authorRole, policy.StoryCreate)))
+		return false
+	}
+
+	user, err := h.repos.Users.FindByID(ctx, application.UserID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return false
+	}
+	if policy.Can(user.Role, policy.StoryCreate) {
+		return true
+	}
+	if !policy.Covers(authorRole, user.Role) {
+		apperror.Abort(c, apperror.RoleNotPromotable)
+		return false
+	}
+
+	// Lọc theo role vừa đọc: role bị đổi song song thì không ghi đè
+	replaced, err := h.repos.Users.ReplaceRole(ctx, user.ID, user.Role, authorRole)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return false
+	}
+	if replaced {
+		writeUserAudit(ctx, c, user.ID, "change_role", "Duyệt đơn đăng ký tác giả",
+			bson.M{"from": user.Role, "to": authorRole, "application_id": application.ID})
+	}
+	return true
+}
+
+// notifyAuthorApplicationResult gửi mail báo kết quả nếu user có email
+func (h *Handler) notifyAuthorApplicationResult(ctx context.Context, c *gin.Context, application models.AuthorApplication) {
+	user, err := h.repos.Users.FindByID(ctx, application.UserID)
+	if err != nil || user.Email == "" {
+		return
+	}
+
+	subject := "Đơn đăng ký tác giả đã được duyệt"
+	body := "Xin chào " + user.Username + ",\n\nChúc mừng! Bạn đã trở thành tác giả với bút danh \"" + application.PenName + "\"."
+	if application.Status == "rejected" {
+		subject = "Đơn đăng ký tác giả chưa được duyệt"
+		body = "Xin chào " + user.Username + ",\n\nRất tiếc, đơn đăng ký tác giả của bạn chưa được duyệt."
+	}
+	if application.ReviewMessage != "" {
+		body += "\n\nLời nhắn từ quản trị viên:\n" + application.ReviewMessage
+	}
+
+	if err := mailer.Default.Send(mailer.Message{To: user.Email, Subject: subject, Body: body}); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi gửi mail kết quả đơn tác giả", "error", err)
+	}
+}
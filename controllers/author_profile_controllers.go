@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// publicStoryFilter: chỉ truyện người đọc được thấy
+func publicStoryFilter(authorID primitive.ObjectID) bson.M {
+	return bson.M{
+		"created_by": authorID,
+		"is_hidden":  false,
+		"is_banned":  false,
+	}
+}
+
+// findPublicUser tìm user theo username, bỏ qua tài khoản bị khoá
+func (h *Handler) findPublicUser(ctx context.Context, username string) (models.User, error) {
+	user, err := h.repos.Users.FindByUsername(ctx, username)
+	if err == nil && user.IsBanned(time.Now()) {
+		return models.User{}, repository.ErrNotFound
+	}
+	return user, err
+}
+
+// GET /users/:username
+func (h *Handler) GetPublicProfile(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.findPublicUser(ctx, c.Param("username"))
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	followerCount, err := config.MongoDB.Collection("Follows").CountDocuments(ctx, bson.M{"followee_id": user.ID})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: publicStoryFilter(user.ID)}},
+		{{Key: "$group", Value: bson.D{
+			{Key: "_id", Value: nil},
+			{Key: "story_count", Value: bson.D{{Key: "$sum", Value: 1}}},
+			{Key: "total_views", Value: bson.D{{Key: "$sum", Value: "$view_count"}}},
+		}}},
+	}
+	cursor, err := config.MongoDB.Collection("Stories").Aggregate(ctx, pipeline)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	var stats []struct {
+		StoryCount int64 `bson:"story_count"`
+		TotalViews int64 `bson:"total_views"`
+	}
+	if err := cursor.All(ctx, &stats); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	var storyCount, totalViews int64
+	if len(stats) > 0 {
+		storyCount = stats[0].StoryCount
+		totalViews = stats[0].TotalViews
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":             user.ID.Hex(),
+		"username":       user.Username,
+		"display_name":   user.DisplayName,
+		"avatar_url":     user.AvatarURL,
+		"bio":            user.Bio,
+		"role":           user.Role,
+		"joined_at":      user.CreatedAt,
+		"follower_count": followerCount,
+		"story_count":    storyCount,
+		"total_views":    totalViews,
+	})
+}
+
+// GET /users/:username/stories?page=1&limit=10
+func (h *Handler) GetPublicUserStories(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.findPublicUser(ctx, c.Param("username"))
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	storyCollection := config.MongoDB.Collection("Stories")
+	filter := publicStoryFilter(user.ID)
+
+	total, err := storyCollection.CountDocuments(ctx, filter)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
+		SetSkip(int64((page - 1) * limit)).
+		SetLimit(int64(limit))
+	cursor, err := storyCollection.Find(ctx, filter, findOptions)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	stories := []models.Story{}
+	if err := cursor.All(ctx, &stories); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"page":    page,
+		"limit":   limit,
+		"total":   total,
+		"stories": stories,
+	})
+}
+
+// POST /users/:username/follow
+func (h *Handler) FollowUser(c *gin.Context) {
+	followerID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.findPublicUser(ctx, c.Param("username"))
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if user.ID == followerID {
+		apperror.Abort(c, apperror.SelfAction)
+		return
+	}
+
+	_, err = config.MongoDB.Collection("Follows").InsertOne(ctx, models.Follow{
+		ID:         primitive.NewObjectID(),
+		FollowerID: followerID,
+		FolloweeID: user.ID,
+		CreatedAt:  time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		c.JSON(http.StatusOK, gin.H{"message": "Bạn đã theo dõi người này"})
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã theo dõi"})
+}
+
+// DELETE /users/:username/follow
+func (h *Handler) UnfollowUser(c *gin.Context) {
+	followerID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByUsername(ctx, c.Param("username"))
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+
+	result, err := config.MongoDB.Collection("Follows").DeleteOne(ctx, bson.M{"follower_id": followerID, "followee_id": user.ID})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if result.DeletedCount == 0 {
+		apperror.Abort(c, apperror.FollowNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã bỏ theo dõi"})
+}
@@ -0,0 +1,338 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/mailer"
+	"Truyen_BE/models"
+	"Truyen_BE/policy"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// findStoryByParam đọc :id và nạp truyện, tự trả lỗi nếu không hợp lệ
+func findStoryByParam(ctx context.Context, c *gin.Context) (models.Story, bool) {
+	var story models.Story
+	storyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return story, false
+	}
+	if err := config.MongoDB.Collection("Stories").FindOne(ctx, bson.M{"_id": storyID}).Decode(&story); err != nil {
+		apperror.Abort(c, apperror.StoryNotFound)
+		return story, false
+	}
+	return story, true
+}
+
+// GET /stories/:id/collaborators
+func (h *Handler) GetStoryCollaborators(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	story, ok := findStoryByParam(ctx, c)
+	if !ok {
+		return
+	}
+
+	owner, _ := h.repos.Users.FindByID(ctx, story.CreatedBy)
+
+	collaborators := story.Collaborators
+	if collaborators == nil {
+		collaborators = []models.Collaborator{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"owner":         gin.H{"user_id": story.CreatedBy, "username": owner.Username, "role": "owner"},
+		"collaborators": collaborators,
+	})
+}
+
+// POST /stories/:id/collaborators/invitations
+func (h *Handler) InviteCollaborator(c *gin.Context) {
+	inviterID := c.MustGet("user_id").(primitive.ObjectID)
+
+	var input dto.InviteCollaborator
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+	input.Username = strings.TrimSpace(input.Username)
+	if !policy.IsCollaboratorRole(input.Role) {
+		apperror.Abort(c, apperror.Validation(apperror.Field("role", apperror.NotAllowed)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	story, ok := findStoryByParam(ctx, c)
+	if !ok {
+		return
+	}
+
+	invitee, err := h.findPublicUser(ctx, input.Username)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if invitee.ID == story.CreatedBy {
+		apperror.Abort(c, apperror.AlreadyOwner)
+		return
+	}
+	for _, collaborator := range story.Collaborators {
+		if collaborator.UserID == invitee.ID {
+			apperror.Abort(c, apperror.AlreadyCollaborator)
+			return
+		}
+	}
+
+	invitation := models.StoryInvitation{
+		ID:         primitive.NewObjectID(),
+		StoryID:    story.ID,
+		StoryTitle: story.Title,
+		InviterID:  inviterID,
+		InviteeID:  invitee.ID,
+		Role:       input.Role,
+		Status:     "pending",
+		CreatedAt:  time.Now(),
+	}
+	_, err = config.MongoDB.Collection("StoryInvitations").InsertOne(ctx, invitation)
+	if mongo.IsDuplicateKeyError(err) {
+		apperror.Abort(c, apperror.InvitationPending)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if invitee.Email != "" {
+		body := "Xin chào " + invitee.Username + ",\n\nBạn được mời làm " + input.Role + " cho truyện \"" + story.Title +
+			"\". Vào mục lời mời trong tài khoản để chấp nhận hoặc từ chối."
+		if err := mailer.Default.Send(mailer.Message{To: invitee.Email, Subject: "Lời mời cộng tác truyện", Body: body}); err != nil {
+			logging.FromContext(c.Request.Context()).Error("Lỗi gửi mail lời mời cộng tác", "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã gửi lời mời", "invitation": invitation})
+}
+
+// GET /stories/:id/collaborators/invitations → lời mời đang chờ của truyện
+func GetStoryInvitations(c *gin.Context) {
+	storyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := config.MongoDB.Collection("StoryInvitations").Find(ctx,
+		bson.M{"story_id": storyID, "status": "pending"},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []models.StoryInvitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// DELETE /stories/:id/collaborators/invitations/:invitation_id → huỷ lời mời đang chờ
+func CancelStoryInvitation(c *gin.Context) {
+	storyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+	invitationID, err := primitive.ObjectIDFromHex(c.Param("invitation_id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("invitation_id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := config.MongoDB.Collection("StoryInvitations").UpdateOne(ctx,
+		bson.M{"_id": invitationID, "story_id": storyID, "status": "pending"},
+		bson.M{"$set": bson.M{"status": "cancelled", "responded_at": time.Now()}},
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if result.MatchedCount == 0 {
+		apperror.Abort(c, apperror.InvitationNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã huỷ lời mời"})
+}
+
+// DELETE /stories/:id/collaborators/:user_id
+func RemoveCollaborator(c *gin.Context) {
+	storyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(c.Param("user_id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("user_id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	removeCollaborator(ctx, c, storyID, userID, "✅ Đã xoá cộng tác viên")
+}
+
+// DELETE /users/me/collaborations/:story_id → cộng tác viên tự rời truyện
+func LeaveCollaboration(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	storyID, err := primitive.ObjectIDFromHex(c.Param("story_id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("story_id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	removeCollaborator(ctx, c, storyID, userID, "✅ Bạn đã rời khỏi truyện")
+}
+
+func removeCollaborator(ctx context.Context, c *gin.Context, storyID, userID primitive.ObjectID, message string) {
+	result, err := config.MongoDB.Collection("Stories").UpdateOne(ctx,
+		bson.M{"_id": storyID, "collaborators.user_id": userID},
+		bson.M{"$pull": bson.M{"collaborators": bson.M{"user_id": userID}}},
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if result.MatchedCount == 0 {
+		apperror.Abort(c, apperror.CollaboratorNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": message})
+}
+
+// GET /users/me/invitations → lời mời cộng tác đang chờ mình trả lời
+func GetMyInvitations(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := config.MongoDB.Collection("StoryInvitations").Find(ctx,
+		bson.M{"invitee_id": userID, "status": "pending"},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	invitations := []models.StoryInvitation{}
+	if err := cursor.All(ctx, &invitations); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invitations": invitations})
+}
+
+// POST /users/me/invitations/:id/accept
+func AcceptInvitation(c *gin.Context) {
+	respondInvitation(c, "accepted")
+}
+
+// POST /users/me/invitations/:id/decline
+func DeclineInvitation(c *gin.Context) {
+	respondInvitation(c, "declined")
+}
+
+func respondInvitation(c *gin.Context, decision string) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	username := c.MustGet("username").(string)
+
+	invitationID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Chỉ người được mời mới trả lời được, và chỉ trả lời một lần
+	var invitation models.StoryInvitation
+	err = config.MongoDB.Collection("StoryInvitations").FindOneAndUpdate(ctx,
+		bson.M{"_id": invitationID, "invitee_id": userID, "status": "pending"},
+		bson.M{"$set": bson.M{"status": decision, "responded_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&invitation)
+	if err == mongo.ErrNoDocuments {
+		apperror.Abort(c, apperror.InvitationNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if decision == "declined" {
+		c.JSON(http.StatusOK, gin.H{"message": "✅ Đã từ chối lời mời"})
+		return
+	}
+
+	collaborator := models.Collaborator{
+		UserID:   userID,
+		Username: username,
+		Role:     invitation.Role,
+		AddedAt:  time.Now(),
+	}
+	result, err := config.MongoDB.Collection("Stories").UpdateOne(ctx,
+		bson.M{
+			"_id":                   invitation.StoryID,
+			"created_by":            bson.M{"$ne": userID},
+			"collaborators.user_id": bson.M{"$ne": userID},
+		},
+		bson.M{"$push": bson.M{"collaborators": collaborator}},
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if result.MatchedCount == 0 {
+		apperror.Abort(c, apperror.StoryChanged)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã tham gia truyện", "collaborator": collaborator})
+}
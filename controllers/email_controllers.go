@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/mailer"
+	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const emailVerificationTTL = 24 * time.Hour
+
+// sendVerificationEmail tạo token mới (vô hiệu token cũ) và gửi link xác minh tới email hiện tại của user
+func sendVerificationEmail(ctx context.Context, user models.User) error {
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		return err
+	}
+
+	verificationCollection := config.MongoDB.Collection("EmailVerifications")
+	now := time.Now()
+
+	_, _ = verificationCollection.UpdateMany(ctx,
+		bson.M{"user_id": user.ID, "used_at": nil},
+		bson.M{"$set": bson.M{"used_at": now}},
+	)
+
+	verification := models.EmailVerification{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: utils.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(emailVerificationTTL),
+	}
+	if _, err := verificationCollection.InsertOne(ctx, verification); err != nil {
+		return err
+	}
+
+	link := config.App.FrontendURL + "/verify-email?token=" + url.QueryEscape(token)
+	return mailer.Default.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Xác minh địa chỉ email",
+		Body: "Xin chào " + user.Username + ",\n\n" +
+			"Bấm vào link sau để xác minh email (hết hạn sau 24 giờ):\n" + link,
+	})
+}
+
+// POST /users/me/email/verification → gửi lại email xác minh
+func (h *Handler) ResendVerificationEmail(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if user.Email == "" {
+		apperror.Abort(c, apperror.EmailMissing)
+		return
+	}
+	if user.EmailVerified {
+		apperror.Abort(c, apperror.EmailAlreadyVerified)
+		return
+	}
+
+	if err := sendVerificationEmail(ctx, user); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã gửi email xác minh"})
+}
+
+// POST /users/auth/verify-email
+func (h *Handler) VerifyEmail(c *gin.Context) {
+	var input dto.VerifyEmail
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+	var verification models.EmailVerification
+	err := config.MongoDB.Collection("EmailVerifications").FindOneAndUpdate(ctx,
+		bson.M{
+			"token_hash": utils.HashToken(input.Token),
+			"used_at":    nil,
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&verification)
+	if err != nil {
+		apperror.Abort(c, apperror.LinkInvalid)
+		return
+	}
+
+	// Chỉ xác minh nếu user vẫn dùng đúng email đã nhận link
+	err = h.repos.Users.MarkEmailVerified(ctx, verification.UserID, verification.Email)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.EmailChanged)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Email đã được xác minh"})
+}
@@ -0,0 +1,13 @@
+package controllers
+
+import "Truyen_BE/repository"
+
+// Handler gom các handler đọc/ghi qua repository. Bộ repository được truyền vào khi dựng
+// router (MongoDB khi chạy thật, in-memory trong test) nên mỗi router có dữ liệu riêng.
+type Handler struct {
+	repos repository.Repositories
+}
+
+func NewHandler(repos repository.Repositories) *Handler {
+	return &Handler{repos: repos}
+}
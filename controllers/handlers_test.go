@@ -0,0 +1,938 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	middlewares "Truyen_BE/middleware"
+	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
+
+// setupHandler cho test chạy song song và trả Handler trên repository in-memory riêng của test
+func setupHandler(t *testing.T) (*Handler, repository.Repositories) {
+	t.Helper()
+	t.Parallel()
+	repos := repository.NewMemory()
+	return NewHandler(repos), repos
+}
+
+// request gọi handler qua một router gin với đúng route pattern; userID khác zero thì giả lập đã đăng nhập
+func request(t *testing.T, handler gin.HandlerFunc, method, route, path string, userID primitive.ObjectID, body any) *httptest.ResponseRecorder {
+	t.Helper()
+	router := gin.New()
+	router.Use(middlewares.ErrorHandler())
+	router.Handle(method, route, func(c *gin.Context) {
+		if !userID.IsZero() {
+			c.Set("user_id", userID)
+		}
+	}, handler)
+
+	var reader *bytes.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var out T
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("response không phải JSON hợp lệ: %v\n%s", err, w.Body.String())
+	}
+	return out
+}
+
+func expectStatus(t *testing.T, w *httptest.ResponseRecorder, status int) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status = %d, muốn %d, body: %s", w.Code, status, w.Body.String())
+	}
+}
+
+// expectCode kiểm tra mã lỗi trong body do ErrorHandler render
+func expectCode(t *testing.T, w *httptest.ResponseRecorder, code apperror.Code) {
+	t.Helper()
+	var body struct {
+		Code apperror.Code `json:"code"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body.Code != code {
+		t.Fatalf("code = %q, muốn %q, body: %s", body.Code, code, w.Body.String())
+	}
+}
+
+func seedStory(t *testing.T, repos repository.Repositories, story models.Story) models.Story {
+	t.Helper()
+	if story.ID.IsZero() {
+		story.ID = primitive.NewObjectID()
+	}
+	if story.UpdatedAt.IsZero() {
+		story.UpdatedAt = time.Now()
+	}
+	if err := repos.Stories.Insert(context.Background(), story); err != nil {
+		t.Fatal(err)
+	}
+	return story
+}
+
+func seedChapter(t *testing.T, repos repository.Repositories, chapter models.Chapter) models.Chapter {
+	t.Helper()
+	if chapter.ID.IsZero() {
+		chapter.ID = primitive.NewObjectID()
+	}
+	if chapter.CreatedAt.IsZero() {
+		chapter.CreatedAt = time.Now()
+	}
+	if err := repos.Chapters.Insert(context.Background(), chapter); err != nil {
+		t.Fatal(err)
+	}
+	return chapter
+}
+
+func TestInsertStorySetsOwnerAndIgnoresClientFields(t *testing.T) {
+	h, repos := setupHandler(t)
+	author := primitive.NewObjectID()
+	other := primitive.NewObjectID()
+
+	w := request(t, h.InsertStory, http.MethodPost, "/stories", "/stories", author, gin.H{
+		"title":         "Truyện A",
+		"genres":        []string{"fantasy"},
+		"view_count":    999,
+		"is_featured":   true,
+		"created_by":    other.Hex(),
+		"collaborators": []gin.H{{"user_id": other.Hex(), "role": "co-author"}},
+	})
+	expectStatus(t, w, http.StatusOK)
+	id, err := primitive.ObjectIDFromHex(decode[map[string]string](t, w)["id"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	story, err := repos.Stories.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if story.CreatedBy != author || story.ViewCount != 0 || story.IsFeatured || len(story.Collaborators) != 0 {
+		t.Fatalf("truyện lưu sai: %+v", story)
+	}
+	if story.Status != "active" {
+		t.Fatalf("status = %q", story.Status)
+	}
+}
+
+func TestGetStoriesHidesBannedAndPaginates(t *testing.T) {
+	h, repos := setupHandler(t)
+	for _, title := range []string{"Một", "Hai", "Ba"} {
+		seedStory(t, repos, models.Story{Title: title})
+	}
+	seedStory(t, repos, models.Story{Title: "Bị ban", IsBanned: true})
+	seedStory(t, repos, models.Story{Title: "Bị ẩn", IsHidden: true})
+
+	w := request(t, h.GetStories, http.MethodGet, "/stories", "/stories?page=2&limit=2", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	body := decode[struct {
+		Total   int64 `json:"total"`
+		Stories []struct {
+			Title string `json:"title"`
+		} `json:"stories"`
+	}](t, w)
+	if body.Total != 3 {
+		t.Fatalf("total = %d, muốn 3", body.Total)
+	}
+	if len(body.Stories) != 1 || body.Stories[0].Title != "Ba" {
+		t.Fatalf("trang 2 sai: %+v", body.Stories)
+	}
+}
+
+func TestGetStoriesIncludesLatestChapter(t *testing.T) {
+	h, repos := setupHandler(t)
+	story := seedStory(t, repos, models.Story{Title: "A"})
+	seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 1, Title: "Chương 1", CreatedAt: time.Now().Add(-time.Hour)})
+	latest := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 2, Title: "Chương 2"})
+
+	w := request(t, h.GetStories, http.MethodGet, "/stories", "/stories", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	body := decode[struct {
+		Stories []struct {
+			LatestChapterID    string `json:"latest_chapter_id"`
+			LatestChapterTitle string `json:"latest_chapter_title"`
+		} `json:"stories"`
+	}](t, w)
+	if len(body.Stories) != 1 || body.Stories[0].LatestChapterID != latest.ID.Hex() || body.Stories[0].LatestChapterTitle != "Chương 2" {
+		t.Fatalf("chương mới nhất sai: %+v", body.Stories)
+	}
+}
+
+func TestSearchStoriesMatchesNameLiterally(t *testing.T) {
+	h, repos := setupHandler(t)
+	seedStory(t, repos, models.Story{Title: "Kiếm Hiệp Truyện"})
+	seedStory(t, repos, models.Story{Title: "abc"})
+
+	w := request(t, h.SearchStoriesByName, http.MethodGet, "/stories/search", "/stories/search?name=kiếm", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	if stories := decode[[]models.Story](t, w); len(stories) != 1 || stories[0].Title != "Kiếm Hiệp Truyện" {
+		t.Fatalf("kết quả tìm kiếm sai: %+v", stories)
+	}
+
+	// Ký tự đặc biệt của regex không được diễn giải
+	w = request(t, h.SearchStoriesByName, http.MethodGet, "/stories/search", "/stories/search?name=a.c", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	if stories := decode[[]models.Story](t, w); len(stories) != 0 {
+		t.Fatalf("a.c không được khớp abc: %+v", stories)
+	}
+
+	w = request(t, h.SearchStoriesByName, http.MethodGet, "/stories/search", "/stories/search", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestFilterStoriesByGenreAndSort(t *testing.T) {
+	h, repos := setupHandler(t)
+	seedStory(t, repos, models.Story{Title: "Ít xem", Genres: []string{"fantasy"}, ViewCount: 1})
+	seedStory(t, repos, models.Story{Title: "Nhiều xem", Genres: []string{"fantasy", "action"}, ViewCount: 100})
+	seedStory(t, repos, models.Story{Title: "Khác thể loại", Genres: []string{"romance"}, ViewCount: 50})
+
+	w := request(t, h.FilterStories, http.MethodGet, "/stories/filter", "/stories/filter?genre=fantasy&sort=views_desc", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	body := decode[struct {
+		Total   int64          `json:"total"`
+		Stories []models.Story `json:"stories"`
+	}](t, w)
+	if body.Total != 2 || len(body.Stories) != 2 || body.Stories[0].Title != "Nhiều xem" {
+		t.Fatalf("lọc/sắp xếp sai: %+v", body)
+	}
+}
+
+func TestUpdateStoryKeepsOwnershipFields(t *testing.T) {
+	h, repos := setupHandler(t)
+	owner := primitive.NewObjectID()
+	story := seedStory(t, repos, models.Story{Title: "Cũ", CreatedBy: owner})
+
+	// Trường ngoài danh sách được sửa bị từ chối, không lặng lẽ bỏ qua
+	w := request(t, h.UpdateStory, http.MethodPut, "/stories/:id", "/stories/"+story.ID.Hex(), owner, gin.H{
+		"title":      "Mới",
+		"created_by": primitive.NewObjectID().Hex(),
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+	expectCode(t, w, apperror.ValidationFailed)
+	if body := w.Body.String(); !strings.Contains(body, `"field":"created_by"`) {
+		t.Fatalf("thiếu chi tiết created_by: %s", body)
+	}
+	unchanged, _ := repos.Stories.FindByID(context.Background(), story.ID)
+	if unchanged.Title != "Cũ" {
+		t.Fatalf("request lỗi vẫn sửa truyện: %+v", unchanged)
+	}
+
+	w = request(t, h.UpdateStory, http.MethodPut, "/stories/:id", "/stories/"+story.ID.Hex(), owner, gin.H{"title": "Mới"})
+	expectStatus(t, w, http.StatusOK)
+
+	updated, _ := repos.Stories.FindByID(context.Background(), story.ID)
+	if updated.Title != "Mới" || updated.CreatedBy != owner {
+		t.Fatalf("cập nhật sai: %+v", updated)
+	}
+
+	w = request(t, h.UpdateStory, http.MethodPut, "/stories/:id", "/stories/"+primitive.NewObjectID().Hex(), owner, gin.H{"title": "x"})
+	expectStatus(t, w, http.StatusNotFound)
+	expectCode(t, w, apperror.StoryNotFound)
+}
+
+func TestUpdateStoryMergePatch(t *testing.T) {
+	h, repos := setupHandler(t)
+	owner := primitive.NewObjectID()
+	story := seedStory(t, repos, models.Story{
+		Title: "Truyện", Description: "Giới thiệu", Genres: []string{"fantasy"}, Status: "active",
+	})
+	patch := func(body any) *httptest.ResponseRecorder {
+		return request(t, h.UpdateStory, http.MethodPatch, "/stories/:id", "/stories/"+story.ID.Hex(), owner, body)
+	}
+
+	// null xoá trường cho phép rỗng, trường vắng mặt giữ nguyên
+	w := patch(gin.H{"description": nil, "status": "completed"})
+	expectStatus(t, w, http.StatusOK)
+	updated, _ := repos.Stories.FindByID(context.Background(), story.ID)
+	if updated.Description != "" || updated.Status != "completed" || updated.Title != "Truyện" || len(updated.Genres) != 1 {
+		t.Fatalf("merge patch sai: %+v", updated)
+	}
+
+	cases := []struct {
+		name  string
+		body  any
+		field string
+		code  apperror.FieldCode
+	}{
+		{"tiêu đề null", gin.H{"title": nil}, "title", apperror.Required},
+		{"tiêu đề rỗng", gin.H{"title": "  "}, "title", apperror.Required},
+		{"tiêu đề quá dài", gin.H{"title": strings.Repeat("a", 201)}, "title", apperror.TooLong},
+		{"thể loại lạ", gin.H{"genres": []string{"fantasy", "cooking"}}, "genres[1]", apperror.NotAllowed},
+		{"trạng thái lạ", gin.H{"status": "deleted"}, "status", apperror.NotAllowed},
+		{"sửa lượt xem", gin.H{"view_count": 999}, "view_count", apperror.NotAllowed},
+	}
+	for _, tc := range cases {
+		w := patch(tc.body)
+		expectStatus(t, w, http.StatusBadRequest)
+		body := decode[struct {
+			Details []struct {
+				Field string             `json:"field"`
+				Code  apperror.FieldCode `json:"code"`
+			} `json:"details"`
+		}](t, w)
+		if len(body.Details) != 1 || body.Details[0].Field != tc.field || body.Details[0].Code != tc.code {
+			t.Fatalf("%s: chi tiết lỗi sai: %s", tc.name, w.Body.String())
+		}
+	}
+
+	w = patch(gin.H{})
+	expectStatus(t, w, http.StatusBadRequest)
+	expectCode(t, w, apperror.NothingToUpdate)
+}
+
+func TestDeleteStoryRemovesChaptersAndBookshelf(t *testing.T) {
+	h, repos := setupHandler(t)
+	ctx := context.Background()
+	reader := primitive.NewObjectID()
+	story := seedStory(t, repos, models.Story{Title: "Xoá"})
+	chapter := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 1})
+	if _, err := repos.Bookshelf.SaveProgress(ctx, reader, story.ID, chapter.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	w := request(t, h.DeleteStory, http.MethodDelete, "/stories/:id", "/stories/"+story.ID.Hex(), primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusOK)
+
+	if _, err := repos.Stories.FindByID(ctx, story.ID); err != repository.ErrNotFound {
+		t.Fatalf("truyện vẫn còn: %v", err)
+	}
+	if chapters, _ := repos.Chapters.FindByStory(ctx, story.ID); len(chapters) != 0 {
+		t.Fatalf("chương vẫn còn: %+v", chapters)
+	}
+	if entries, _ := repos.Bookshelf.List(ctx, reader, "updated_at", -1, repository.Page{}); len(entries) != 0 {
+		t.Fatalf("tủ sách vẫn còn: %+v", entries)
+	}
+}
+
+func TestDeleteStoryByAuthorRequiresBannedOwnStory(t *testing.T) {
+	h, repos := setupHandler(t)
+	author := primitive.NewObjectID()
+	seedStory(t, repos, models.Story{Title: "Đang hiện", CreatedBy: author})
+	banned := seedStory(t, repos, models.Story{Title: "Đã ẩn", CreatedBy: author, IsBanned: true})
+
+	w := request(t, h.DeleteStoryByAuthor, http.MethodDelete, "/my-stories/:title", "/my-stories/"+url.PathEscape("Đang hiện"), author, nil)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = request(t, h.DeleteStoryByAuthor, http.MethodDelete, "/my-stories/:title", "/my-stories/"+url.PathEscape("Đã ẩn"), primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusForbidden)
+
+	w = request(t, h.DeleteStoryByAuthor, http.MethodDelete, "/my-stories/:title", "/my-stories/"+url.PathEscape("Đã ẩn"), author, nil)
+	expectStatus(t, w, http.StatusOK)
+	if _, err := repos.Stories.FindByID(context.Background(), banned.ID); err != repository.ErrNotFound {
+		t.Fatalf("truyện vẫn còn: %v", err)
+	}
+}
+
+func TestBanAndUnbanStory(t *testing.T) {
+	h, repos := setupHandler(t)
+	story := seedStory(t, repos, models.Story{Title: "Vi phạm"})
+
+	w := request(t, h.BanStory, http.MethodPut, "/admin/stories/ban/:title", "/admin/stories/ban/"+url.PathEscape("Vi phạm"), primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusOK)
+	banned, _ := repos.Stories.FindByID(context.Background(), story.ID)
+	if !banned.IsBanned || banned.DeletedAt == nil {
+		t.Fatalf("chưa bị ban: %+v", banned)
+	}
+
+	w = request(t, h.UnbanStory, http.MethodPut, "/admin/stories/unban/:title", "/admin/stories/unban/"+url.PathEscape("Vi phạm"), primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusOK)
+	unbanned, _ := repos.Stories.FindByID(context.Background(), story.ID)
+	if unbanned.IsBanned || unbanned.DeletedAt != nil {
+		t.Fatalf("chưa bỏ ban: %+v", unbanned)
+	}
+
+	w = request(t, h.BanStory, http.MethodPut, "/admin/stories/ban/:title", "/admin/stories/ban/"+url.PathEscape("Không có"), primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusNotFound)
+	expectCode(t, w, apperror.StoryNotFound)
+}
+
+func TestListUsersByBanStatus(t *testing.T) {
+	h, repos := setupHandler(t)
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	active := seedUser(t, repos, models.User{Status: "active"})
+	forever := seedUser(t, repos, models.User{Status: "banned"})
+	temporary := seedUser(t, repos, models.User{Status: "banned", BannedUntil: &future})
+	expired := seedUser(t, repos, models.User{Status: "banned", BannedUntil: &past})
+
+	for _, tc := range []struct {
+		status string
+		want   []primitive.ObjectID
+	}{
+		// Khoá tạm đã hết hạn không còn bị tính là đang khoá
+		{"banned", []primitive.ObjectID{forever.ID, temporary.ID}},
+		{"active", []primitive.ObjectID{active.ID, expired.ID}},
+	} {
+		w := request(t, h.ListUsers, http.MethodGet, "/admin/users", "/admin/users?status="+tc.status, primitive.NewObjectID(), nil)
+		expectStatus(t, w, http.StatusOK)
+		body := decode[struct {
+			Total int64         `json:"total"`
+			Users []models.User `json:"users"`
+		}](t, w)
+
+		got := []primitive.ObjectID{}
+		for _, user := range body.Users {
+			got = append(got, user.ID)
+		}
+		if body.Total != int64(len(tc.want)) || len(got) != len(tc.want) {
+			t.Fatalf("status=%s: total = %d, users = %v; muốn %v", tc.status, body.Total, got, tc.want)
+		}
+		for _, id := range tc.want {
+			if !slices.Contains(got, id) {
+				t.Errorf("status=%s thiếu user %s", tc.status, id.Hex())
+			}
+		}
+	}
+}
+
+func TestUnbanUserBody(t *testing.T) {
+	h, repos := setupHandler(t)
+	user := seedUser(t, repos, models.User{Status: "active"})
+	path := "/admin/users/" + user.ID.Hex() + "/unban"
+
+	// Không có body: bỏ qua, xử lý tiếp (user không bị khoá → 404)
+	w := request(t, h.UnbanUser, http.MethodPut, "/admin/users/:id/unban", path, primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusNotFound)
+	expectCode(t, w, apperror.UserNotFound)
+
+	// Body sai định dạng thì báo lỗi, không bị nuốt
+	w = request(t, h.UnbanUser, http.MethodPut, "/admin/users/:id/unban", path, primitive.NewObjectID(), "không phải object")
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = request(t, h.UnbanUser, http.MethodPut, "/admin/users/:id/unban", path, primitive.NewObjectID(), gin.H{"reason": strings.Repeat("a", 501)})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestGetGenresWithCount(t *testing.T) {
+	h, repos := setupHandler(t)
+	seedStory(t, repos, models.Story{Title: "A", Genres: []string{"fantasy", "action"}})
+	seedStory(t, repos, models.Story{Title: "B", Genres: []string{"fantasy"}})
+
+	w := request(t, h.GetGenresWithCount, http.MethodGet, "/stories/genre", "/stories/genre", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	counts := decode[[]repository.GenreCount](t, w)
+	if len(counts) != 2 || counts[0] != (repository.GenreCount{Genre: "fantasy", Count: 2}) {
+		t.Fatalf("đếm thể loại sai: %+v", counts)
+	}
+}
+
+func TestInsertAndDeleteChapterMaintainCount(t *testing.T) {
+	h, repos := setupHandler(t)
+	ctx := context.Background()
+	author := primitive.NewObjectID()
+	story := seedStory(t, repos, models.Story{Title: "A", CreatedBy: author})
+
+	var ids []string
+	for i, title := range []string{"Mở đầu", "Tiếp theo"} {
+		w := request(t, h.InsertChapter, http.MethodPost, "/stories/chapters", "/stories/chapters", author, gin.H{
+			"story_id": story.ID.Hex(),
+			"title":    title,
+		})
+		expectStatus(t, w, http.StatusOK)
+		body := decode[struct {
+			ID            string `json:"id"`
+			ChapterNumber int    `json:"chapter_number"`
+		}](t, w)
+		if body.ChapterNumber != i+1 {
+			t.Fatalf("chapter_number = %d, muốn %d", body.ChapterNumber, i+1)
+		}
+		ids = append(ids, body.ID)
+	}
+	if got, _ := repos.Stories.FindByID(ctx, story.ID); got.ChaptersCount != 2 {
+		t.Fatalf("chapters_count = %d, muốn 2", got.ChaptersCount)
+	}
+
+	w := request(t, h.DeleteChapter, http.MethodDelete, "/stories/chapters/:id", "/stories/chapters/"+ids[0], author, nil)
+	expectStatus(t, w, http.StatusOK)
+	if got, _ := repos.Stories.FindByID(ctx, story.ID); got.ChaptersCount != 1 {
+		t.Fatalf("chapters_count = %d, muốn 1", got.ChaptersCount)
+	}
+
+	w = request(t, h.DeleteChapter, http.MethodDelete, "/stories/chapters/:id", "/stories/chapters/"+ids[0], author, nil)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = request(t, h.InsertChapter, http.MethodPost, "/stories/chapters", "/stories/chapters", author, gin.H{"title": "Thiếu truyện"})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestUpdateChapter(t *testing.T) {
+	h, repos := setupHandler(t)
+	chapter := seedChapter(t, repos, models.Chapter{StoryID: primitive.NewObjectID(), ChapterNumber: 1, Title: "Cũ"})
+
+	w := request(t, h.UpdateChapter, http.MethodPut, "/stories/chapters/:id", "/stories/chapters/"+chapter.ID.Hex(), primitive.NewObjectID(), gin.H{
+		"title":   "Mới",
+		"content": "Nội dung",
+	})
+	expectStatus(t, w, http.StatusOK)
+	updated, _ := repos.Chapters.FindByID(context.Background(), chapter.ID)
+	if updated.Title != "Mới" || updated.Content != "Nội dung" || updated.ChapterNumber != 1 {
+		t.Fatalf("cập nhật chương sai: %+v", updated)
+	}
+
+	w = request(t, h.UpdateChapter, http.MethodPut, "/stories/chapters/:id", "/stories/chapters/"+primitive.NewObjectID().Hex(), primitive.NewObjectID(), gin.H{"title": "x"})
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestGetChapterByIDReturnsNeighboursAndCountsViews(t *testing.T) {
+	h, repos := setupHandler(t)
+	ctx := context.Background()
+	story := seedStory(t, repos, models.Story{Title: "A"})
+	first := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 1})
+	second := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 2})
+	third := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 3})
+	seedChapter(t, repos, models.Chapter{StoryID: primitive.NewObjectID(), ChapterNumber: 2})
+
+	w := request(t, h.GetChapterByID, http.MethodGet, "/stories/chapters/id/:id", "/stories/chapters/id/"+second.ID.Hex(), primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	body := decode[struct {
+		Chapter  models.Chapter  `json:"chapter"`
+		Previous *models.Chapter `json:"previous"`
+		Next     *models.Chapter `json:"next"`
+	}](t, w)
+	if body.Chapter.ViewCount != 1 {
+		t.Fatalf("view_count = %d, muốn 1", body.Chapter.ViewCount)
+	}
+	if body.Previous == nil || body.Previous.ID != first.ID || body.Next == nil || body.Next.ID != third.ID {
+		t.Fatalf("chương trước/sau sai: %+v / %+v", body.Previous, body.Next)
+	}
+	if got, _ := repos.Stories.FindByID(ctx, story.ID); got.ViewCount != 1 {
+		t.Fatalf("view_count truyện = %d, muốn 1", got.ViewCount)
+	}
+
+	w = request(t, h.GetChapterByID, http.MethodGet, "/stories/chapters/id/:id", "/stories/chapters/id/"+first.ID.Hex(), primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	if body := decode[map[string]any](t, w); body["previous"] != nil {
+		t.Fatalf("chương đầu không có chương trước: %+v", body["previous"])
+	}
+}
+
+func TestGetChapterByStoryAndNumber(t *testing.T) {
+	h, repos := setupHandler(t)
+	story := seedStory(t, repos, models.Story{Title: "A"})
+	seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 1, Title: "Một"})
+
+	w := request(t, h.GetChapterByStoryAndNumber, http.MethodGet, "/stories/chapters/:story_id/:number", "/stories/chapters/"+story.ID.Hex()+"/1", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	if chapter := decode[models.Chapter](t, w); chapter.Title != "Một" {
+		t.Fatalf("chương sai: %+v", chapter)
+	}
+
+	w = request(t, h.GetChapterByStoryAndNumber, http.MethodGet, "/stories/chapters/:story_id/:number", "/stories/chapters/"+story.ID.Hex()+"/2", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusNotFound)
+
+	w = request(t, h.GetChapterByStoryAndNumber, http.MethodGet, "/stories/chapters/:story_id/:number", "/stories/chapters/"+story.ID.Hex()+"/abc", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestCommentsLifecycle(t *testing.T) {
+	h, repos := setupHandler(t)
+	user := primitive.NewObjectID()
+	story := seedStory(t, repos, models.Story{Title: "A"})
+	chapter := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 1})
+
+	w := request(t, h.InsertComment, http.MethodPost, "/stories/chapters/comment", "/stories/chapters/comment", user, gin.H{
+		"story_id":   story.ID.Hex(),
+		"chapter_id": primitive.NewObjectID().Hex(),
+		"content":    "Hay",
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = request(t, h.InsertComment, http.MethodPost, "/stories/chapters/comment", "/stories/chapters/comment", user, gin.H{
+		"story_id":   story.ID.Hex(),
+		"chapter_id": chapter.ID.Hex(),
+		"content":    "   ",
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+
+	var commentIDs []string
+	for _, content := range []string{"Thứ nhất", "Thứ hai", "Thứ ba"} {
+		w = request(t, h.InsertComment, http.MethodPost, "/stories/chapters/comment", "/stories/chapters/comment", user, gin.H{
+			"story_id":   story.ID.Hex(),
+			"chapter_id": chapter.ID.Hex(),
+			"content":    content,
+		})
+		expectStatus(t, w, http.StatusOK)
+		body := decode[struct {
+			Comment struct {
+				ID     string `json:"id"`
+				UserID string `json:"user_id"`
+			} `json:"comment"`
+		}](t, w)
+		if body.Comment.UserID != user.Hex() {
+			t.Fatalf("user_id = %s, muốn %s", body.Comment.UserID, user.Hex())
+		}
+		commentIDs = append(commentIDs, body.Comment.ID)
+	}
+
+	w = request(t, h.GetCommentsByChapterID, http.MethodGet, "/stories/chapters/comments/:chapter_id", "/stories/chapters/comments/"+chapter.ID.Hex()+"?limit=2&page=2", primitive.NilObjectID, nil)
+	expectStatus(t, w, http.StatusOK)
+	page := decode[struct {
+		Comments []models.Comment `json:"comments"`
+	}](t, w)
+	if len(page.Comments) != 1 || page.Comments[0].Content != "Thứ ba" {
+		t.Fatalf("trang bình luận sai: %+v", page.Comments)
+	}
+
+	w = request(t, h.DeleteComment, http.MethodDelete, "/stories/chapters/comments/:id", "/stories/chapters/comments/"+commentIDs[0], user, nil)
+	expectStatus(t, w, http.StatusOK)
+	w = request(t, h.DeleteComment, http.MethodDelete, "/stories/chapters/comments/:id", "/stories/chapters/comments/"+commentIDs[0], user, nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestBookshelfLifecycle(t *testing.T) {
+	h, repos := setupHandler(t)
+	reader := primitive.NewObjectID()
+	story := seedStory(t, repos, models.Story{Title: "Đang đọc"})
+	first := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 1, Title: "Một"})
+	second := seedChapter(t, repos, models.Chapter{StoryID: story.ID, ChapterNumber: 2, Title: "Hai"})
+
+	w := request(t, h.UpdateLastChapter, http.MethodPost, "/bookshelf", "/bookshelf", reader, gin.H{
+		"story_id":        story.ID.Hex(),
+		"last_chapter_id": first.ID.Hex(),
+	})
+	expectStatus(t, w, http.StatusOK)
+	if msg := decode[map[string]string](t, w)["message"]; msg != "✅ Đã thêm câu chuyện vào tủ sách" {
+		t.Fatalf("message = %q", msg)
+	}
+
+	w = request(t, h.UpdateLastChapter, http.MethodPost, "/bookshelf", "/bookshelf", reader, gin.H{
+		"story_id":        story.ID.Hex(),
+		"last_chapter_id": second.ID.Hex(),
+	})
+	expectStatus(t, w, http.StatusOK)
+	if msg := decode[map[string]string](t, w)["message"]; msg != "✅ Đã cập nhật chương cuối" {
+		t.Fatalf("message = %q", msg)
+	}
+
+	w = request(t, h.GetBookshelf, http.MethodGet, "/bookshelf", "/bookshelf", reader, nil)
+	expectStatus(t, w, http.StatusOK)
+	entries := decode[[]repository.BookshelfEntry](t, w)
+	if len(entries) != 1 || entries[0].StoryTitle != "Đang đọc" || entries[0].ChapterNumber != 2 || entries[0].ChapterTitle != "Hai" {
+		t.Fatalf("tủ sách sai: %+v", entries)
+	}
+
+	// Tủ sách của người khác không bị lộ
+	w = request(t, h.GetBookshelf, http.MethodGet, "/bookshelf", "/bookshelf", primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusOK)
+	if entries := decode[[]repository.BookshelfEntry](t, w); len(entries) != 0 {
+		t.Fatalf("tủ sách người khác phải rỗng: %+v", entries)
+	}
+
+	w = request(t, h.GetBookshelf, http.MethodGet, "/bookshelf", "/bookshelf?sortBy=password", reader, nil)
+	expectStatus(t, w, http.StatusBadRequest)
+
+	w = request(t, h.RemoveFromBookshelf, http.MethodDelete, "/bookshelf/:story_id", "/bookshelf/"+story.ID.Hex(), reader, nil)
+	expectStatus(t, w, http.StatusOK)
+	w = request(t, h.RemoveFromBookshelf, http.MethodDelete, "/bookshelf/:story_id", "/bookshelf/"+story.ID.Hex(), reader, nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestRegisterUserAndGetCurrentUser(t *testing.T) {
+	h, repos := setupHandler(t)
+
+	w := request(t, h.RegisterUser, http.MethodPost, "/users/register", "/users/register", primitive.NilObjectID, gin.H{
+		"username": "reader",
+		"password": "mật-khẩu-dài",
+	})
+	expectStatus(t, w, http.StatusOK)
+	body := decode[struct {
+		User struct {
+			ID   string `json:"id"`
+			Role string `json:"role"`
+		} `json:"user"`
+	}](t, w)
+	if body.User.Role != "user" {
+		t.Fatalf("role = %q, muốn user", body.User.Role)
+	}
+
+	w = request(t, h.RegisterUser, http.MethodPost, "/users/register", "/users/register", primitive.NilObjectID, gin.H{
+		"username": "reader",
+		"password": "mật-khẩu-khác",
+	})
+	expectStatus(t, w, http.StatusConflict)
+	// Quá 72 byte: bcrypt không băm được, phải chặn từ lúc bind thay vì trả 500
+	w = request(t, h.RegisterUser, http.MethodPost, "/users/register", "/users/register", primitive.NilObjectID, gin.H{
+		"username": "reader2",
+		"password": strings.Repeat("ệ", 30),
+	})
+	expectStatus(t, w, http.StatusBadRequest)
+
+	id, _ := primitive.ObjectIDFromHex(body.User.ID)
+	user, err := repos.Users.FindByID(context.Background(), id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if user.Password == "" || user.Password == "mật-khẩu-dài" {
+		t.Fatal("mật khẩu phải được băm")
+	}
+
+	w = request(t, h.GetCurrentUser, http.MethodGet, "/users/me", "/users/me", id, nil)
+	expectStatus(t, w, http.StatusOK)
+	me := decode[map[string]any](t, w)
+	if me["username"] != "reader" {
+		t.Fatalf("username = %v", me["username"])
+	}
+	if _, leaked := me["password"]; leaked {
+		t.Fatal("không được trả password")
+	}
+
+	w = request(t, h.GetCurrentUser, http.MethodGet, "/users/me", "/users/me", primitive.NewObjectID(), nil)
+	expectStatus(t, w, http.StatusNotFound)
+}
+
+func TestGetUserStoriesIncludesCollaborations(t *testing.T) {
+	h, repos := setupHandler(t)
+	user := primitive.NewObjectID()
+	seedStory(t, repos, models.Story{Title: "Của tôi", CreatedBy: user})
+	seedStory(t, repos, models.Story{Title: "Cộng tác", CreatedBy: primitive.NewObjectID(), Collaborators: []models.Collaborator{
+		{UserID: user, Role: "editor"},
+	}})
+	seedStory(t, repos, models.Story{Title: "Người khác", CreatedBy: primitive.NewObjectID()})
+
+	w := request(t, h.GetUserStories, http.MethodGet, "/users/me/stories", "/users/me/stories", user, nil)
+	expectStatus(t, w, http.StatusOK)
+	body := decode[struct {
+		Stories []models.Story `json:"stories"`
+	}](t, w)
+	if len(body.Stories) != 2 || body.Stories[0].Title != "Của tôi" || body.Stories[1].Title != "Cộng tác" {
+		t.Fatalf("danh sách truyện sai: %+v", body.Stories)
+	}
+}
+
+func seedUser(t *testing.T, repos repository.Repositories, user models.User) models.User {
+	t.Helper()
+	if user.ID.IsZero() {
+		user.ID = primitive.NewObjectID()
+	}
+	if user.Username == "" {
+		user.Username = "user" + user.ID.Hex()[18:]
+	}
+	if err := repos.Users.Insert(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return user
+}
+
+func seedSession(t *testing.T, repos repository.Repositories, userID primitive.ObjectID) models.Session {
+	t.Helper()
+	session := models.Session{ID: primitive.NewObjectID(), UserID: userID, ExpiresAt: time.Now().Add(time.Hour)}
+	if err := repos.Sessions.Insert(context.Background(), session); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+// inSession giả lập request đi qua AuthMiddleware với phiên sessionID
+func inSession(sessionID primitive.ObjectID, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set("session_id", sessionID)
+		handler(c)
+	}
+}
+
+func TestChangePasswordSetsFirstPasswordForSSOAccount(t *testing.T) {
+	h, repos := setupHandler(t)
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tạo qua SSO, chưa có mật khẩu
+	user := seedUser(t, repos, models.User{Role: "user", TOTPEnabled: true, TOTPSecret: secret})
+	current := seedSession(t, repos, user.ID)
+	other := seedSession(t, repos, user.ID)
+	change := inSession(current.ID, h.ChangeMyPassword)
+
+	code, err := utils.TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := request(t, change, http.MethodPut, "/users/me/password", "/users/me/password", user.ID, gin.H{
+		"new_password": "mật-khẩu-mới", "code": code,
+	})
+	expectStatus(t, w, http.StatusOK)
+
+	stored, _ := repos.Users.FindByID(context.Background(), user.ID)
+	if bcrypt.CompareHashAndPassword([]byte(stored.Password), []byte("mật-khẩu-mới")) != nil {
+		t.Fatal("mật khẩu đầu tiên chưa được lưu")
+	}
+	if s, _ := repos.Sessions.FindByID(context.Background(), other.ID, user.ID); s.RevokedAt == nil {
+		t.Fatal("phiên khác phải bị thu hồi")
+	}
+	if s, _ := repos.Sessions.FindByID(context.Background(), current.ID, user.ID); s.RevokedAt != nil {
+		t.Fatal("phiên hiện tại phải được giữ")
+	}
+
+	// Đã có mật khẩu → lần sau phải nhập mật khẩu cũ
+	w = request(t, change, http.MethodPut, "/users/me/password", "/users/me/password", user.ID, gin.H{"new_password": "thử-lại"})
+	expectStatus(t, w, http.StatusBadRequest)
+	w = request(t, change, http.MethodPut, "/users/me/password", "/users/me/password", user.ID, gin.H{
+		"old_password": "sai", "new_password": "thử-lại",
+	})
+	expectCode(t, w, apperror.WrongPassword)
+	w = request(t, change, http.MethodPut, "/users/me/password", "/users/me/password", user.ID, gin.H{
+		"old_password": "mật-khẩu-mới", "new_password": "thử-lại",
+	})
+	expectStatus(t, w, http.StatusOK)
+}
+
+func TestChangePasswordRejectsInvalidLength(t *testing.T) {
+	h, repos := setupHandler(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("mật-khẩu"), bcrypt.MinCost)
+	user := seedUser(t, repos, models.User{Role: "user", Password: string(hash)})
+	session := seedSession(t, repos, user.ID)
+	change := inSession(session.ID, h.ChangeMyPassword)
+
+	cases := []struct {
+		name     string
+		password string
+		code     apperror.FieldCode
+	}{
+		{"quá ngắn", "12345", apperror.TooShort},
+		{"quá 72 ký tự", strings.Repeat("a", 73), apperror.TooLong},
+		// 30 ký tự nhưng 90 byte: bcrypt chỉ nhận 72 byte
+		{"quá 72 byte", strings.Repeat("ệ", 30), apperror.TooManyBytes},
+	}
+	for _, tc := range cases {
+		w := request(t, change, http.MethodPut, "/users/me/password", "/users/me/password", user.ID, gin.H{
+			"old_password": "mật-khẩu", "new_password": tc.password,
+		})
+		expectStatus(t, w, http.StatusBadRequest)
+		body := decode[struct {
+			Details []struct {
+				Field string             `json:"field"`
+				Code  apperror.FieldCode `json:"code"`
+			} `json:"details"`
+		}](t, w)
+		if len(body.Details) != 1 || body.Details[0].Field != "new_password" || body.Details[0].Code != tc.code {
+			t.Fatalf("%s: chi tiết lỗi sai: %s", tc.name, w.Body.String())
+		}
+	}
+}
+
+func TestChangePasswordFirstPasswordRequiresReauth(t *testing.T) {
+	h, repos := setupHandler(t)
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	withTOTP := seedUser(t, repos, models.User{Role: "user", TOTPEnabled: true, TOTPSecret: secret})
+	ssoOnly := seedUser(t, repos, models.User{Role: "user"})
+	change := func(userID primitive.ObjectID, body gin.H) *httptest.ResponseRecorder {
+		session := seedSession(t, repos, userID)
+		return request(t, inSession(session.ID, h.ChangeMyPassword), http.MethodPut, "/users/me/password", "/users/me/password", userID, body)
+	}
+
+	// Chỉ có access token (ví dụ bị lộ) → không được tự đặt mật khẩu để chiếm tài khoản
+	expectCode(t, change(withTOTP.ID, gin.H{"new_password": "mật-khẩu-mới"}), apperror.ReauthRequired)
+	expectCode(t, change(withTOTP.ID, gin.H{"new_password": "mật-khẩu-mới", "code": "000000"}), apperror.InvalidTOTPCode)
+	expectCode(t, change(ssoOnly.ID, gin.H{"new_password": "mật-khẩu-mới"}), apperror.ReauthRequired)
+	expectCode(t, change(ssoOnly.ID, gin.H{"new_password": "mật-khẩu-mới", "code": "123456"}), apperror.TwoFactorDisabled)
+
+	for _, id := range []primitive.ObjectID{withTOTP.ID, ssoOnly.ID} {
+		if stored, _ := repos.Users.FindByID(context.Background(), id); stored.Password != "" {
+			t.Fatal("mật khẩu không được đặt khi chưa xác nhận lại")
+		}
+	}
+}
+
+func TestDeleteAccountWithoutPasswordUsesSecondFactor(t *testing.T) {
+	h, repos := setupHandler(t)
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := seedUser(t, repos, models.User{
+		Role:          "user",
+		TOTPEnabled:   true,
+		TOTPSecret:    secret,
+		RecoveryCodes: []string{utils.HashToken(utils.NormalizeRecoveryCode("abcde-12345"))},
+	})
+	story := seedStory(t, repos, models.Story{Title: "Của tôi", CreatedBy: user.ID})
+	del := func(body gin.H) *httptest.ResponseRecorder {
+		return request(t, h.DeleteMyAccount, http.MethodDelete, "/users/me", "/users/me", user.ID, body)
+	}
+
+	expectCode(t, del(gin.H{}), apperror.ReauthRequired)
+	expectCode(t, del(gin.H{"password": "đoán-bừa"}), apperror.ReauthRequired)
+	expectCode(t, del(gin.H{"code": "000000"}), apperror.InvalidTOTPCode)
+
+	code, err := utils.TOTPCode(secret, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Xác nhận đúng nhưng còn sở hữu truyện → chưa xoá, mã đã dùng không dùng lại được
+	expectCode(t, del(gin.H{"code": code}), apperror.OwnsStories)
+	if err := repos.Stories.Delete(context.Background(), story.ID); err != nil {
+		t.Fatal(err)
+	}
+	expectCode(t, del(gin.H{"code": code}), apperror.InvalidTOTPCode)
+
+	expectStatus(t, del(gin.H{"recovery_code": "ABCDE 12345"}), http.StatusOK)
+	if _, err := repos.Users.FindByID(context.Background(), user.ID); err != repository.ErrNotFound {
+		t.Fatalf("tài khoản phải bị xoá, err = %v", err)
+	}
+}
+
+func TestDeleteAccountWithoutPasswordOrSecondFactor(t *testing.T) {
+	h, repos := setupHandler(t)
+	user := seedUser(t, repos, models.User{Role: "user"})
+
+	w := request(t, h.DeleteMyAccount, http.MethodDelete, "/users/me", "/users/me", user.ID, gin.H{"code": "123456"})
+	expectCode(t, w, apperror.TwoFactorDisabled)
+	w = request(t, h.DeleteMyAccount, http.MethodDelete, "/users/me", "/users/me", user.ID, gin.H{})
+	expectCode(t, w, apperror.ReauthRequired)
+	// oidc thiếu trường bắt buộc bị chặn ngay khi bind
+	w = request(t, h.DeleteMyAccount, http.MethodDelete, "/users/me", "/users/me", user.ID, gin.H{"oidc": gin.H{"provider": "google"}})
+	expectStatus(t, w, http.StatusBadRequest)
+}
+
+func TestDeleteAccountWithPassword(t *testing.T) {
+	h, repos := setupHandler(t)
+	hash, _ := bcrypt.GenerateFromPassword([]byte("mật-khẩu"), bcrypt.MinCost)
+	user := seedUser(t, repos, models.User{Role: "user", Password: string(hash)})
+	// Chỉ cộng tác, không sở hữu → vẫn xoá được
+	seedStory(t, repos, models.Story{Title: "Cộng tác", CreatedBy: primitive.NewObjectID(), Collaborators: []models.Collaborator{
+		{UserID: user.ID, Role: "editor"},
+	}})
+	del := func(body gin.H) *httptest.ResponseRecorder {
+		return request(t, h.DeleteMyAccount, http.MethodDelete, "/users/me", "/users/me", user.ID, body)
+	}
+
+	expectStatus(t, del(gin.H{}), http.StatusBadRequest)
+	expectCode(t, del(gin.H{"password": "sai"}), apperror.WrongPassword)
+	expectStatus(t, del(gin.H{"password": "mật-khẩu"}), http.StatusOK)
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"Truyen_BE/config"
+	"Truyen_BE/logging"
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /healthz: tiến trình còn chạy (không kiểm tra phụ thuộc bên ngoài)
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GET /readyz: sẵn sàng nhận request khi ping được MongoDB và ghi được vào thư mục upload
+func Readyz(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	checks := gin.H{}
+	ready := true
+	for name, check := range map[string]func(context.Context) error{
+		"mongodb":    pingMongo,
+		"upload_dir": checkUploadDirWritable,
+	} {
+		if err := check(ctx); err != nil {
+			// Endpoint công khai: không trả chi tiết lỗi (host MongoDB, đường dẫn thư mục...)
+			logging.FromContext(c.Request.Context()).Warn("Readiness check lỗi", "check", name, "error", err)
+			checks[name] = "fail"
+			ready = false
+			continue
+		}
+		checks[name] = "ok"
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "not_ready", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ready", "checks": checks})
+}
+
+func pingMongo(ctx context.Context) error {
+	if config.MongoClient == nil {
+		return errors.New("chưa kết nối MongoDB")
+	}
+	return config.MongoClient.Ping(ctx, nil)
+}
+
+// checkUploadDirWritable thử tạo rồi xoá một file tạm trong thư mục upload
+func checkUploadDirWritable(context.Context) error {
+	f, err := os.CreateTemp(config.App.Upload.Dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
@@ -0,0 +1,14 @@
+package controllers
+
+import (
+	"Truyen_BE/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /.well-known/jwks.json → public key để dịch vụ khác tự kiểm tra access token
+func GetJWKS(c *gin.Context) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, utils.JWKS())
+}
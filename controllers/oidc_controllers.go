@@ -0,0 +1,405 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/metrics"
+	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/sso"
+	"Truyen_BE/utils"
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	oidcStateTTL         = 10 * time.Minute
+	maxGeneratedUsername = 20
+	usernameAttempts     = 5
+)
+
+// startOIDC lưu state + PKCE verifier + nonce và trả URL đăng nhập của provider.
+// linkUserID khác NilObjectID nghĩa là đang liên kết vào tài khoản đó.
+func startOIDC(c *gin.Context, linkUserID primitive.ObjectID) {
+	provider, ok := sso.Get(c.Param("provider"))
+	if !ok {
+		apperror.Abort(c, apperror.OIDCProviderNotFound)
+		return
+	}
+
+	state, err := utils.GenerateRandomToken()
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	nonce, err := utils.GenerateRandomToken()
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	codeVerifier := sso.GenerateCodeVerifier()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err = config.MongoDB.Collection("OIDCStates").InsertOne(ctx, models.OIDCState{
+		ID:           primitive.NewObjectID(),
+		StateHash:    utils.HashToken(state),
+		Provider:     provider.Name,
+		CodeVerifier: codeVerifier,
+		Nonce:        nonce,
+		UserID:       linkUserID,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"authorization_url": provider.AuthCodeURL(state, nonce, codeVerifier),
+		"state":             state,
+		"expires_in":        int(oidcStateTTL.Seconds()),
+	})
+}
+
+// finishOIDC kiểm tra state (dùng một lần), đổi code lấy ID token đã xác thực
+func finishOIDC(ctx context.Context, c *gin.Context) (*sso.Identity, models.OIDCState, bool) {
+	var input dto.OIDCCallback
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return nil, models.OIDCState{}, false
+	}
+	return exchangeOIDC(ctx, c, c.Param("provider"), input.Code, input.State)
+}
+
+// exchangeOIDC xoá state (dùng một lần) rồi đổi code lấy identity từ provider
+func exchangeOIDC(ctx context.Context, c *gin.Context, providerName, code, rawState string) (*sso.Identity, models.OIDCState, bool) {
+	var state models.OIDCState
+
+	provider, ok := sso.Get(providerName)
+	if !ok {
+		apperror.Abort(c, apperror.OIDCProviderNotFound)
+		return nil, state, false
+	}
+
+	err := config.MongoDB.Collection("OIDCStates").FindOneAndDelete(ctx, bson.M{
+		"state_hash": utils.HashToken(rawState),
+		"provider":   provider.Name,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}).Decode(&state)
+	if err != nil {
+		apperror.Abort(c, apperror.OIDCStateInvalid)
+		return nil, state, false
+	}
+
+	identity, err := provider.Exchange(ctx, code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi xác thực OIDC", "error", err)
+		apperror.Abort(c, apperror.OIDCAuthFailed)
+		return nil, state, false
+	}
+	return identity, state, true
+}
+
+// reauthenticateOIDC xác nhận lại user bằng một lần đăng nhập mới qua provider đã liên kết.
+// State phải do chính user tạo qua POST /users/me/identities/:provider/start.
+func reauthenticateOIDC(ctx context.Context, c *gin.Context, userID primitive.ObjectID, input dto.OIDCReauth) bool {
+	identity, state, ok := exchangeOIDC(ctx, c, input.Provider, input.Code, input.State)
+	if !ok {
+		return false
+	}
+	if state.UserID != userID {
+		apperror.Abort(c, apperror.OIDCStateMismatch)
+		return false
+	}
+
+	count, err := config.MongoDB.Collection("UserIdentities").CountDocuments(ctx, bson.M{
+		"user_id":  userID,
+		"provider": identity.Provider,
+		"subject":  identity.Subject,
+	})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return false
+	}
+	if count == 0 {
+		apperror.Abort(c, apperror.New(apperror.IdentityNotFound).WithParam("provider", identity.Provider))
+		return false
+	}
+	return true
+}
+
+// GET /users/auth/oidc/providers
+func ListOIDCProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"providers": sso.Names()})
+}
+
+// GET /users/auth/oidc/:provider/start
+func StartOIDCLogin(c *gin.Context) {
+	startOIDC(c, primitive.NilObjectID)
+}
+
+// POST /users/auth/oidc/:provider/callback → đăng nhập, tự tạo tài khoản nếu chưa có
+func (h *Handler) OIDCLoginCallback(c *gin.Context) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	identity, state, ok := finishOIDC(ctx, c)
+	if !ok {
+		return
+	}
+	if !state.UserID.IsZero() {
+		apperror.Abort(c, apperror.OIDCStateMismatch)
+		return
+	}
+
+	var user models.User
+
+	var linked models.UserIdentity
+	err := config.MongoDB.Collection("UserIdentities").FindOne(ctx,
+		bson.M{"provider": identity.Provider, "subject": identity.Subject},
+	).Decode(&linked)
+	switch {
+	case err == nil:
+		if user, err = h.repos.Users.FindByID(ctx, linked.UserID); err != nil {
+			apperror.Abort(c, apperror.New(apperror.UserNotFound).WithStatus(http.StatusUnauthorized))
+			return
+		}
+	case err == mongo.ErrNoDocuments:
+		user, ok = h.createUserFromIdentity(ctx, c, identity)
+		if !ok {
+			return
+		}
+	default:
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if user.IsBanned(time.Now()) {
+		apperror.Abort(c, apperror.AccountBanned)
+		return
+	}
+
+	h.beginLogin(ctx, c, user)
+}
+
+// createUserFromIdentity tạo tài khoản mới (không có mật khẩu) cho lần đăng nhập đầu qua provider
+func (h *Handler) createUserFromIdentity(ctx context.Context, c *gin.Context, identity *sso.Identity) (models.User, bool) {
+	// Chỉ nhận email provider đã xác minh. Email trùng tài khoản sẵn có thì không tự gộp
+	// (tránh chiếm tài khoản), user phải đăng nhập rồi tự liên kết.
+	email := ""
+	if normalized, ok := utils.NormalizeEmail(identity.Email); ok && identity.EmailVerified {
+		taken, err := h.repos.Users.EmailTaken(ctx, normalized)
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return models.User{}, false
+		}
+		if taken {
+			apperror.Abort(c, apperror.New(apperror.EmailBelongsToAccount).WithParam("provider", identity.Provider))
+			return models.User{}, false
+		}
+		email = normalized
+	}
+
+	user := models.User{
+		ID:            primitive.NewObjectID(),
+		Email:         email,
+		EmailVerified: email != "",
+		DisplayName:   strings.TrimSpace(identity.Name),
+		Role:          "user",
+		Status:        "active",
+		CreatedAt:     time.Now(),
+	}
+
+	base := usernameBase(identity)
+	var err error
+	for attempt := 0; attempt < usernameAttempts; attempt++ {
+		user.Username = base
+		if attempt > 0 {
+			user.Username = fmt.Sprintf("%s%04d", base, randomSuffix())
+		}
+		if err = h.repos.Users.Insert(ctx, user); !errors.Is(err, repository.ErrDuplicate) {
+			break
+		}
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return models.User{}, false
+	}
+	metrics.Registrations.Inc("oidc")
+
+	_, err = config.MongoDB.Collection("UserIdentities").InsertOne(ctx, models.UserIdentity{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		// Hai callback song song cho cùng một người: bỏ tài khoản vừa tạo
+		_ = h.repos.Users.Delete(ctx, user.ID)
+		apperror.Abort(c, apperror.AccountBeingCreated)
+		return models.User{}, false
+	}
+
+	return user, true
+}
+
+// usernameBase lấy username gợi ý từ ID token, chỉ giữ chữ cái, số, "_" và "."
+func usernameBase(identity *sso.Identity) string {
+	candidates := []string{identity.PreferredUsername}
+	if at := strings.Index(identity.Email, "@"); at > 0 {
+		candidates = append(candidates, identity.Email[:at])
+	}
+	candidates = append(candidates, identity.Name)
+
+	for _, candidate := range candidates {
+		var b strings.Builder
+		for _, r := range strings.ToLower(candidate) {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.') {
+				b.WriteRune(r)
+			}
+		}
+		if name := b.String(); len(name) >= 3 {
+			if len(name) > maxGeneratedUsername {
+				name = name[:maxGeneratedUsername]
+			}
+			return name
+		}
+	}
+	return identity.Provider + "_user"
+}
+
+func randomSuffix() int64 {
+	n, err := rand.Int(rand.Reader, big.NewInt(10000))
+	if err != nil {
+		return time.Now().UnixNano() % 10000
+	}
+	return n.Int64()
+}
+
+// GET /users/me/identities
+func GetMyIdentities(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := config.MongoDB.Collection("UserIdentities").Find(ctx,
+		bson.M{"user_id": userID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	identities := []models.UserIdentity{}
+	if err := cursor.All(ctx, &identities); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"identities": identities, "available_providers": sso.Names()})
+}
+
+// POST /users/me/identities/:provider/start
+func StartOIDCLink(c *gin.Context) {
+	startOIDC(c, c.MustGet("user_id").(primitive.ObjectID))
+}
+
+// POST /users/me/identities/:provider/callback
+func OIDCLinkCallback(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	identity, state, ok := finishOIDC(ctx, c)
+	if !ok {
+		return
+	}
+	// State phải do chính user này tạo, tránh bị lừa liên kết tài khoản ngoài của kẻ khác
+	if state.UserID != userID {
+		apperror.Abort(c, apperror.OIDCStateMismatch)
+		return
+	}
+
+	_, err := config.MongoDB.Collection("UserIdentities").InsertOne(ctx, models.UserIdentity{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		apperror.Abort(c, apperror.New(apperror.IdentityAlreadyLinked).WithParam("provider", identity.Provider))
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã liên kết tài khoản " + identity.Provider})
+}
+
+// DELETE /users/me/identities/:provider
+func (h *Handler) UnlinkIdentity(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	provider := c.Param("provider")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+
+	identityCollection := config.MongoDB.Collection("UserIdentities")
+	// Không cho gỡ cách đăng nhập cuối cùng của tài khoản
+	if user.Password == "" {
+		others, err := identityCollection.CountDocuments(ctx, bson.M{"user_id": userID, "provider": bson.M{"$ne": provider}})
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+		if others == 0 {
+			apperror.Abort(c, apperror.LastLoginMethod)
+			return
+		}
+	}
+
+	result, err := identityCollection.DeleteOne(ctx, bson.M{"user_id": userID, "provider": provider})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if result.DeletedCount == 0 {
+		apperror.Abort(c, apperror.New(apperror.IdentityNotFound).WithParam("provider", provider))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã gỡ liên kết " + provider})
+}
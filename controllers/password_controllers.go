@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/mailer"
+	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const passwordResetTTL = 30 * time.Minute
+
+// POST /users/auth/forgot-password
+func (h *Handler) ForgotPassword(c *gin.Context) {
+	var input dto.ForgotPassword
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+	email, ok := utils.NormalizeEmail(input.Email)
+	if !ok {
+		apperror.Abort(c, apperror.Validation(apperror.Field("email", apperror.Invalid)))
+		return
+	}
+
+	// Luôn trả cùng một thông báo để không lộ email nào đã đăng ký
+	okResponse := gin.H{"message": "✅ Nếu email tồn tại, hướng dẫn đặt lại mật khẩu đã được gửi"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByEmail(ctx, email)
+	if err != nil || user.IsBanned(time.Now()) {
+		c.JSON(http.StatusOK, okResponse)
+		return
+	}
+
+	token, err := utils.GenerateRandomToken()
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	resetCollection := config.MongoDB.Collection("PasswordResets")
+	now := time.Now()
+
+	// Vô hiệu hoá các link cũ chưa dùng, chỉ link mới nhất có hiệu lực
+	_, _ = resetCollection.UpdateMany(ctx,
+		bson.M{"user_id": user.ID, "used_at": nil},
+		bson.M{"$set": bson.M{"used_at": now}},
+	)
+
+	reset := models.PasswordReset{
+		ID:        primitive.NewObjectID(),
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(passwordResetTTL),
+	}
+	if _, err := resetCollection.InsertOne(ctx, reset); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	link := config.App.FrontendURL + "/reset-password?token=" + url.QueryEscape(token)
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Đặt lại mật khẩu",
+		Body: "Xin chào " + user.Username + ",\n\n" +
+			"Bấm vào link sau để đặt lại mật khẩu (hết hạn sau 30 phút):\n" + link + "\n\n" +
+			"Nếu bạn không yêu cầu, hãy bỏ qua email này.",
+	}
+	// Gửi nền và chỉ ghi log khi lỗi: response và thời gian trả về không được khác
+	// giữa email có và không có tài khoản
+	sender, logger := mailer.Default, logging.FromContext(c.Request.Context())
+	go func() {
+		if err := sender.Send(msg); err != nil {
+			logger.Error("Lỗi gửi mail đặt lại mật khẩu", "error", err)
+		}
+	}()
+
+	c.JSON(http.StatusOK, okResponse)
+}
+
+// POST /users/auth/reset-password
+func (h *Handler) ResetPassword(c *gin.Context) {
+	var input dto.ResetPassword
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Đánh dấu đã dùng ngay trong cùng một thao tác → token chỉ dùng được một lần
+	now := time.Now()
+	var reset models.PasswordReset
+	err := config.MongoDB.Collection("PasswordResets").FindOneAndUpdate(ctx,
+		bson.M{
+			"token_hash": utils.HashToken(input.Token),
+			"used_at":    nil,
+			"expires_at": bson.M{"$gt": now},
+		},
+		bson.M{"$set": bson.M{"used_at": now}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&reset)
+	if err != nil {
+		apperror.Abort(c, apperror.LinkInvalid)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	err = h.repos.Users.SetPassword(ctx, reset.UserID, string(hashedPassword))
+	// Tài khoản đã bị xoá sau khi gửi link: link coi như không còn hiệu lực
+	if errors.Is(err, repository.ErrNotFound) {
+		apperror.Abort(c, apperror.LinkInvalid)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	// Mật khẩu đổi → đăng xuất mọi thiết bị
+	if _, err := h.repos.Sessions.RevokeAll(ctx, reset.UserID); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi thu hồi phiên sau khi đặt lại mật khẩu", "error", err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã đặt lại mật khẩu, vui lòng đăng nhập lại"})
+}
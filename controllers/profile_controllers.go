@@ -0,0 +1,238 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// PATCH /users/me → JSON merge patch: trường vắng mặt giữ nguyên, null hoặc chuỗi rỗng xoá trường
+func (h *Handler) UpdateMyProfile(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	patch, err := dto.BindPatch(c, &dto.ProfilePatch{})
+	if err != nil {
+		apperror.Abort(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	changed := false
+	for key, target := range map[string]*string{
+		"display_name": &user.DisplayName,
+		"bio":          &user.Bio,
+		"avatar_url":   &user.AvatarURL,
+	} {
+		value, ok := patch[key].(string)
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		// Chỉ nhận ảnh đã upload qua POST /upload, không nhận link ngoài
+		if key == "avatar_url" && value != "" && !utils.IsUploadedImage(value) {
+			apperror.Abort(c, apperror.Validation(apperror.Field(key, apperror.Invalid)))
+			return
+		}
+		*target = value
+		changed = true
+	}
+
+	emailChanged := false
+	if raw, present := patch["email"].(string); present {
+		email, ok := utils.NormalizeEmail(raw)
+		if !ok {
+			apperror.Abort(c, apperror.Validation(apperror.Field("email", apperror.Invalid)))
+			return
+		}
+		if email != user.Email {
+			taken, err := h.repos.Users.EmailTaken(ctx, email)
+			if err != nil {
+				apperror.Abort(c, apperror.Internal(err))
+				return
+			}
+			if taken {
+				apperror.Abort(c, apperror.EmailTaken)
+				return
+			}
+			// Đổi email → phải xác minh lại
+			user.Email = email
+			user.EmailVerified = false
+			emailChanged = true
+			changed = true
+		}
+	}
+
+	if !changed {
+		apperror.Abort(c, apperror.NothingToUpdate)
+		return
+	}
+
+	err = h.repos.Users.UpdateProfile(ctx, user)
+	if errors.Is(err, repository.ErrDuplicate) {
+		apperror.Abort(c, apperror.EmailTaken)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if emailChanged {
+		if err := sendVerificationEmail(ctx, user); err != nil {
+			logging.FromContext(c.Request.Context()).Error("Lỗi gửi email xác minh", "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã cập nhật hồ sơ", "user": user})
+}
+
+// PUT /users/me/password
+func (h *Handler) ChangeMyPassword(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	currentSessionID := c.MustGet("session_id").(primitive.ObjectID)
+
+	var input dto.ChangePassword
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	// Tài khoản tạo qua SSO chưa có mật khẩu: access token chưa đủ, phải xác nhận lại
+	// bằng 2FA/mã khôi phục hoặc đăng nhập lại qua provider trước khi đặt mật khẩu đầu tiên
+	firstPassword := user.Password == ""
+	if firstPassword {
+		if !h.reauthenticate(ctx, c, user, "", input.Reauth) {
+			return
+		}
+	} else {
+		if input.OldPassword == "" {
+			apperror.Abort(c, apperror.Validation(apperror.Field("old_password", apperror.Required)))
+			return
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.OldPassword)); err != nil {
+			apperror.Abort(c, apperror.WrongPassword)
+			return
+		}
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if err := h.repos.Users.SetPassword(ctx, userID, string(hashedPassword)); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	// Giữ phiên hiện tại, đăng xuất các thiết bị khác
+	if _, err := h.repos.Sessions.RevokeOthers(ctx, userID, currentSessionID); err != nil {
+		logging.FromContext(c.Request.Context()).Error("Lỗi thu hồi phiên sau khi đổi mật khẩu", "error", err)
+	}
+
+	if firstPassword {
+		c.JSON(http.StatusOK, gin.H{"message": "✅ Đã đặt mật khẩu"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã đổi mật khẩu"})
+}
+
+// DELETE /users/me → xoá tài khoản: ẩn danh bình luận, xoá tủ sách và phiên đăng nhập
+func (h *Handler) DeleteMyAccount(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	var input dto.DeleteAccount
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if !h.reauthenticate(ctx, c, user, input.Password, input.Reauth) {
+		return
+	}
+
+	// Truyện phải được xoá hoặc chuyển cho người khác trước, tránh truyện mồ côi
+	storyCount, err := h.repos.Stories.Count(ctx, repository.StoryFilter{OwnerID: userID})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if storyCount > 0 {
+		apperror.Abort(c, apperror.OwnsStories)
+		return
+	}
+
+	if err := h.repos.Users.Delete(ctx, userID); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Tài khoản đã được xoá"})
+}
+
+// reauthenticate xác nhận lại danh tính trước thao tác không hoàn tác được. Tài khoản có mật khẩu
+// phải nhập mật khẩu; tài khoản chỉ đăng nhập qua SSO dùng mã 2FA/mã khôi phục hoặc đăng nhập lại qua provider.
+func (h *Handler) reauthenticate(ctx context.Context, c *gin.Context, user models.User, password string, input dto.Reauth) bool {
+	switch {
+	case user.Password != "":
+		if password == "" {
+			apperror.Abort(c, apperror.Validation(apperror.Field("password", apperror.Required)))
+			return false
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+			apperror.Abort(c, apperror.WrongPassword)
+			return false
+		}
+		return true
+	case input.Code != "" || input.RecoveryCode != "":
+		if !user.TOTPEnabled {
+			apperror.Abort(c, apperror.TwoFactorDisabled)
+			return false
+		}
+		if !verifySecondFactor(ctx, h.repos.Users, user, input.Code, input.RecoveryCode) {
+			apperror.Abort(c, apperror.InvalidTOTPCode)
+			return false
+		}
+		return true
+	case input.OIDC != nil:
+		return reauthenticateOIDC(ctx, c, user.ID, *input.OIDC)
+	default:
+		apperror.Abort(c, apperror.ReauthRequired)
+		return false
+	}
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/policy"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GET /admin/roles
+func ListRoles(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"roles":       policy.List(),
+		"permissions": policy.All,
+	})
+}
+
+// PUT /admin/roles/:name → tạo mới hoặc thay toàn bộ tập quyền của role
+func UpdateRole(c *gin.Context) {
+	var input dto.UpdateRole
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	role := policy.Role{
+		Name:        c.Param("name"),
+		Permissions: input.Permissions,
+		Require2FA:  input.Require2FA,
+	}
+	if role.Permissions == nil {
+		role.Permissions = []policy.Permission{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := policy.Save(ctx, config.MongoDB.Collection("Roles"), role)
+	if err == policy.ErrInvalidRole {
+		apperror.Abort(c, apperror.Validation(apperror.Field("permissions", apperror.NotAllowed)))
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã cập nhật role", "role": role})
+}
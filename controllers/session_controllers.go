@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/repository"
+	"Truyen_BE/throttle"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// GET /users/me/sessions
+func (h *Handler) GetMySessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	currentSessionID := c.MustGet("session_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	sessions, err := h.repos.Sessions.FindActive(ctx, userID, time.Now())
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	results := make([]gin.H, 0, len(sessions))
+	for _, s := range sessions {
+		results = append(results, gin.H{
+			"id":           s.ID.Hex(),
+			"user_agent":   s.UserAgent,
+			"ip":           s.IP,
+			"created_at":   s.CreatedAt,
+			"last_seen_at": s.LastSeenAt,
+			"expires_at":   s.ExpiresAt,
+			"current":      s.ID == currentSessionID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"sessions": results})
+}
+
+// DELETE /users/me/sessions/:id
+func (h *Handler) RevokeMySession(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	sessionID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Lọc theo user_id để không thu hồi được phiên của người khác
+	err = h.repos.Sessions.Revoke(ctx, sessionID, userID)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.SessionNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã thu hồi phiên đăng nhập"})
+}
+
+// DELETE /users/me/sessions → đăng xuất mọi thiết bị khác, giữ lại phiên hiện tại
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	currentSessionID := c.MustGet("session_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	revoked, err := h.repos.Sessions.RevokeOthers(ctx, userID, currentSessionID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã đăng xuất các thiết bị khác", "revoked": revoked})
+}
+
+// DELETE /admin/users/:id/lockout?ip=... → gỡ khoá đăng nhập của tài khoản (và IP nếu truyền)
+func (h *Handler) ClearLoginLockout(c *gin.Context) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+
+	if err := throttle.Default.Store.Reset(ctx, throttle.AccountKey(user.Username)); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if ip := c.Query("ip"); ip != "" {
+		if err := throttle.Default.Store.Reset(ctx, throttle.IPKey(ip)); err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã gỡ khoá đăng nhập"})
+}
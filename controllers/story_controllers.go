@@ -1,25 +1,24 @@
 package controllers
 
 import (
-	"Truyen_BE/config"
+	"Truyen_BE/apperror"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/metrics"
 	"Truyen_BE/models"
+	"Truyen_BE/repository"
 	"context"
-	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 // GET /stories
-func GetStories(c *gin.Context) {
-	storyCollection := config.MongoDB.Collection("Stories")
-	chapterCollection := config.MongoDB.Collection("Chapters")
-
+func (h *Handler) GetStories(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -35,24 +34,12 @@ func GetStories(c *gin.Context) {
 	skip := int64((page - 1) * limit)
 	limit64 := int64(limit)
 
-	filter := bson.M{
-		"is_hidden": false,
-		"is_banned": false,
-	}
-
-	findOptions := options.Find().SetSkip(skip).SetLimit(limit64)
-	total, _ := storyCollection.CountDocuments(ctx, filter)
+	filter := repository.StoryFilter{VisibleOnly: true}
+	total, _ := h.repos.Stories.Count(ctx, filter)
 
-	cursor, err := storyCollection.Find(ctx, filter, findOptions)
+	stories, err := h.repos.Stories.Find(ctx, filter, "", repository.Page{Skip: skip, Limit: limit64})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi truy vấn MongoDB"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var stories []models.Story
-	if err := cursor.All(ctx, &stories); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi giải mã dữ liệu"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -64,11 +51,7 @@ func GetStories(c *gin.Context) {
 
 	var results []StoryResponse
 	for _, story := range stories {
-		var latestChapter models.Chapter
-		err := chapterCollection.FindOne(ctx,
-			bson.M{"story_id": story.ID},
-			options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}),
-		).Decode(&latestChapter)
+		latestChapter, err := h.repos.Chapters.Latest(ctx, story.ID)
 
 		latestChapterID := ""
 		latestChapterTitle := ""
@@ -93,27 +76,19 @@ func GetStories(c *gin.Context) {
 }
 
 // GET /stories/search?name=abc
-func SearchStoriesByName(c *gin.Context) {
+func (h *Handler) SearchStoriesByName(c *gin.Context) {
 	name := c.Query("name")
 	if name == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu query 'name'"})
+		apperror.Abort(c, apperror.Validation(apperror.Field("name", apperror.Required)))
 		return
 	}
 
-	storyCollection := config.MongoDB.Collection("Stories")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	filter := bson.M{"title": bson.M{"$regex": name, "$options": "i"}}
-	cursor, err := storyCollection.Find(ctx, filter)
+	stories, err := h.repos.Stories.Find(ctx, repository.StoryFilter{TitleContains: name}, "", repository.Page{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể tìm kiếm"})
-		return
-	}
-
-	var stories []models.Story
-	if err := cursor.All(ctx, &stories); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi giải mã kết quả"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -121,54 +96,54 @@ func SearchStoriesByName(c *gin.Context) {
 }
 
 // POST /stories
-func InsertStory(c *gin.Context) {
-	var newStory models.Story
-	if err := c.ShouldBindJSON(&newStory); err != nil {
-		c.JSON(400, gin.H{"error": "Lỗi dữ liệu đầu vào"})
-		return
-	}
-
-	newStory.ID = primitive.NewObjectID()
-	newStory.CreatedAt = time.Now()
-	newStory.UpdatedAt = time.Now()
-	newStory.ChaptersCount = 0
-	newStory.ViewCount = 0
-	newStory.IsHidden = false
-	newStory.IsFeatured = false
-	newStory.IsBanned = false
-	newStory.DeletedAt = nil
-	newStory.CreatedBy = c.MustGet("user_id").(primitive.ObjectID)
-	newStory.Status = "active"
+func (h *Handler) InsertStory(c *gin.Context) {
+	var input dto.CreateStory
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	// Các trường còn lại (lượt xem, ẩn/ban, cộng tác viên...) do server đặt
+	newStory := models.Story{
+		ID:          primitive.NewObjectID(),
+		Title:       strings.TrimSpace(input.Title),
+		Author:      input.Author,
+		Description: input.Description,
+		CoverURL:    input.CoverURL,
+		Genres:      input.Genres,
+		Status:      input.Status,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		CreatedBy:   c.MustGet("user_id").(primitive.ObjectID),
+	}
+	if newStory.Status == "" {
+		newStory.Status = dto.StoryActive
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
-	val, exists := c.Get("user_id")
-	if !exists {
-		fmt.Println("❌ Controller không thấy user_id trong context")
-	} else {
-		fmt.Println("✅ Controller lấy user_id:", val)
-	}
-	storyCollection := config.MongoDB.Collection("Stories")
-	_, err := storyCollection.InsertOne(ctx, newStory)
+	err := h.repos.Stories.Insert(ctx, newStory)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi chèn truyện"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "✅ Truyện đã được thêm", "id": newStory.ID.Hex()})
 }
 
-// PUT /stories/:id
-func UpdateStory(c *gin.Context) {
+// PUT, PATCH /stories/:id (JSON merge patch)
+func (h *Handler) UpdateStory(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "ID không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
-	var updates map[string]interface{}
-	if err := c.ShouldBindJSON(&updates); err != nil {
-		c.JSON(400, gin.H{"error": "Dữ liệu đầu vào sai"})
+	// Chủ sở hữu và cộng tác viên chỉ đổi qua API chuyển quyền / lời mời,
+	// nên không nằm trong danh sách trường được sửa
+	updates, err := dto.BindPatch(c, &dto.StoryPatch{})
+	if err != nil {
+		apperror.Abort(c, err)
 		return
 	}
 	updates["updated_at"] = time.Now()
@@ -176,14 +151,13 @@ func UpdateStory(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	storyCollection := config.MongoDB.Collection("Stories")
-	result, err := storyCollection.UpdateOne(ctx, bson.M{"_id": objectID}, bson.M{"$set": updates})
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể cập nhật truyện"})
+	err = h.repos.Stories.Update(ctx, objectID, updates)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.StoryNotFound)
 		return
 	}
-	if result.MatchedCount == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "Không tìm thấy truyện"})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -191,35 +165,32 @@ func UpdateStory(c *gin.Context) {
 }
 
 // DELETE /stories/:id
-func DeleteStory(c *gin.Context) {
+func (h *Handler) DeleteStory(c *gin.Context) {
 	id := c.Param("id")
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "ID không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	storyCollection := config.MongoDB.Collection("Stories")
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	bookshelfCollection := config.MongoDB.Collection("Bookshelf")
-	_, err = bookshelfCollection.DeleteMany(ctx, bson.M{"story_id": objectID})
+	err = h.repos.Bookshelf.DeleteByStory(ctx, objectID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Không thể xoá truyện trong tủ sách"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
-	_, err = chapterCollection.DeleteMany(ctx, bson.M{"story_id": objectID})
+	err = h.repos.Chapters.DeleteByStory(ctx, objectID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Không thể xoá các chương"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
-	result, err := storyCollection.DeleteOne(ctx, bson.M{"_id": objectID})
-	if err != nil || result.DeletedCount == 0 {
-		c.JSON(500, gin.H{"error": "Không thể xoá truyện"})
+	err = h.repos.Stories.Delete(ctx, objectID)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -227,28 +198,20 @@ func DeleteStory(c *gin.Context) {
 }
 
 // GET /stories/:id/chapters
-func GetChaptersByStoryID(c *gin.Context) {
+func (h *Handler) GetChaptersByStoryID(c *gin.Context) {
 	id := c.Param("id")
 	storyID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "ID truyện không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	cursor, err := chapterCollection.Find(ctx, bson.M{"story_id": storyID})
+	chapters, err := h.repos.Chapters.FindByStory(ctx, storyID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Không thể truy vấn chương"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var chapters []models.Chapter
-	if err := cursor.All(ctx, &chapters); err != nil {
-		c.JSON(500, gin.H{"error": "Lỗi đọc chương"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -259,8 +222,7 @@ func GetChaptersByStoryID(c *gin.Context) {
 }
 
 // GET /stories/filter
-func FilterStories(c *gin.Context) {
-	storyCollection := config.MongoDB.Collection("Stories")
+func (h *Handler) FilterStories(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
@@ -276,47 +238,26 @@ func FilterStories(c *gin.Context) {
 	limit64 := int64(limit)
 
 	// Filter
-	filter := bson.M{}
-	if genre != "" {
-		filter["genres"] = bson.M{"$in": []string{genre}}
-	}
-	if status != "" {
-		filter["status"] = status
+	filter := repository.StoryFilter{
+		Genre:          genre,
+		Status:         status,
+		AuthorContains: author,
 	}
-	if author != "" {
-		filter["author"] = bson.M{"$regex": author, "$options": "i"}
-	}
-
-	// Tạo options và gán trực tiếp sort ở đây (tránh SA4006)
-	findOptions := options.Find().
-		SetSkip(skip).
-		SetLimit(limit64)
 
-	switch sort {
-	case "views_desc":
-		findOptions.SetSort(bson.D{{Key: "view_count", Value: -1}})
-	case "chapters_desc":
-		findOptions.SetSort(bson.D{{Key: "chapters_count", Value: -1}})
-	case "title_asc":
-		findOptions.SetSort(bson.D{{Key: "title", Value: 1}})
+	order := repository.StorySort(sort)
+	switch order {
+	case repository.SortViewsDesc, repository.SortChaptersDesc, repository.SortTitleAsc:
 	default:
-		findOptions.SetSort(bson.D{{Key: "updated_at", Value: -1}})
+		order = repository.SortUpdatedDesc
 	}
 
 	// Count
-	total, _ := storyCollection.CountDocuments(ctx, filter)
+	total, _ := h.repos.Stories.Count(ctx, filter)
 
 	// Query
-	cursor, err := storyCollection.Find(ctx, filter, findOptions)
+	stories, err := h.repos.Stories.Find(ctx, filter, order, repository.Page{Skip: skip, Limit: limit64})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể truy vấn"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var stories []models.Story
-	if err := cursor.All(ctx, &stories); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi đọc dữ liệu"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -327,28 +268,16 @@ func FilterStories(c *gin.Context) {
 		"stories": stories,
 	})
 }
-func GetTopRankedStories(c *gin.Context) {
-	storyCollection := config.MongoDB.Collection("Stories")
+func (h *Handler) GetTopRankedStories(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	limit64 := int64(limit)
 
-	findOptions := options.Find().
-		SetSort(bson.D{{Key: "view_count", Value: -1}}).
-		SetLimit(limit64)
-
-	cursor, err := storyCollection.Find(ctx, bson.M{}, findOptions)
+	stories, err := h.repos.Stories.Find(ctx, repository.StoryFilter{}, repository.SortViewsDesc, repository.Page{Limit: limit64})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Không thể truy vấn top truyện"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var stories []models.Story
-	if err := cursor.All(ctx, &stories); err != nil {
-		c.JSON(500, gin.H{"error": "Lỗi đọc dữ liệu"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -356,37 +285,26 @@ func GetTopRankedStories(c *gin.Context) {
 }
 
 // GET /stories/:id/export
-func ExportStoryChapters(c *gin.Context) {
+func (h *Handler) ExportStoryChapters(c *gin.Context) {
 	storyIDStr := c.Param("id")
 	storyID, err := primitive.ObjectIDFromHex(storyIDStr)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "ID không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
 
-	storyCollection := config.MongoDB.Collection("Stories")
-	chapterCollection := config.MongoDB.Collection("Chapters")
-
-	var story models.Story
-	err = storyCollection.FindOne(ctx, bson.M{"_id": storyID}).Decode(&story)
+	story, err := h.repos.Stories.FindByID(ctx, storyID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Không tìm thấy truyện"})
+		apperror.Abort(c, apperror.StoryNotFound)
 		return
 	}
 
-	cursor, err := chapterCollection.Find(ctx, bson.M{"story_id": storyID})
+	chapters, err := h.repos.Chapters.FindByStory(ctx, storyID)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Không thể truy vấn chương"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var chapters []models.Chapter
-	if err := cursor.All(ctx, &chapters); err != nil {
-		c.JSON(500, gin.H{"error": "Lỗi đọc dữ liệu chương"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -398,255 +316,176 @@ func ExportStoryChapters(c *gin.Context) {
 }
 
 // GET /stories/featured
-func GetFeaturedStories(c *gin.Context) {
-	storyCollection := config.MongoDB.Collection("Stories")
+func (h *Handler) GetFeaturedStories(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	cursor, err := storyCollection.Find(ctx, bson.M{"is_featured": true})
+	stories, err := h.repos.Stories.Find(ctx, repository.StoryFilter{FeaturedOnly: true}, "", repository.Page{})
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Không thể truy vấn truyện đề cử"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var stories []models.Story
-	if err := cursor.All(ctx, &stories); err != nil {
-		c.JSON(500, gin.H{"error": "Lỗi đọc dữ liệu"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	c.JSON(200, stories)
 }
-func BanStory(c *gin.Context) {
+func (h *Handler) BanStory(c *gin.Context) {
 	title := c.Param("title")
 	if title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu tên truyện"})
+		apperror.Abort(c, apperror.Validation(apperror.Field("title", apperror.Required)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	storyCollection := config.MongoDB.Collection("Stories")
-
 	// Gán trạng thái bị ban
-	update := bson.M{
-		"$set": bson.M{
-			"is_banned":  true,
-			"deleted_at": time.Now(),
-		},
+	err := h.repos.Stories.SetBannedByTitle(ctx, title, true)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.StoryNotFound)
+		return
 	}
-
-	result, err := storyCollection.UpdateOne(ctx, bson.M{"title": title}, update)
-	if err != nil || result.MatchedCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể ban hoặc không tìm thấy truyện"})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
+	metrics.StoriesBanned.Inc()
+
 	c.JSON(http.StatusOK, gin.H{"message": "✅ Truyện đã bị ẩn (soft-delete)"})
 }
-func UnbanStory(c *gin.Context) {
+func (h *Handler) UnbanStory(c *gin.Context) {
 	title := c.Param("title")
 	if title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu tên truyện"})
+		apperror.Abort(c, apperror.Validation(apperror.Field("title", apperror.Required)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	storyCollection := config.MongoDB.Collection("Stories")
-
 	// Gán trạng thái không bị ban
-	update := bson.M{
-		"$set": bson.M{
-			"is_banned":  false,
-			"deleted_at": nil,
-		},
+	err := h.repos.Stories.SetBannedByTitle(ctx, title, false)
+	if err == repository.ErrNotFound {
+		apperror.Abort(c, apperror.StoryNotFound)
+		return
 	}
-
-	result, err := storyCollection.UpdateOne(ctx, bson.M{"title": title}, update)
-	if err != nil || result.MatchedCount == 0 {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể bỏ ban hoặc không tìm thấy truyện"})
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "✅ Truyện đã được bỏ ban"})
 }
-func DeleteStoryByAuthor(c *gin.Context) {
+func (h *Handler) DeleteStoryByAuthor(c *gin.Context) {
 	userIDValue, exists := c.Get("user_id")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Không xác định được người dùng"})
+		apperror.Abort(c, apperror.Unauthenticated)
 		return
 	}
 	userID := userIDValue.(primitive.ObjectID)
 
 	title := c.Param("title")
 	if title == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu tên truyện"})
+		apperror.Abort(c, apperror.Validation(apperror.Field("title", apperror.Required)))
 		return
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	storyCollection := config.MongoDB.Collection("Stories")
-	chapterCollection := config.MongoDB.Collection("Chapters")
-	bookshelfCollection := config.MongoDB.Collection("Bookshelf")
-
 	// 1. Kiểm tra truyện có tồn tại và đúng tác giả không
-	var story models.Story
-	err := storyCollection.FindOne(ctx, bson.M{
-		"title":      title,
-		"created_by": userID,
-		"is_banned":  true, // ❗ Chỉ cho xóa nếu đã bị ẩn trước
-	}).Decode(&story)
-	if err != nil {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Bạn không có quyền xóa truyện này hoặc truyện chưa bị ẩn"})
+	story, err := h.repos.Stories.FindByTitle(ctx, title)
+	// ❗ Chỉ cho xóa nếu đã bị ẩn trước
+	if err != nil || story.CreatedBy != userID || !story.IsBanned {
+		apperror.Abort(c, apperror.StoryDeleteForbidden)
 		return
 	}
 
 	// 2. Xóa chương liên quan
-	_, _ = chapterCollection.DeleteMany(ctx, bson.M{"story_id": story.ID})
+	_ = h.repos.Chapters.DeleteByStory(ctx, story.ID)
 
 	// 3. Xóa khỏi tủ sách người dùng
-	_, _ = bookshelfCollection.DeleteMany(ctx, bson.M{"story_id": story.ID})
+	_ = h.repos.Bookshelf.DeleteByStory(ctx, story.ID)
 
-	// 4. Xóa truyện
-	_, err = storyCollection.DeleteOne(ctx, bson.M{"_id": story.ID})
+	// 4. Xóa truyện (kèm lời mời cộng tác và yêu cầu chuyển quyền)
+	err = h.repos.Stories.Delete(ctx, story.ID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể xóa truyện"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "✅ Truyện đã được xóa vĩnh viễn"})
 }
-func GetStoryContent(c *gin.Context) {
+func (h *Handler) GetStoryContent(c *gin.Context) {
 	storyIDStr := c.Param("id")
 	storyID, err := primitive.ObjectIDFromHex(storyIDStr)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "ID không hợp lệ"})
+		apperror.Abort(c, apperror.InvalidID("id"))
 		return
 	}
 
-	// Log ID truy vấn để kiểm tra
-	fmt.Println("Truy vấn với ID:", storyIDStr)
-
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Kiểm tra lại filter
-	filter := bson.M{
-		"_id": storyID,
-	}
-
-	storyCollection := config.MongoDB.Collection("Stories")
-	var story models.Story
-	err = storyCollection.FindOne(ctx, filter).Decode(&story)
+	story, err := h.repos.Stories.FindByID(ctx, storyID)
 	if err != nil {
-		fmt.Println("Lỗi truy vấn:", err)
-		c.JSON(404, gin.H{"error": "Không tìm thấy truyện"})
+		logging.FromContext(c.Request.Context()).Debug("Không tìm thấy truyện", "story_id", storyIDStr, "error", err)
+		apperror.Abort(c, apperror.StoryNotFound)
 		return
 	}
 
 	c.JSON(200, story)
 }
-func GetGenresWithCount(c *gin.Context) {
-	storyCollection := config.MongoDB.Collection("Stories")
+func (h *Handler) GetGenresWithCount(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	pipeline := []bson.M{
-		{"$unwind": "$genres"},
-		{"$group": bson.M{
-			"_id":   "$genres",
-			"count": bson.M{"$sum": 1},
-		}},
-		{"$sort": bson.M{"count": -1}},
-	}
-
-	cursor, err := storyCollection.Aggregate(ctx, pipeline)
+	results, err := h.repos.Stories.GenreCounts(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể truy vấn thể loại"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var results []bson.M
-	if err := cursor.All(ctx, &results); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi đọc kết quả"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, results)
 }
-func GetAllStoriesOfGenre(c *gin.Context) {
+func (h *Handler) GetAllStoriesOfGenre(c *gin.Context) {
 	// Lấy thể loại từ URL
 	genre := c.Param("genre")
 	if genre == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Thiếu thể loại"})
+		apperror.Abort(c, apperror.Validation(apperror.Field("genre", apperror.Required)))
 		return
 	}
 
-	// Truy vấn đến MongoDB
-	storyCollection := config.MongoDB.Collection("Stories")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	// Tạo bộ lọc để tìm truyện theo thể loại
-	filter := bson.M{"genres": genre}
-	cursor, err := storyCollection.Find(ctx, filter)
+	// Tìm truyện theo thể loại
+	stories, err := h.repos.Stories.Find(ctx, repository.StoryFilter{Genre: genre}, "", repository.Page{})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể truy vấn truyện"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var stories []models.Story
-	if err := cursor.All(ctx, &stories); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi khi đọc dữ liệu"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	// Trả về kết quả truy vấn
 	c.JSON(http.StatusOK, stories)
 }
-func GetNewestUpdatedStoryList(c *gin.Context) {
-	storyCollection := config.MongoDB.Collection("Stories")
-	chapterCollection := config.MongoDB.Collection("Chapters")
-
+func (h *Handler) GetNewestUpdatedStoryList(c *gin.Context) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 	limit64 := int64(limit)
 
-	findOptions := options.Find().
-		SetSort(bson.D{{Key: "updated_at", Value: -1}}).
-		SetLimit(limit64)
-
-	cursor, err := storyCollection.Find(ctx, bson.M{}, findOptions)
+	stories, err := h.repos.Stories.Find(ctx, repository.StoryFilter{}, repository.SortUpdatedDesc, repository.Page{Limit: limit64})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể truy vấn truyện mới nhất"})
-		return
-	}
-	defer cursor.Close(ctx)
-
-	var stories []models.Story
-	if err := cursor.All(ctx, &stories); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi đọc dữ liệu"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
 	var result []models.StoryWithLatestChapter
 
 	for _, story := range stories {
-		var latestChapter models.Chapter
-		err := chapterCollection.FindOne(ctx,
-			bson.M{"story_id": story.ID},
-			options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}),
-		).Decode(&latestChapter)
+		latestChapter, err := h.repos.Chapters.Latest(ctx, story.ID)
 
 		storyWithChapter := models.StoryWithLatestChapter{
 			Story:         story,
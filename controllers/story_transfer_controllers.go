@@ -0,0 +1,309 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/mailer"
+	"Truyen_BE/models"
+	"Truyen_BE/policy"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// findTransferRecipient tìm người nhận truyện, người nhận phải có quyền đăng truyện
+func (h *Handler) findTransferRecipient(ctx context.Context, c *gin.Context, username string) (models.User, bool) {
+	recipient, err := h.findPublicUser(ctx, strings.TrimSpace(username))
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return recipient, false
+	}
+	if !policy.Can(recipient.Role, policy.StoryCreate) {
+		apperror.Abort(c, apperror.RecipientNotAuthor)
+		return recipient, false
+	}
+	return recipient, true
+}
+
+// transferOwnership đổi created_by và ghi lịch sử. Lọc theo chủ cũ để đề nghị đã lỗi thời
+// (truyện đã đổi chủ bằng cách khác) không ghi đè được. Trả false nếu truyện không còn thuộc fromID.
+func transferOwnership(ctx context.Context, storyID, fromID, toID, actorID primitive.ObjectID, forced bool, reason string) (bool, error) {
+	record := models.OwnershipRecord{
+		FromUserID:    fromID,
+		ToUserID:      toID,
+		ActorID:       actorID,
+		Forced:        forced,
+		Reason:        reason,
+		TransferredAt: time.Now(),
+	}
+	result, err := config.MongoDB.Collection("Stories").UpdateOne(ctx,
+		bson.M{"_id": storyID, "created_by": fromID},
+		bson.M{
+			"$set":  bson.M{"created_by": toID, "updated_at": record.TransferredAt},
+			"$push": bson.M{"owner_history": record},
+			// Chủ mới không còn là cộng tác viên của chính truyện mình
+			"$pull": bson.M{"collaborators": bson.M{"user_id": toID}},
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+
+	// Đề nghị đang chờ của chủ cũ không còn hiệu lực
+	_, _ = config.MongoDB.Collection("StoryTransfers").UpdateMany(ctx,
+		bson.M{"story_id": storyID, "status": "pending"},
+		bson.M{"$set": bson.M{"status": "cancelled", "responded_at": record.TransferredAt}},
+	)
+	return true, nil
+}
+
+// POST /stories/:id/transfer → chủ truyện đề nghị chuyển cho người khác
+func (h *Handler) RequestStoryTransfer(c *gin.Context) {
+	var input dto.TransferStory
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	story, ok := findStoryByParam(ctx, c)
+	if !ok {
+		return
+	}
+	recipient, ok := h.findTransferRecipient(ctx, c, input.Username)
+	if !ok {
+		return
+	}
+	if recipient.ID == story.CreatedBy {
+		apperror.Abort(c, apperror.AlreadyOwner)
+		return
+	}
+
+	transfer := models.StoryTransfer{
+		ID:         primitive.NewObjectID(),
+		StoryID:    story.ID,
+		StoryTitle: story.Title,
+		FromUserID: story.CreatedBy,
+		ToUserID:   recipient.ID,
+		Status:     "pending",
+		CreatedAt:  time.Now(),
+	}
+	_, err := config.MongoDB.Collection("StoryTransfers").InsertOne(ctx, transfer)
+	if mongo.IsDuplicateKeyError(err) {
+		apperror.Abort(c, apperror.TransferPending)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if recipient.Email != "" {
+		body := "Xin chào " + recipient.Username + ",\n\nBạn được đề nghị nhận quyền sở hữu truyện \"" + story.Title +
+			"\". Vào mục chuyển nhượng trong tài khoản để chấp nhận hoặc từ chối."
+		if err := mailer.Default.Send(mailer.Message{To: recipient.Email, Subject: "Đề nghị chuyển quyền sở hữu truyện", Body: body}); err != nil {
+			logging.FromContext(c.Request.Context()).Error("Lỗi gửi mail chuyển truyện", "error", err)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã gửi đề nghị chuyển truyện", "transfer": transfer})
+}
+
+// DELETE /stories/:id/transfer → huỷ đề nghị đang chờ
+func CancelStoryTransfer(c *gin.Context) {
+	storyID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	result, err := config.MongoDB.Collection("StoryTransfers").UpdateOne(ctx,
+		bson.M{"story_id": storyID, "status": "pending"},
+		bson.M{"$set": bson.M{"status": "cancelled", "responded_at": time.Now()}},
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if result.MatchedCount == 0 {
+		apperror.Abort(c, apperror.TransferNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã huỷ đề nghị chuyển truyện"})
+}
+
+// GET /users/me/transfers → đề nghị chuyển truyện đang chờ mình trả lời
+func GetMyStoryTransfers(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	cursor, err := config.MongoDB.Collection("StoryTransfers").Find(ctx,
+		bson.M{"to_user_id": userID, "status": "pending"},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	defer cursor.Close(ctx)
+
+	transfers := []models.StoryTransfer{}
+	if err := cursor.All(ctx, &transfers); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transfers": transfers})
+}
+
+// POST /users/me/transfers/:id/accept
+func AcceptStoryTransfer(c *gin.Context) {
+	respondStoryTransfer(c, "accepted")
+}
+
+// POST /users/me/transfers/:id/decline
+func DeclineStoryTransfer(c *gin.Context) {
+	respondStoryTransfer(c, "declined")
+}
+
+func respondStoryTransfer(c *gin.Context, decision string) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	transferID, err := primitive.ObjectIDFromHex(c.Param("id"))
+	if err != nil {
+		apperror.Abort(c, apperror.InvalidID("id"))
+		return
+	}
+
+	// Tài khoản bị hạ quyền sau khi được đề nghị thì không nhận được truyện nữa
+	if decision == "accepted" && !policy.Can(c.GetString("user_role"), policy.StoryCreate) {
+		apperror.Abort(c, apperror.AuthorOnly)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var transfer models.StoryTransfer
+	err = config.MongoDB.Collection("StoryTransfers").FindOneAndUpdate(ctx,
+		bson.M{"_id": transferID, "to_user_id": userID, "status": "pending"},
+		bson.M{"$set": bson.M{"status": decision, "responded_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&transfer)
+	if err == mongo.ErrNoDocuments {
+		apperror.Abort(c, apperror.TransferNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if decision == "declined" {
+		c.JSON(http.StatusOK, gin.H{"message": "✅ Đã từ chối nhận truyện"})
+		return
+	}
+
+	// Đề nghị đã được giữ ở trạng thái accepted (chặn hai lần nhận song song), chuyển thất bại
+	// thì trả lại: lỗi DB → pending để nhận lại được, truyện đã đổi chủ → cancelled
+	transferred, err := transferOwnership(ctx, transfer.StoryID, transfer.FromUserID, userID, userID, false, "")
+	if err != nil || !transferred {
+		reason := error(apperror.StoryChanged)
+		if err != nil {
+			reason = apperror.Internal(err)
+		}
+		if rollbackErr := rollbackStoryTransfer(ctx, transfer.ID, err != nil); rollbackErr != nil {
+			logging.FromContext(c.Request.Context()).Error("Lỗi trả lại trạng thái đề nghị chuyển truyện", "error", rollbackErr)
+		}
+		apperror.Abort(c, reason)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Bạn đã trở thành chủ truyện", "story_id": transfer.StoryID})
+}
+
+// rollbackStoryTransfer trả đề nghị đang giữ ở accepted về pending (retry) hoặc cancelled.
+// Trong lúc giữ, chủ truyện có thể đã gửi đề nghị mới cho cùng truyện: pending lúc đó vi phạm
+// unique index (mỗi truyện một đề nghị pending) nên đề nghị cũ bị huỷ luôn.
+func rollbackStoryTransfer(ctx context.Context, transferID primitive.ObjectID, retry bool) error {
+	transfers := config.MongoDB.Collection("StoryTransfers")
+	filter := bson.M{"_id": transferID, "status": "accepted"}
+
+	if retry {
+		_, err := transfers.UpdateOne(ctx, filter,
+			bson.M{"$set": bson.M{"status": "pending"}, "$unset": bson.M{"responded_at": ""}},
+		)
+		if !mongo.IsDuplicateKeyError(err) {
+			return err
+		}
+	}
+	_, err := transfers.UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"status": "cancelled", "responded_at": time.Now()}},
+	)
+	return err
+}
+
+// POST /admin/story-transfers → admin chuyển truyện ngay, không cần người nhận đồng ý
+func (h *Handler) ForceTransferStory(c *gin.Context) {
+	actorID := c.MustGet("user_id").(primitive.ObjectID)
+
+	var input dto.ForceTransfer
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+	input.Reason = strings.TrimSpace(input.Reason)
+	storyID, _ := primitive.ObjectIDFromHex(input.StoryID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var story models.Story
+	if err := config.MongoDB.Collection("Stories").FindOne(ctx, bson.M{"_id": storyID}).Decode(&story); err != nil {
+		apperror.Abort(c, apperror.StoryNotFound)
+		return
+	}
+	recipient, ok := h.findTransferRecipient(ctx, c, input.Username)
+	if !ok {
+		return
+	}
+	if recipient.ID == story.CreatedBy {
+		apperror.Abort(c, apperror.AlreadyOwner)
+		return
+	}
+
+	transferred, err := transferOwnership(ctx, story.ID, story.CreatedBy, recipient.ID, actorID, true, input.Reason)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	if !transferred {
+		apperror.Abort(c, apperror.StoryChanged)
+		return
+	}
+
+	writeUserAudit(ctx, c, story.CreatedBy, "transfer_story", input.Reason,
+		bson.M{"story_id": story.ID, "to_user_id": recipient.ID})
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã chuyển truyện", "story_id": story.ID, "owner_id": recipient.ID})
+}
@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/dto"
+	"Truyen_BE/models"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const recoveryCodeCount = 10
+
+// verifySecondFactor kiểm tra mã TOTP hoặc mã khôi phục, cập nhật DB để mã không dùng lại được
+func verifySecondFactor(ctx context.Context, users repository.UserRepository, user models.User, code, recoveryCode string) bool {
+	if code != "" {
+		step, ok := utils.ValidateTOTP(user.TOTPSecret, code, time.Now(), user.TOTPLastStep)
+		if !ok {
+			return false
+		}
+		consumed, err := users.ConsumeTOTPStep(ctx, user.ID, step)
+		return err == nil && consumed
+	}
+
+	if recoveryCode != "" {
+		hash := utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode))
+		consumed, err := users.ConsumeRecoveryCode(ctx, user.ID, []string{hash})
+		return err == nil && consumed
+	}
+
+	return false
+}
+
+// POST /users/auth/login/2fa
+func (h *Handler) LoginWith2FA(c *gin.Context) {
+	var input dto.Login2FA
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	userIDStr, err := utils.ParseMFAToken(input.MFAToken)
+	if err != nil {
+		apperror.Abort(c, apperror.MFATokenExpired)
+		return
+	}
+	userID, err := primitive.ObjectIDFromHex(userIDStr)
+	if err != nil {
+		apperror.Abort(c, apperror.Unauthenticated)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.New(apperror.UserNotFound).WithStatus(http.StatusUnauthorized))
+		return
+	}
+	if user.IsBanned(time.Now()) {
+		apperror.Abort(c, apperror.AccountBanned)
+		return
+	}
+	if !user.TOTPEnabled {
+		apperror.Abort(c, apperror.TwoFactorDisabled)
+		return
+	}
+
+	// Mã 2FA sai cũng tính vào bộ đếm chống brute-force
+	if !checkLoginThrottle(ctx, c, user.Username) {
+		return
+	}
+	if !verifySecondFactor(ctx, h.repos.Users, user, input.Code, input.RecoveryCode) {
+		recordLoginFailure(ctx, c, user.Username)
+		apperror.Abort(c, apperror.InvalidTOTPCode)
+		return
+	}
+
+	h.completeLogin(ctx, c, user)
+}
+
+// POST /users/me/2fa/setup → tạo secret mới (chưa bật cho tới khi xác nhận mã)
+func (h *Handler) Setup2FA(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+	username := c.MustGet("username").(string)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if user.TOTPEnabled {
+		apperror.Abort(c, apperror.TwoFactorEnabled)
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	if err := h.repos.Users.SetPendingTOTPSecret(ctx, userID, secret); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"secret":      secret,
+		"otpauth_uri": utils.TOTPURI(config.App.Auth.TOTPIssuer, username, secret),
+	})
+}
+
+// POST /users/me/2fa/enable → xác nhận mã từ app, bật 2FA và trả mã khôi phục (chỉ hiện một lần)
+func (h *Handler) Enable2FA(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	var input dto.TOTPCode
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if user.TOTPPendingSecret == "" {
+		apperror.Abort(c, apperror.TwoFactorNotSetUp)
+		return
+	}
+
+	step, ok := utils.ValidateTOTP(user.TOTPPendingSecret, input.Code, time.Now(), 0)
+	if !ok {
+		apperror.Abort(c, apperror.New(apperror.InvalidTOTPCode).WithStatus(http.StatusBadRequest))
+		return
+	}
+
+	codes, err := utils.GenerateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	if err := h.repos.Users.EnableTOTP(ctx, userID, user.TOTPPendingSecret, step, hashes); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "✅ Đã bật xác thực 2 bước",
+		"recovery_codes": codes,
+	})
+}
+
+// POST /users/me/2fa/disable → cần mật khẩu và mã hiện tại
+func (h *Handler) Disable2FA(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	var input dto.DisableTOTP
+	if err := c.ShouldBindJSON(&input); err != nil {
+		apperror.Abort(c, apperror.Bind(err))
+		return
+	}
+
+	// Role bắt buộc 2FA (mặc định: admin khi REQUIRE_ADMIN_2FA=true) không được tự tắt
+	if role, ok := policy.Get(c.GetString("user_role")); ok && role.Require2FA {
+		apperror.Abort(c, apperror.TwoFactorRequired)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
+	if !user.TOTPEnabled {
+		apperror.Abort(c, apperror.TwoFactorDisabled)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
+		apperror.Abort(c, apperror.WrongPassword)
+		return
+	}
+	if !verifySecondFactor(ctx, h.repos.Users, user, input.Code, input.RecoveryCode) {
+		apperror.Abort(c, apperror.InvalidTOTPCode)
+		return
+	}
+
+	if err := h.repos.Users.DisableTOTP(ctx, userID); err != nil {
+		apperror.Abort(c, apperror.Internal(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "✅ Đã tắt xác thực 2 bước"})
+}
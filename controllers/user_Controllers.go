@@ -1,60 +1,74 @@
 package controllers
 
 import (
-	"Truyen_BE/config"
+	"Truyen_BE/apperror"
+	"Truyen_BE/dto"
+	"Truyen_BE/logging"
+	"Truyen_BE/metrics"
 	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
 	"context"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"golang.org/x/crypto/bcrypt"
 
 )
 
-func RegisterUser(c *gin.Context) {
-	var input struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-		Email    string `json:"email,omitempty"`
-	}
+func (h *Handler) RegisterUser(c *gin.Context) {
+	var input dto.Register
 
 	// Bước 1: Validate đầu vào
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Dữ liệu đầu vào không hợp lệ"})
+		apperror.Abort(c, apperror.Bind(err))
 		return
 	}
 
 	input.Username = strings.TrimSpace(input.Username)
 	input.Email = strings.TrimSpace(input.Email)
 
-	if input.Username == "" || input.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username và Password là bắt buộc"})
-		return
+	if input.Email != "" {
+		email, ok := utils.NormalizeEmail(input.Email)
+		if !ok {
+			apperror.Abort(c, apperror.Validation(apperror.Field("email", apperror.Invalid)))
+			return
+		}
+		input.Email = email
 	}
 
 	// Bước 2: Kiểm tra username đã tồn tại chưa
-	userCollection := config.MongoDB.Collection("Users")
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	count, err := userCollection.CountDocuments(ctx, bson.M{"username": input.Username})
+	taken, err := h.repos.Users.UsernameTaken(ctx, input.Username)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Lỗi kiểm tra username"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
-	if count > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "Username đã tồn tại"})
+	if taken {
+		apperror.Abort(c, apperror.UsernameTaken)
 		return
 	}
+	if input.Email != "" {
+		taken, err = h.repos.Users.EmailTaken(ctx, input.Email)
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+		if taken {
+			apperror.Abort(c, apperror.EmailTaken)
+			return
+		}
+	}
 
 	// Bước 3: Hash mật khẩu
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể mã hóa mật khẩu"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
@@ -70,12 +84,26 @@ func RegisterUser(c *gin.Context) {
 	}
 
 	// Bước 5: Lưu vào MongoDB
-	_, err = userCollection.InsertOne(ctx, newUser)
+	err = h.repos.Users.Insert(ctx, newUser)
+	if err == repository.ErrDuplicate {
+		// Hai request đăng ký đồng thời: unique index chặn lại
+		apperror.Abort(c, apperror.AccountExists)
+		return
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể tạo tài khoản"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 
+	metrics.Registrations.Inc("password")
+
+	// Bước 6: Gửi email xác minh (lỗi gửi mail không chặn việc đăng ký)
+	if newUser.Email != "" {
+		if err := sendVerificationEmail(ctx, newUser); err != nil {
+			logging.FromContext(c.Request.Context()).Error("Lỗi gửi email xác minh", "error", err)
+		}
+	}
+
 	// Trả kết quả (ẩn pass)
 	c.JSON(http.StatusOK, gin.H{
 		"message": "✅ Đăng ký thành công",
@@ -84,36 +112,49 @@ func RegisterUser(c *gin.Context) {
 			"username": newUser.Username,
 			"role":     newUser.Role,
 			"status":   newUser.Status,
+			"email":    newUser.Email,
+			"email_verified": newUser.EmailVerified,
 		},
 	})
 }
-func GetCurrentUser(c *gin.Context) {
-	userID, _ := c.Get("user_id")
-	username, _ := c.Get("username")
-	role, _ := c.Get("user_role")
-	created_at, _ := c.Get("created_at")
-	status, _ := c.Get("status")
+func (h *Handler) GetCurrentUser(c *gin.Context) {
+	userID := c.MustGet("user_id").(primitive.ObjectID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	user, err := h.repos.Users.FindByID(ctx, userID)
+	if err != nil {
+		apperror.Abort(c, apperror.UserNotFound)
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"user_id":  userID,
-		"username": username,
-		"role":     role,
-		"created_at": created_at,
-		"status":   status,
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+		"created_at": user.CreatedAt,
+		"status":   user.Status,
+		"email":    user.Email,
+		"email_verified": user.EmailVerified,
+		"display_name": user.DisplayName,
+		"bio":      user.Bio,
+		"avatar_url": user.AvatarURL,
+		"totp_enabled": user.TOTPEnabled,
 	})
 }
 
 // API handler để lấy truyện của người dùng
-func GetUserStories(c *gin.Context) {
+func (h *Handler) GetUserStories(c *gin.Context) {
     userIDVal, exists := c.Get("user_id")
     if !exists {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "Chưa đăng nhập"})
+        apperror.Abort(c, apperror.Unauthenticated)
         return
     }
 
     userID, ok := userIDVal.(primitive.ObjectID)
     if !ok {
-        c.JSON(http.StatusUnauthorized, gin.H{"error": "ID người dùng không hợp lệ"})
+        apperror.Abort(c, apperror.Unauthenticated)
         return
     }
 
@@ -121,14 +162,10 @@ func GetUserStories(c *gin.Context) {
     ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
     defer cancel()
 
-    var stories []models.Story
-    cursor, err := config.MongoDB.Collection("Stories").Find(ctx, bson.M{"created_by": userID})
+    // Gồm cả truyện user là cộng tác viên
+    stories, err := h.repos.Stories.Find(ctx, repository.StoryFilter{MemberID: userID}, "", repository.Page{})
     if err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể lấy truyện"})
-        return
-    }
-    if err := cursor.All(ctx, &stories); err != nil {
-        c.JSON(http.StatusInternalServerError, gin.H{"error": "Không thể lấy dữ liệu truyện"})
+        apperror.Abort(c, apperror.Internal(err))
         return
     }
 
@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"Truyen_BE/policy"
+	"time"
+)
+
+// ProfilePatch: PATCH /users/me; null (hoặc chuỗi rỗng) xoá trường, email không xoá được
+type ProfilePatch struct {
+	DisplayName *string `json:"display_name" binding:"omitempty,max=50" patch:"nullable"`
+	Bio         *string `json:"bio" binding:"omitempty,max=500" patch:"nullable"`
+	Email       *string `json:"email" binding:"omitempty,max=254"`
+	AvatarURL   *string `json:"avatar_url" binding:"omitempty,max=500" patch:"nullable"`
+}
+
+// CreateAPIKey: POST /users/me/api-keys
+type CreateAPIKey struct {
+	Name      string     `json:"name" binding:"required,notblank,max=50"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1"`
+	ExpiresAt *time.Time `json:"expires_at" binding:"omitempty,future"` // bỏ trống = không hết hạn
+}
+
+// BanUser: admin khoá tài khoản
+type BanUser struct {
+	Reason    string     `json:"reason" binding:"required,notblank,max=500"`
+	ExpiresAt *time.Time `json:"expires_at" binding:"omitempty,future"` // bỏ trống = khoá vĩnh viễn
+}
+
+// UnbanUser: body không bắt buộc
+type UnbanUser struct {
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// ChangeRole: admin đổi vai trò của user
+type ChangeRole struct {
+	Role   string `json:"role" binding:"required"`
+	Reason string `json:"reason" binding:"max=500"`
+}
+
+// UpdateRole: PUT /admin/roles/:name
+type UpdateRole struct {
+	Permissions []policy.Permission `json:"permissions"`
+	Require2FA  bool                `json:"require_2fa"`
+}
@@ -0,0 +1,90 @@
+package dto
+
+// Register: POST /users/register
+type Register struct {
+	Username string `json:"username" binding:"required,notblank,max=50"`
+	Password string `json:"password" binding:"required,min=6,max=72,maxbytes=72"`
+	Email    string `json:"email,omitempty" binding:"omitempty,max=254"`
+}
+
+// Login: POST /users/auth/login
+type Login struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshToken: POST /users/auth/refresh
+type RefreshToken struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// Login2FA: bước 2 của đăng nhập, cần mã TOTP hoặc mã khôi phục
+type Login2FA struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// TOTPCode: xác nhận bật 2FA bằng mã từ ứng dụng xác thực
+type TOTPCode struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTOTP: tắt 2FA cần cả mật khẩu lẫn mã 2FA (hoặc mã khôi phục)
+type DisableTOTP struct {
+	Password     string `json:"password" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// VerifyEmail: token trong link xác minh email
+type VerifyEmail struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// ForgotPassword: gửi link đặt lại mật khẩu
+type ForgotPassword struct {
+	Email string `json:"email" binding:"required,max=254"`
+}
+
+// ResetPassword: đặt mật khẩu mới bằng token trong link
+type ResetPassword struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=72,maxbytes=72"`
+}
+
+// ChangePassword: đổi mật khẩu khi đã đăng nhập. Tài khoản tạo qua SSO chưa có mật khẩu
+// thì bỏ trống old_password và xác nhận lại bằng Reauth để đặt mật khẩu đầu tiên.
+type ChangePassword struct {
+	OldPassword string `json:"old_password"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=72,maxbytes=72"`
+	Reauth
+}
+
+// DeleteAccount: xác nhận lại trước khi xoá tài khoản. Tài khoản có mật khẩu gửi password;
+// tài khoản chỉ đăng nhập qua SSO gửi mã 2FA/mã khôi phục hoặc kết quả đăng nhập lại qua provider.
+type DeleteAccount struct {
+	Password string `json:"password"`
+	Reauth
+}
+
+// Reauth: cách xác nhận lại của tài khoản chưa có mật khẩu: mã 2FA, mã khôi phục
+// hoặc một lần đăng nhập mới qua provider đã liên kết
+type Reauth struct {
+	Code         string      `json:"code"`
+	RecoveryCode string      `json:"recovery_code"`
+	OIDC         *OIDCReauth `json:"oidc"`
+}
+
+// OIDCReauth: code và state nhận được sau POST /users/me/identities/:provider/start
+type OIDCReauth struct {
+	Provider string `json:"provider" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+	State    string `json:"state" binding:"required"`
+}
+
+// OIDCCallback: code và state provider trả về cho frontend
+type OIDCCallback struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
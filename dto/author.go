@@ -0,0 +1,37 @@
+package dto
+
+// AuthorApplication: user gửi đơn xin quyền tác giả
+type AuthorApplication struct {
+	PenName string `json:"pen_name" binding:"required,notblank,max=50"`
+	Sample  string `json:"sample" binding:"required,notblank,max=20000"`
+	Note    string `json:"note" binding:"max=1000"`
+}
+
+// ReviewApplication: lời nhắn khi duyệt/từ chối đơn (bắt buộc khi từ chối)
+type ReviewApplication struct {
+	Message string `json:"message" binding:"max=1000"`
+}
+
+// InviteCollaborator: chủ truyện mời cộng tác (co-author, editor, translator)
+type InviteCollaborator struct {
+	Username string `json:"username" binding:"required,notblank"`
+	Role     string `json:"role" binding:"required"`
+}
+
+// TransferStory: chủ truyện đề nghị chuyển truyện cho tác giả khác
+type TransferStory struct {
+	Username string `json:"username" binding:"required,notblank"`
+}
+
+// ForceTransfer: admin chuyển truyện không cần người nhận đồng ý
+type ForceTransfer struct {
+	StoryID  string `json:"story_id" binding:"required,objectid"`
+	Username string `json:"username" binding:"required,notblank"`
+	Reason   string `json:"reason" binding:"required,notblank,max=500"`
+}
+
+// BookshelfProgress: thêm truyện vào tủ sách / cập nhật chương đọc cuối
+type BookshelfProgress struct {
+	StoryID       string `json:"story_id" binding:"required,objectid"`
+	LastChapterID string `json:"last_chapter_id" binding:"required,objectid"`
+}
@@ -0,0 +1,26 @@
+package dto
+
+// CreateChapter: POST /stories/chapters, số chương do server đánh theo thứ tự
+type CreateChapter struct {
+	StoryID string `json:"story_id" binding:"required,objectid"`
+	Title   string `json:"title" binding:"required,notblank,max=200"`
+	Content string `json:"content" binding:"max=200000"`
+}
+
+// ChapterPatch: PUT/PATCH /stories/chapters/:id; truyện và số chương không đổi được
+type ChapterPatch struct {
+	Title   *string `json:"title" binding:"omitempty,notblank,max=200"`
+	Content *string `json:"content" binding:"omitempty,max=200000" patch:"nullable"`
+}
+
+// ReorderChapters: PUT /stories/:id/chapters/order, liệt kê đủ mọi chương của truyện theo thứ tự mới
+type ReorderChapters struct {
+	ChapterIDs []string `json:"chapter_ids" binding:"required,min=1,dive,objectid"`
+}
+
+// CreateComment: POST /stories/chapters/comment
+type CreateComment struct {
+	StoryID   string `json:"story_id" binding:"required,objectid"`
+	ChapterID string `json:"chapter_id" binding:"required,objectid"`
+	Content   string `json:"content" binding:"required,notblank,max=1000"`
+}
@@ -0,0 +1,95 @@
+package dto
+
+import (
+	"Truyen_BE/apperror"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// Patch: các trường đã kiểm tra của một JSON merge patch, khoá là tên trường JSON
+// (trùng tên trong MongoDB) để $set thẳng vào document
+type Patch map[string]any
+
+// BindPatch đọc body theo JSON merge patch (RFC 7386) vào target — struct gồm các field con trỏ,
+// tag json và binding (luôn bắt đầu bằng omitempty):
+//   - trường không khai báo trong target → NOT_ALLOWED (không lén ghi created_by, view_count...)
+//   - null → đưa trường về giá trị rỗng nếu field có tag patch:"nullable", ngược lại REQUIRED
+//   - trường vắng mặt giữ nguyên
+func BindPatch(c *gin.Context, target any) (Patch, error) {
+	body, err := c.GetRawData()
+	if err != nil {
+		return nil, apperror.Bind(err)
+	}
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(body, &present); err != nil {
+		return nil, apperror.Bind(err)
+	}
+	if present == nil {
+		return nil, apperror.Bind(errors.New("merge patch phải là JSON object"))
+	}
+	if len(present) == 0 {
+		return nil, apperror.New(apperror.NothingToUpdate)
+	}
+
+	fields := patchFields(reflect.TypeOf(target).Elem())
+	keys := make([]string, 0, len(present))
+	for key := range present {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var invalid []apperror.FieldError
+	nulls := map[string]bool{}
+	for _, key := range keys {
+		field, ok := fields[key]
+		switch {
+		case !ok:
+			invalid = append(invalid, apperror.Field(key, apperror.NotAllowed))
+		case bytes.Equal(bytes.TrimSpace(present[key]), []byte("null")):
+			if field.Tag.Get("patch") != "nullable" {
+				invalid = append(invalid, apperror.Field(key, apperror.Required))
+			}
+			nulls[key] = true
+		}
+	}
+	if len(invalid) > 0 {
+		return nil, apperror.Validation(invalid...)
+	}
+
+	if err := json.Unmarshal(body, target); err != nil {
+		return nil, apperror.Bind(err)
+	}
+	if err := binding.Validator.ValidateStruct(target); err != nil {
+		return nil, apperror.Bind(err)
+	}
+
+	value := reflect.ValueOf(target).Elem()
+	patch := make(Patch, len(keys))
+	for _, key := range keys {
+		field := fields[key]
+		if nulls[key] {
+			patch[key] = reflect.Zero(field.Type.Elem()).Interface()
+			continue
+		}
+		patch[key] = value.FieldByIndex(field.Index).Elem().Interface()
+	}
+	return patch, nil
+}
+
+// patchFields: field con trỏ của struct patch theo tên JSON
+func patchFields(t reflect.Type) map[string]reflect.StructField {
+	fields := make(map[string]reflect.StructField, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if name := jsonName(field); name != "" && field.Type.Kind() == reflect.Pointer {
+			fields[name] = field
+		}
+	}
+	return fields
+}
@@ -0,0 +1,46 @@
+package dto
+
+// Genres: thể loại được phép gắn cho truyện
+var Genres = []string{
+	"action", "adventure", "comedy", "drama", "fantasy", "harem", "historical", "horror",
+	"isekai", "martial-arts", "mystery", "romance", "school-life", "sci-fi", "slice-of-life",
+	"sports", "supernatural", "tragedy", "urban", "wuxia", "xianxia",
+}
+
+// Trạng thái phát hành của truyện
+const (
+	StoryActive    = "active"    // đang ra
+	StoryCompleted = "completed" // đã hoàn thành
+	StoryPaused    = "paused"    // tạm ngưng
+)
+
+// IsGenre: genre có nằm trong danh sách Genres không
+func IsGenre(genre string) bool {
+	for _, allowed := range Genres {
+		if genre == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateStory: POST /stories. Chủ sở hữu, lượt xem, trạng thái ban... do server quyết định.
+type CreateStory struct {
+	Title       string   `json:"title" binding:"required,notblank,max=200"`
+	Author      string   `json:"author" binding:"max=100"`
+	Description string   `json:"description" binding:"max=5000"`
+	CoverURL    string   `json:"cover_url" binding:"omitempty,max=500,uri"`
+	Genres      []string `json:"genres" binding:"max=10,dive,genre"`
+	Status      string   `json:"status" binding:"omitempty,oneof=active completed paused"`
+}
+
+// StoryPatch: PUT/PATCH /stories/:id, chỉ các trường dưới đây được sửa
+type StoryPatch struct {
+	Title       *string   `json:"title" binding:"omitempty,notblank,max=200"`
+	Author      *string   `json:"author" binding:"omitempty,max=100" patch:"nullable"`
+	Description *string   `json:"description" binding:"omitempty,max=5000" patch:"nullable"`
+	CoverURL    *string   `json:"cover_url" binding:"omitempty,max=500,uri" patch:"nullable"`
+	Genres      *[]string `json:"genres" binding:"omitempty,max=10,dive,genre" patch:"nullable"`
+	Status      *string   `json:"status" binding:"omitempty,oneof=active completed paused"`
+	IsHidden    *bool     `json:"is_hidden"`
+}
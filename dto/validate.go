@@ -0,0 +1,52 @@
+// Package dto: dữ liệu client gửi lên cho các endpoint ghi. Ràng buộc nằm ở tag binding
+// (validator của gin), lỗi được apperror.Bind đổi thành chi tiết theo từng trường.
+package dto
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func init() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+	// Lỗi trả cho client dùng tên trường JSON thay cho tên field Go
+	v.RegisterTagNameFunc(jsonName)
+	_ = v.RegisterValidation("notblank", func(fl validator.FieldLevel) bool {
+		return strings.TrimSpace(fl.Field().String()) != ""
+	})
+	_ = v.RegisterValidation("objectid", func(fl validator.FieldLevel) bool {
+		return primitive.IsValidObjectID(fl.Field().String())
+	})
+	_ = v.RegisterValidation("genre", func(fl validator.FieldLevel) bool {
+		return IsGenre(fl.Field().String())
+	})
+	// maxbytes: giới hạn theo byte thay vì ký tự (bcrypt chỉ nhận tối đa 72 byte)
+	_ = v.RegisterValidation("maxbytes", func(fl validator.FieldLevel) bool {
+		limit, err := strconv.Atoi(fl.Param())
+		return err == nil && len(fl.Field().String()) <= limit
+	})
+	_ = v.RegisterValidation("future", func(fl validator.FieldLevel) bool {
+		t, ok := fl.Field().Interface().(time.Time)
+		return ok && t.After(time.Now())
+	})
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
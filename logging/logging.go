@@ -0,0 +1,75 @@
+// Package logging: logger JSON dùng chung (log/slog), logger theo từng request đi qua context.
+package logging
+
+import (
+	"Truyen_BE/config"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// New: logger ghi JSON ra w từ level trở lên
+func New(w io.Writer, level slog.Level) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level}))
+}
+
+// Init đặt logger mặc định theo cấu hình; log.Printf cũ cũng đi qua logger này
+func Init(cfg config.LogConfig) {
+	slog.SetDefault(New(os.Stdout, ParseLevel(cfg.Level)))
+}
+
+// ParseLevel: "debug" | "info" | "warn" | "error", giá trị lạ → info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+type loggerKey struct{}
+
+// WithLogger gắn logger (thường đã kèm request_id) vào context
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext lấy logger của request, không có thì dùng logger mặc định
+func FromContext(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// sensitiveHeaders: header không bao giờ được ghi nguyên giá trị ra log
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+const redacted = "[REDACTED]"
+
+// RedactHeaders chuyển header thành map để ghi log, che giá trị các header nhạy cảm
+func RedactHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for name, values := range header {
+		if sensitiveHeaders[http.CanonicalHeaderKey(name)] {
+			out[name] = redacted
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
@@ -0,0 +1,45 @@
+package mailer
+
+import (
+	"Truyen_BE/config"
+	"log"
+	"os"
+)
+
+// Message: một email cần gửi (nội dung text thuần)
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer: interface gửi mail, cho phép đổi SMTP thật ↔ ghi log khi dev/test
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Default là mailer dùng chung cho controllers, được khởi tạo trong Init()
+var Default Mailer = NewLogMailer(os.Stdout)
+
+// Init chọn mailer theo cfg.Driver: "smtp" hoặc "log" (mặc định).
+// Với driver "log", nếu có cfg.LogFile thì ghi nối vào file đó.
+func Init(cfg config.MailConfig) {
+	switch cfg.Driver {
+	case "smtp":
+		Default = NewSMTPMailer(cfg)
+		log.Println("✅ Mailer: SMTP", cfg.SMTPHost)
+	default:
+		path := cfg.LogFile
+		if path == "" {
+			Default = NewLogMailer(os.Stdout)
+			log.Println("⚠️ Mailer: chỉ ghi log ra stdout (MAIL_DRIVER=log)")
+			return
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatal("❌ Không mở được MAIL_LOG_FILE:", err)
+		}
+		Default = NewLogMailer(f)
+		log.Println("⚠️ Mailer: ghi mail vào file", path)
+	}
+}
@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/event"
+)
+
+// Metric HTTP: route là pattern của gin (vd /stories/:id) để số series không phình theo ID
+var (
+	HTTPRequests = NewCounter("http_requests_total",
+		"Số request HTTP theo method, route và status.", "method", "route", "status")
+	HTTPDuration = NewHistogram("http_request_duration_seconds",
+		"Thời gian xử lý request HTTP (giây).", nil, "method", "route")
+)
+
+// Metric MongoDB, lấy từ command monitor của driver
+var MongoCommandDuration = NewHistogram("mongodb_command_duration_seconds",
+	"Thời gian chạy lệnh MongoDB (giây) theo lệnh và kết quả.", nil, "command", "outcome")
+
+// Metric nghiệp vụ
+var (
+	ChaptersPublished = NewCounter("truyen_chapters_published_total", "Số chương đã đăng.")
+	CommentsPosted    = NewCounter("truyen_comments_posted_total", "Số bình luận đã đăng.")
+	Registrations     = NewCounter("truyen_registrations_total", "Số tài khoản mới theo cách đăng ký.", "method")
+	LoginFailures     = NewCounter("truyen_login_failures_total", "Số lần đăng nhập thất bại (sai mật khẩu hoặc mã 2FA).")
+	StoriesBanned     = NewCounter("truyen_stories_banned_total", "Số lần truyện bị ban.")
+)
+
+// MongoMonitor: gắn vào options.Client().SetMonitor để đo thời gian từng lệnh
+func MongoMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			MongoCommandDuration.Observe(evt.Duration.Seconds(), evt.CommandName, "ok")
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			MongoCommandDuration.Observe(evt.Duration.Seconds(), evt.CommandName, "error")
+		},
+	}
+}
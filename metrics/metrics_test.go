@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+// scrape ghi các collector vào một registry riêng (không đụng Default) và trả về text
+func scrape(t *testing.T, collectors ...collector) string {
+	t.Helper()
+	r := newRegistry()
+	for _, c := range collectors {
+		r.register(c)
+	}
+	var b strings.Builder
+	r.Write(&b)
+	return b.String()
+}
+
+func expectLines(t *testing.T, body string, lines ...string) {
+	t.Helper()
+	for _, line := range lines {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("thiếu dòng %q trong:\n%s", line, body)
+		}
+	}
+}
+
+func TestHistogramBucketsAreCumulative(t *testing.T) {
+	h := &HistogramVec{
+		desc:    desc{"test_duration_seconds", "Thời gian.", []string{"route"}},
+		buckets: []float64{0.1, 0.5, 1},
+		values:  make(map[string]*histogramValue),
+	}
+	for _, v := range []float64{0.05, 0.1, 0.3, 0.7, 2} {
+		h.Observe(v, "/a")
+	}
+	h.Observe(0.2, "/b")
+
+	body := scrape(t, h)
+	expectLines(t, body,
+		"# TYPE test_duration_seconds histogram",
+		// 0.1 nằm trong bucket le="0.1" (biên là <=)
+		`test_duration_seconds_bucket{route="/a",le="0.1"} 2`,
+		`test_duration_seconds_bucket{route="/a",le="0.5"} 3`,
+		`test_duration_seconds_bucket{route="/a",le="1"} 4`,
+		`test_duration_seconds_bucket{route="/a",le="+Inf"} 5`,
+		`test_duration_seconds_sum{route="/a"} 3.15`,
+		`test_duration_seconds_count{route="/a"} 5`,
+		`test_duration_seconds_bucket{route="/b",le="0.1"} 0`,
+		`test_duration_seconds_bucket{route="/b",le="0.5"} 1`,
+		`test_duration_seconds_bucket{route="/b",le="+Inf"} 1`,
+	)
+	if got := h.Count("/a"); got != 5 {
+		t.Fatalf("Count = %d, muốn 5", got)
+	}
+}
+
+func TestCounterEscapesLabelsAndHelp(t *testing.T) {
+	c := &CounterVec{
+		desc:   desc{"test_total", "Dòng 1\nđường dẫn C:\\tmp", []string{"value"}},
+		values: make(map[string]*counterValue),
+	}
+	c.Inc(`a"b`)
+	c.Add(2, "x\\y\nz")
+
+	body := scrape(t, c)
+	expectLines(t, body,
+		`# HELP test_total Dòng 1\nđường dẫn C:\\tmp`,
+		`test_total{value="a\"b"} 1`,
+		`test_total{value="x\\y\nz"} 2`,
+	)
+}
+
+func TestCounterWithoutLabelsStartsAtZero(t *testing.T) {
+	c := &CounterVec{desc: desc{"test_events_total", "Sự kiện.", nil}, values: make(map[string]*counterValue)}
+	expectLines(t, scrape(t, c), "test_events_total 0")
+
+	c.Inc()
+	expectLines(t, scrape(t, c), "test_events_total 1")
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	c := &CounterVec{desc: desc{"test_total", "", []string{"a", "b"}}, values: make(map[string]*counterValue)}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("thiếu label phải panic")
+		}
+	}()
+	c.Inc("chỉ-một")
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"Truyen_BE/models"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// apiKeyFromRequest đọc key từ header X-API-Key hoặc "Authorization: ApiKey <key>"
+func apiKeyFromRequest(c *gin.Context) string {
+	if key := strings.TrimSpace(c.GetHeader("X-API-Key")); key != "" {
+		return key
+	}
+	if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "ApiKey ") {
+		return strings.TrimSpace(strings.TrimPrefix(authHeader, "ApiKey "))
+	}
+	return ""
+}
+
+func isReadOnlyMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func hasScope(scopes []string, scope string) bool {
+	if scope == "" {
+		return false
+	}
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticateAPIKey: xác thực bằng API key thay cho access token.
+// Request đọc cần scope "read"; request ghi được Require kiểm tra theo scope của quyền.
+func authenticateAPIKey(c *gin.Context, users repository.UserRepository, rawKey string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	keyCollection := config.MongoDB.Collection("APIKeys")
+	var key models.APIKey
+	err := keyCollection.FindOne(ctx, bson.M{"key_hash": utils.HashToken(rawKey)}).Decode(&key)
+	now := time.Now()
+	if err != nil || !key.IsActive(now) {
+		apperror.Abort(c, apperror.APIKeyInvalid)
+		return
+	}
+	if isReadOnlyMethod(c.Request.Method) && !key.HasScope(policy.ScopeRead) {
+		apperror.Abort(c, apperror.APIKeyScopeDenied)
+		return
+	}
+
+	// Cập nhật last_used_at tối đa mỗi phút một lần để đỡ ghi DB
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) > time.Minute {
+		_, _ = keyCollection.UpdateOne(ctx, bson.M{"_id": key.ID}, bson.M{"$set": bson.M{"last_used_at": now}})
+	}
+
+	user, ok := loadActiveUser(ctx, c, users, key.UserID)
+	if !ok {
+		return
+	}
+
+	// Không có phiên đăng nhập: session_id rỗng để handler không nhầm với phiên nào
+	setUserContext(c, user, primitive.NilObjectID)
+	c.Set("api_key_id", key.ID)
+	c.Set("api_key_scopes", key.Scopes)
+
+	c.Next()
+}
+
+// RejectAPIKeyWrites: các thao tác ghi trên tài khoản (đổi mật khẩu, tạo key...) chỉ làm được bằng phiên đăng nhập
+func RejectAPIKeyWrites() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, isAPIKey := c.Get("api_key_scopes"); isAPIKey && !isReadOnlyMethod(c.Request.Method) {
+			apperror.Abort(c, apperror.APIKeyNotAllowed)
+			return
+		}
+		c.Next()
+	}
+}
@@ -1,93 +1,138 @@
 package middlewares
 
 import (
+	"Truyen_BE/apperror"
 	"Truyen_BE/config"
 	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
 	"context"
-	"log"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// AuthMiddleware xác thực access token hoặc API key; phiên và user đọc qua repos
+func AuthMiddleware(repos repository.Repositories) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// API key (dùng cho script) thay cho access token
+		if rawKey := apiKeyFromRequest(c); rawKey != "" {
+			authenticateAPIKey(c, repos.Users, rawKey)
+			return
+		}
+
 		// 1. Lấy token từ header
 		authHeader := c.GetHeader("Authorization")
 		if !strings.HasPrefix(authHeader, "Bearer ") {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Thiếu hoặc sai định dạng token"})
+			apperror.Abort(c, apperror.Unauthenticated)
 			return
 		}
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		jwtSecret := []byte(os.Getenv("JWT_SECRET"))
-
-		// 2. Parse token
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
-		})
-
-		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token không hợp lệ"})
+		// 2. Kiểm tra chữ ký (theo kid, thuật toán bị ghim theo khoá) và hạn dùng
+		claims, err := utils.ParseAccessToken(tokenString)
+		if err == utils.ErrMFAPending {
+			apperror.Abort(c, apperror.MFAPending)
 			return
 		}
-
-		// 3. Lấy claims từ token
-		claims, ok := token.Claims.(jwt.MapClaims)
-		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token lỗi claims"})
+		if err != nil {
+			apperror.Abort(c, apperror.TokenInvalid)
 			return
 		}
 
+		// 3. Lấy thông tin từ claims
 		userIDStr, ok := claims["user_id"].(string)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token thiếu user_id"})
+			apperror.Abort(c, apperror.TokenInvalid)
 			return
 		}
 		userID, err := primitive.ObjectIDFromHex(userIDStr)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Token user_id không hợp lệ"})
+			apperror.Abort(c, apperror.TokenInvalid)
+			return
+		}
+
+		sessionIDStr, ok := claims["sid"].(string)
+		if !ok {
+			apperror.Abort(c, apperror.TokenInvalid)
+			return
+		}
+		sessionID, err := primitive.ObjectIDFromHex(sessionIDStr)
+		if err != nil {
+			apperror.Abort(c, apperror.TokenInvalid)
 			return
 		}
 
-		// 4. Truy user từ DB
-		userCollection := config.MongoDB.Collection("Users")
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		var user models.User
-		err = userCollection.FindOne(ctx, bson.M{"_id": userID}).Decode(&user)
-		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Không tìm thấy người dùng"})
+		// 4. Kiểm tra phiên chưa bị thu hồi
+		session, err := repos.Sessions.FindByID(ctx, sessionID, userID)
+		now := time.Now()
+		if err != nil || !session.IsActive(now) {
+			apperror.Abort(c, apperror.SessionExpired)
 			return
 		}
+		// Cập nhật last_seen_at tối đa mỗi phút một lần để đỡ ghi DB
+		if now.Sub(session.LastSeenAt) > time.Minute {
+			_ = repos.Sessions.Touch(ctx, sessionID, now, c.ClientIP())
+		}
 
-		// 5. Kiểm tra status
-		if user.Status == "banned" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Tài khoản đã bị khóa"})
+		// 5. Truy user từ DB và kiểm tra status
+		user, ok := loadActiveUser(ctx, c, repos.Users, userID)
+		if !ok {
 			return
 		}
 
 		// 6. Lưu thông tin user vào context
-		c.Set("user_id", user.ID)
-		c.Set("user_role", user.Role)
-		c.Set("username", user.Username)
-		c.Set("created_at", user.CreatedAt) // Lưu created_at vào context
-		c.Set("status", user.Status)        // Lưu status vào context
+		setUserContext(c, user, sessionID)
 
 		// 7. Cho đi tiếp
 		c.Next()
 	}
 }
 
-func LoggingMiddleware(c *gin.Context) {
-	log.Printf("Incoming request: %v", c.Request)
-	c.Next() // Tiếp tục tới các middleware hoặc handler tiếp theo
-	log.Printf("Response: %v", c.Writer.Status())
+// loadActiveUser truy user từ DB, tự abort nếu không tìm thấy hoặc đang bị khoá
+func loadActiveUser(ctx context.Context, c *gin.Context, users repository.UserRepository, userID primitive.ObjectID) (models.User, bool) {
+	user, err := users.FindByID(ctx, userID)
+	if err != nil {
+		// Token còn hạn nhưng user đã bị xoá: coi như chưa đăng nhập
+		apperror.Abort(c, apperror.New(apperror.UserNotFound).WithStatus(http.StatusUnauthorized))
+		return user, false
+	}
+	if user.IsBanned(time.Now()) {
+		apperror.Abort(c, apperror.AccountBanned)
+		return user, false
+	}
+	return user, true
+}
+
+func setUserContext(c *gin.Context, user models.User, sessionID primitive.ObjectID) {
+	c.Set("user_id", user.ID)
+	c.Set("session_id", sessionID)
+	c.Set("user_role", user.Role)
+	c.Set("username", user.Username)
+	c.Set("created_at", user.CreatedAt) // Lưu created_at vào context
+	c.Set("status", user.Status)        // Lưu status vào context
+	c.Set("email_verified", user.EmailVerified)
+	c.Set("totp_enabled", user.TOTPEnabled)
+}
+
+// RequireVerifiedEmail: chặn user chưa xác minh email (bật bằng REQUIRE_VERIFIED_EMAIL=true)
+func RequireVerifiedEmail() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !config.App.Auth.RequireVerifiedEmail {
+			c.Next()
+			return
+		}
+		verified, _ := c.Get("email_verified")
+		if verified != true {
+			apperror.Abort(c, apperror.EmailNotVerified)
+			return
+		}
+		c.Next()
+	}
 }
@@ -0,0 +1,53 @@
+package middlewares
+
+import (
+	"Truyen_BE/apperror"
+	"fmt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorHandler render lỗi mà handler đã ghi bằng apperror.Abort thành JSON thống nhất:
+// {"error": câu thông báo theo Accept-Language, "code", "details", "request_id"}.
+// Nguyên nhân gốc (Cause) không trả cho client, chỉ xuất hiện trong log của RequestLogger.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		err := apperror.From(c.Errors.Last().Err)
+		lang := apperror.Language(c.GetHeader("Accept-Language"))
+
+		body := gin.H{}
+		for key, value := range err.Meta {
+			body[key] = value
+		}
+		body["error"] = apperror.Message(lang, err.Code, err.Params)
+		body["code"] = err.Code
+		if len(err.Fields) > 0 {
+			details := make([]gin.H, 0, len(err.Fields))
+			for _, field := range err.Fields {
+				details = append(details, gin.H{
+					"field":   field.Field,
+					"code":    field.Code,
+					"message": apperror.FieldMessage(lang, field),
+				})
+			}
+			body["details"] = details
+		}
+		if requestID := c.GetString("request_id"); requestID != "" {
+			body["request_id"] = requestID
+		}
+		c.Header("Content-Language", lang)
+		c.JSON(err.Status, body)
+	}
+}
+
+// Recovery chuyển panic thành INTERNAL_ERROR để ErrorHandler render như mọi lỗi khác
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		apperror.Abort(c, apperror.Internal(fmt.Errorf("panic: %v", recovered)))
+	})
+}
@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"Truyen_BE/metrics"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// knownMethods: method chuẩn được giữ nguyên làm label, method khác gộp thành OTHER
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true,
+	http.MethodPatch: true, http.MethodDelete: true, http.MethodOptions: true,
+	http.MethodConnect: true, http.MethodTrace: true,
+}
+
+// Metrics đếm request và đo thời gian xử lý theo route pattern
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			// Không khớp route nào: gộp lại để path lạ không tạo series mới
+			route = "unmatched"
+		}
+		// Method do client tự đặt (X0, X1...) cũng không được tạo series mới
+		method := c.Request.Method
+		if !knownMethods[method] {
+			method = "OTHER"
+		}
+		metrics.HTTPRequests.Inc(method, route, strconv.Itoa(c.Writer.Status()))
+		metrics.HTTPDuration.Observe(time.Since(start).Seconds(), method, route)
+	}
+}
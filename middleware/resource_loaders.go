@@ -0,0 +1,99 @@
+package middlewares
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/models"
+	"Truyen_BE/repository"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// storyResource: chủ sở hữu là người tạo truyện, kèm danh sách cộng tác viên
+func storyResource(story models.Story, err error) (*Resource, error) {
+	if err != nil {
+		return nil, apperror.StoryNotFound
+	}
+	return &Resource{OwnerID: story.CreatedBy, Collaborators: story.Collaborators}, nil
+}
+
+// StoryByID: truyện lấy theo ObjectID trong URL param
+func StoryByID(repos repository.Repositories, param string) ResourceLoader {
+	return func(ctx context.Context, c *gin.Context) (*Resource, error) {
+		storyID, err := primitive.ObjectIDFromHex(c.Param(param))
+		if err != nil {
+			return nil, apperror.InvalidID(param)
+		}
+		return storyResource(repos.Stories.FindByID(ctx, storyID))
+	}
+}
+
+// StoryByTitle: truyện lấy theo tên trong URL param
+func StoryByTitle(repos repository.Repositories, param string) ResourceLoader {
+	return func(ctx context.Context, c *gin.Context) (*Resource, error) {
+		title := c.Param(param)
+		if title == "" {
+			return nil, apperror.Validation(apperror.Field(param, apperror.Required))
+		}
+		return storyResource(repos.Stories.FindByTitle(ctx, title))
+	}
+}
+
+// StoryFromBody: truyện lấy theo trường story_id trong JSON body (body được khôi phục cho handler)
+func StoryFromBody(repos repository.Repositories) ResourceLoader {
+	return func(ctx context.Context, c *gin.Context) (*Resource, error) {
+		bodyBytes, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			return nil, apperror.New(apperror.ValidationFailed).Wrap(err)
+		}
+		c.Request.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
+
+		var body struct {
+			StoryID string `json:"story_id"`
+		}
+		if err := json.Unmarshal(bodyBytes, &body); err != nil || body.StoryID == "" {
+			return nil, apperror.Validation(apperror.Field("story_id", apperror.Required))
+		}
+		storyID, err := primitive.ObjectIDFromHex(body.StoryID)
+		if err != nil {
+			return nil, apperror.InvalidID("story_id")
+		}
+		return storyResource(repos.Stories.FindByID(ctx, storyID))
+	}
+}
+
+// ChapterByID: chủ sở hữu và cộng tác viên của chương là của truyện chứa chương
+func ChapterByID(repos repository.Repositories, param string) ResourceLoader {
+	return func(ctx context.Context, c *gin.Context) (*Resource, error) {
+		chapterID, err := primitive.ObjectIDFromHex(c.Param(param))
+		if err != nil {
+			return nil, apperror.InvalidID(param)
+		}
+
+		chapter, err := repos.Chapters.FindByID(ctx, chapterID)
+		if err != nil {
+			return nil, apperror.ChapterNotFound
+		}
+		return storyResource(repos.Stories.FindByID(ctx, chapter.StoryID))
+	}
+}
+
+// CommentByID: chủ sở hữu bình luận là người viết
+func CommentByID(repos repository.Repositories, param string) ResourceLoader {
+	return func(ctx context.Context, c *gin.Context) (*Resource, error) {
+		commentID, err := primitive.ObjectIDFromHex(c.Param(param))
+		if err != nil {
+			return nil, apperror.InvalidID(param)
+		}
+
+		comment, err := repos.Comments.FindByID(ctx, commentID)
+		if err != nil {
+			return nil, apperror.CommentNotFound
+		}
+		return &Resource{OwnerID: comment.UserID}, nil
+	}
+}
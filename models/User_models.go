@@ -7,9 +7,26 @@ type User struct {
 	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
 	Username  string             `bson:"username" json:"username"`
 	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	EmailVerified bool           `bson:"email_verified" json:"email_verified"`
 	Password  string             `bson:"password,omitempty" json:"-"` // không trả password ra 
 	Status string `bson:"status" json:"status"` // "active", "inactive", "banned"
-	Role      string             `bson:"role" json:"role"`             // "user", "author", "admin"
+	Role      string             `bson:"role" json:"role"`             // "user", "author", "moderator", "admin" (xem policy.Role)
 	AvatarURL string             `bson:"avatar_url,omitempty" json:"avatar_url,omitempty"`
+	BanReason   string           `bson:"ban_reason,omitempty" json:"ban_reason,omitempty"`
+	BannedUntil *time.Time       `bson:"banned_until,omitempty" json:"banned_until,omitempty"` // nil = khoá vĩnh viễn
+	DisplayName string           `bson:"display_name,omitempty" json:"display_name,omitempty"`
+	Bio       string             `bson:"bio,omitempty" json:"bio,omitempty"`
 	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
-}
\ No newline at end of file
+
+	// 2FA (TOTP): secret và mã khôi phục (đã hash) không bao giờ trả ra ngoài
+	TOTPEnabled       bool     `bson:"totp_enabled" json:"totp_enabled"`
+	TOTPSecret        string   `bson:"totp_secret,omitempty" json:"-"`
+	TOTPPendingSecret string   `bson:"totp_pending_secret,omitempty" json:"-"` // secret đang chờ xác nhận khi bật 2FA
+	TOTPLastStep      int64    `bson:"totp_last_step,omitempty" json:"-"`      // chống dùng lại cùng một mã
+	RecoveryCodes     []string `bson:"recovery_codes,omitempty" json:"-"`
+}
+
+// IsBanned: bị khoá và chưa tới thời điểm tự mở khoá
+func (u *User) IsBanned(now time.Time) bool {
+	return u.Status == "banned" && (u.BannedUntil == nil || now.Before(*u.BannedUntil))
+}
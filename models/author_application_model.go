@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// AuthorApplication: đơn xin trở thành tác giả, admin duyệt hoặc từ chối
+type AuthorApplication struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Username      string              `bson:"username" json:"username"`
+	PenName       string              `bson:"pen_name" json:"pen_name"`
+	Sample        string              `bson:"sample" json:"sample"`
+	Note          string              `bson:"note,omitempty" json:"note,omitempty"`
+	Status        string              `bson:"status" json:"status"` // "pending", "approved", "rejected"
+	ReviewerID    *primitive.ObjectID `bson:"reviewer_id,omitempty" json:"reviewer_id,omitempty"`
+	ReviewMessage string              `bson:"review_message,omitempty" json:"review_message,omitempty"`
+	ReviewedAt    *time.Time          `bson:"reviewed_at,omitempty" json:"reviewed_at,omitempty"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Session: một phiên đăng nhập (mỗi thiết bị một phiên), giữ refresh token đã hash
+type Session struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID            primitive.ObjectID `bson:"user_id" json:"user_id"`
+	RefreshTokenHash  string             `bson:"refresh_token_hash" json:"-"`
+	PreviousTokenHash string             `bson:"previous_token_hash,omitempty" json:"-"` // dùng để phát hiện refresh token bị dùng lại
+	UserAgent         string             `bson:"user_agent" json:"user_agent"`
+	IP                string             `bson:"ip" json:"ip"`
+	CreatedAt         time.Time          `bson:"created_at" json:"created_at"`
+	LastSeenAt        time.Time          `bson:"last_seen_at" json:"last_seen_at"`
+	ExpiresAt         time.Time          `bson:"expires_at" json:"expires_at"`
+	RevokedAt         *time.Time         `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"`
+}
+
+// IsActive: phiên chưa bị thu hồi và chưa hết hạn
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
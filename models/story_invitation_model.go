@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StoryInvitation: lời mời cộng tác, người được mời phải chấp nhận mới trở thành cộng tác viên
+type StoryInvitation struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StoryID     primitive.ObjectID `bson:"story_id" json:"story_id"`
+	StoryTitle  string             `bson:"story_title" json:"story_title"`
+	InviterID   primitive.ObjectID `bson:"inviter_id" json:"inviter_id"`
+	InviteeID   primitive.ObjectID `bson:"invitee_id" json:"invitee_id"`
+	Role        string             `bson:"role" json:"role"`
+	Status      string             `bson:"status" json:"status"` // "pending", "accepted", "declined", "cancelled"
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	RespondedAt *time.Time         `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+}
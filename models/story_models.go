@@ -34,6 +34,26 @@ type Story struct {
 	IsBanned      bool               `bson:"is_banned" json:"is_banned"`
 	DeletedAt     *time.Time         `bson:"deleted_at,omitempty" json:"deleted_at,omitempty"`
 	CreatedBy     primitive.ObjectID `bson:"created_by" json:"created_by"`
+	Collaborators []Collaborator     `bson:"collaborators,omitempty" json:"collaborators,omitempty"`
+	OwnerHistory  []OwnershipRecord  `bson:"owner_history,omitempty" json:"owner_history,omitempty"`
+}
+
+// OwnershipRecord: một lần chuyển quyền sở hữu truyện
+type OwnershipRecord struct {
+	FromUserID    primitive.ObjectID `bson:"from_user_id" json:"from_user_id"`
+	ToUserID      primitive.ObjectID `bson:"to_user_id" json:"to_user_id"`
+	ActorID       primitive.ObjectID `bson:"actor_id" json:"actor_id"` // người thực hiện (người nhận hoặc admin)
+	Forced        bool               `bson:"forced" json:"forced"`
+	Reason        string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	TransferredAt time.Time          `bson:"transferred_at" json:"transferred_at"`
+}
+
+// Collaborator: người được chủ truyện mời cùng làm (co-author, editor, translator)
+type Collaborator struct {
+	UserID   primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Username string             `bson:"username" json:"username"`
+	Role     string             `bson:"role" json:"role"`
+	AddedAt  time.Time          `bson:"added_at" json:"added_at"`
 }
 type StoryWithLatestChapter struct {
 	Story
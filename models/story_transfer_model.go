@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// StoryTransfer: đề nghị chuyển quyền sở hữu truyện, người nhận phải chấp nhận
+type StoryTransfer struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	StoryID     primitive.ObjectID `bson:"story_id" json:"story_id"`
+	StoryTitle  string             `bson:"story_title" json:"story_title"`
+	FromUserID  primitive.ObjectID `bson:"from_user_id" json:"from_user_id"`
+	ToUserID    primitive.ObjectID `bson:"to_user_id" json:"to_user_id"`
+	Status      string             `bson:"status" json:"status"` // "pending", "accepted", "declined", "cancelled"
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+	RespondedAt *time.Time         `bson:"responded_at,omitempty" json:"responded_at,omitempty"`
+}
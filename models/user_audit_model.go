@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserAuditLog: lịch sử thao tác quản trị trên tài khoản (ai làm, lúc nào, lý do)
+type UserAuditLog struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Action    string             `bson:"action" json:"action"` // "ban", "unban", "change_role"
+	ActorID   primitive.ObjectID `bson:"actor_id" json:"actor_id"`
+	ActorName string             `bson:"actor_name" json:"actor_name"`
+	Reason    string             `bson:"reason,omitempty" json:"reason,omitempty"`
+	Details   bson.M             `bson:"details,omitempty" json:"details,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
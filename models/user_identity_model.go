@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// UserIdentity: tài khoản ngoài (OpenID Connect) đã liên kết với user
+type UserIdentity struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Provider  string             `bson:"provider" json:"provider"`
+	Subject   string             `bson:"subject" json:"-"` // "sub" trong ID token, duy nhất theo provider
+	Email     string             `bson:"email,omitempty" json:"email,omitempty"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// OIDCState: trạng thái một lần đăng nhập qua provider (state, PKCE verifier, nonce), dùng một lần
+type OIDCState struct {
+	ID           primitive.ObjectID `bson:"_id,omitempty"`
+	StateHash    string             `bson:"state_hash"`
+	Provider     string             `bson:"provider"`
+	CodeVerifier string             `bson:"code_verifier"`
+	Nonce        string             `bson:"nonce"`
+	UserID       primitive.ObjectID `bson:"user_id,omitempty"` // có giá trị khi đang liên kết vào tài khoản sẵn có
+	ExpiresAt    time.Time          `bson:"expires_at"`
+}
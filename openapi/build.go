@@ -0,0 +1,266 @@
+package openapi
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/policy"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Route: mô tả một route gin. Path viết đúng như khi đăng ký với gin (:id, *filepath).
+type Route struct {
+	Method      string
+	Path        string
+	Tag         string
+	Summary     string
+	Description string
+	Auth        bool              // cần access token (hoặc API key)
+	Permission  policy.Permission // quyền middleware Require kiểm tra, rỗng = không kiểm tra
+	Query       []Param
+	Body        any    // DTO của body JSON, nil = không có body
+	MergePatch  bool   // body còn nhận dạng application/merge-patch+json
+	File        string // tên field file khi body là multipart/form-data
+	Response    any    // body của 200: giá trị mẫu kiểu Go hoặc Object, nil = không mô tả
+	ContentType string // kiểu nội dung của 200, mặc định application/json
+	Responses   map[int]any
+	Errors      []apperror.Code
+}
+
+// Param: tham số query
+type Param struct {
+	Name        string
+	Description string
+	Default     string
+	Integer     bool
+	Enum        []string
+}
+
+// objectIDParams: tham số path là ObjectID, sai định dạng thì handler trả VALIDATION_FAILED
+var objectIDParams = map[string]bool{
+	"id": true, "story_id": true, "chapter_id": true, "user_id": true, "invitation_id": true,
+}
+
+var ginParam = regexp.MustCompile(`[:*](\w+)`)
+
+// PathFromGin đổi path gin sang path OpenAPI: /stories/:id → /stories/{id}
+func PathFromGin(path string) string {
+	return ginParam.ReplaceAllString(path, "{$1}")
+}
+
+// Build dựng tài liệu từ bảng route. Method lạ là lỗi lập trình nên panic.
+func Build(info Info, tags []Tag, routes []Route) *Document {
+	s := newSchemas()
+	s.defs["Error"] = errorSchema()
+	s.defs["FieldError"] = fieldErrorSchema()
+
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Tags:    tags,
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: s.defs,
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Access token lấy từ /users/auth/login"},
+				"apiKey":     {Type: "apiKey", In: "header", Name: "X-API-Key", Description: "API key cá nhân (hoặc header Authorization: ApiKey <key>)"},
+			},
+		},
+	}
+	for _, route := range routes {
+		path := PathFromGin(route.Path)
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		slot := item.slot(route.Method)
+		if slot == nil {
+			panic(fmt.Sprintf("openapi: method %s của %s không được hỗ trợ", route.Method, route.Path))
+		}
+		*slot = s.operation(route)
+	}
+	return doc
+}
+
+func (s *schemas) operation(route Route) *Operation {
+	op := &Operation{
+		Summary:     route.Summary,
+		Description: route.Description,
+		OperationID: operationID(route.Method, route.Path),
+		Responses:   map[string]*Response{},
+		Permission:  string(route.Permission),
+	}
+	if route.Tag != "" {
+		op.Tags = []string{route.Tag}
+	}
+	if route.Auth {
+		op.Security = []map[string][]string{{"bearerAuth": {}}, {"apiKey": {}}}
+	}
+
+	for _, match := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+		schema := &Schema{Type: "string"}
+		if objectIDParams[match[1]] {
+			schema.Pattern = objectIDPattern
+		} else if match[1] == "number" {
+			schema.Type = "integer"
+		}
+		op.Parameters = append(op.Parameters, &Parameter{Name: match[1], In: "path", Required: true, Schema: schema})
+	}
+	for _, param := range route.Query {
+		schema := &Schema{Type: "string"}
+		if param.Integer {
+			schema.Type = "integer"
+		}
+		if param.Default != "" {
+			schema.Default = param.Default
+			if param.Integer {
+				schema.Default, _ = strconv.Atoi(param.Default)
+			}
+		}
+		for _, value := range param.Enum {
+			schema.Enum = append(schema.Enum, value)
+		}
+		op.Parameters = append(op.Parameters, &Parameter{Name: param.Name, In: "query", Description: param.Description, Schema: schema})
+	}
+
+	switch {
+	case route.Body != nil:
+		body := &MediaType{Schema: s.of(route.Body)}
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{"application/json": body}}
+		if route.MergePatch {
+			op.RequestBody.Content["application/merge-patch+json"] = body
+		}
+	case route.File != "":
+		form := &Schema{
+			Type:       "object",
+			Properties: map[string]*Schema{route.File: {Type: "string", Format: "binary"}},
+			Required:   []string{route.File},
+		}
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]*MediaType{"multipart/form-data": {Schema: form}}}
+	}
+
+	op.Responses["200"] = s.response(http.StatusOK, route.ContentType, route.Response)
+	for status, body := range route.Responses {
+		op.Responses[strconv.Itoa(status)] = s.response(status, "", body)
+	}
+	for status, codes := range groupByStatus(errorCodes(route)) {
+		enum := make([]any, len(codes))
+		names := make([]string, len(codes))
+		for i, code := range codes {
+			enum[i], names[i] = code, string(code)
+		}
+		op.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status) + ": " + strings.Join(names, ", "),
+			Content: map[string]*MediaType{"application/json": {Schema: &Schema{AllOf: []*Schema{
+				Ref("Error"),
+				{Properties: map[string]*Schema{"code": {Enum: enum}}},
+			}}}},
+		}
+	}
+	return op
+}
+
+func (s *schemas) response(status int, contentType string, body any) *Response {
+	response := &Response{Description: http.StatusText(status)}
+	switch {
+	case contentType == "" && body == nil:
+		return response
+	case contentType == "":
+		contentType = "application/json"
+	}
+	schema := &Schema{Type: "string"}
+	if body != nil {
+		schema = s.of(body)
+	}
+	response.Content = map[string]*MediaType{contentType: {Schema: schema}}
+	return response
+}
+
+// errorCodes: mã lỗi riêng của route cộng với mã do middleware và binding sinh ra
+func errorCodes(route Route) []apperror.Code {
+	codes := append([]apperror.Code{}, route.Errors...)
+	validates := route.Body != nil || route.File != "" || len(route.Query) > 0
+	for _, match := range ginParam.FindAllStringSubmatch(route.Path, -1) {
+		validates = validates || objectIDParams[match[1]]
+	}
+	if validates {
+		codes = append(codes, apperror.ValidationFailed)
+	}
+	if route.MergePatch {
+		codes = append(codes, apperror.NothingToUpdate)
+	}
+	if route.Auth {
+		codes = append(codes, apperror.Unauthenticated, apperror.TokenInvalid, apperror.SessionExpired,
+			apperror.MFAPending, apperror.APIKeyInvalid, apperror.AccountBanned)
+	}
+	if route.Permission != "" {
+		codes = append(codes, apperror.Forbidden, apperror.APIKeyScopeDenied, apperror.TwoFactorRequired)
+	}
+	return append(codes, apperror.InternalError)
+}
+
+func groupByStatus(codes []apperror.Code) map[int][]apperror.Code {
+	groups := map[int][]apperror.Code{}
+	seen := map[apperror.Code]bool{}
+	for _, code := range codes {
+		if !seen[code] {
+			seen[code] = true
+			groups[code.Status()] = append(groups[code.Status()], code)
+		}
+	}
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool { return group[i] < group[j] })
+	}
+	return groups
+}
+
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.Split(strings.Trim(ginParam.ReplaceAllString(path, "by_$1"), "/"), "/") {
+		part = strings.NewReplacer("-", "_", ".", "").Replace(part)
+		if part != "" {
+			id += "_" + part
+		}
+	}
+	return id
+}
+
+func errorSchema() *Schema {
+	codes := apperror.Codes()
+	enum := make([]any, len(codes))
+	for i, code := range codes {
+		enum[i] = code
+	}
+	return &Schema{
+		Type:        "object",
+		Description: "Định dạng lỗi chung; có thể kèm trường bổ sung như retry_after",
+		Properties: map[string]*Schema{
+			"error":      {Type: "string", Description: "Câu thông báo theo Accept-Language (vi mặc định, en)"},
+			"code":       {Type: "string", Enum: enum},
+			"details":    {Type: "array", Items: Ref("FieldError")},
+			"request_id": {Type: "string"},
+		},
+		Required:             []string{"error", "code"},
+		AdditionalProperties: true,
+	}
+}
+
+func fieldErrorSchema() *Schema {
+	enum := make([]any, len(apperror.FieldCodes))
+	for i, code := range apperror.FieldCodes {
+		enum[i] = code
+	}
+	return &Schema{
+		Type: "object",
+		Properties: map[string]*Schema{
+			"field":   {Type: "string", Description: "Tên trường JSON, phần tử mảng dạng genres[1]"},
+			"code":    {Type: "string", Enum: enum},
+			"message": {Type: "string"},
+		},
+		Required: []string{"field", "code", "message"},
+	}
+}
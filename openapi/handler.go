@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	"Truyen_BE/apperror"
+	"embed"
+	"io/fs"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUI: Swagger UI nhúng vào binary, /docs không tải gì từ CDN nên chạy được cả khi offline
+//
+//go:embed swagger-ui/index.html swagger-ui/swagger-initializer.js swagger-ui/swagger-ui-bundle.js swagger-ui/swagger-ui.css
+//go:embed swagger-ui/LICENSE swagger-ui/NOTICE swagger-ui/swagger-ui-bundle.js.LICENSE.txt
+var swaggerUI embed.FS
+
+var swaggerAssets, _ = fs.Sub(swaggerUI, "swagger-ui")
+
+// Handler trả tài liệu dạng JSON (GET /openapi.json)
+func Handler(doc *Document) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, doc)
+	}
+}
+
+// SwaggerUI trả trang Swagger UI đọc tài liệu từ /openapi.json
+func SwaggerUI() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := fs.ReadFile(swaggerAssets, "index.html")
+		if err != nil {
+			apperror.Abort(c, apperror.Internal(err))
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	}
+}
+
+// SwaggerAssets phục vụ CSS/JS của Swagger UI, route cần có tham số *filepath
+func SwaggerAssets() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.FileFromFS(c.Param("filepath"), http.FS(swaggerAssets))
+	}
+}
@@ -0,0 +1,253 @@
+package openapi
+
+import (
+	"Truyen_BE/dto"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// objectIDPattern: ObjectID của MongoDB ở dạng hex
+const objectIDPattern = "^[0-9a-f]{24}$"
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+)
+
+// Object: response dựng bằng gin.H. Giá trị chỉ dùng để lấy kiểu của trường,
+// vd Object{"message": "", "story": models.Story{}}.
+type Object map[string]any
+
+// schemas gom struct có tên vào components.schemas, mỗi kiểu Go một tên
+type schemas struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+}
+
+func newSchemas() *schemas {
+	return &schemas{defs: map[string]*Schema{}, names: map[reflect.Type]string{}}
+}
+
+// of: schema của giá trị mẫu (kiểu Go, Object hoặc []Object)
+func (s *schemas) of(v any) *Schema {
+	switch v := v.(type) {
+	case Object:
+		schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+		for name, field := range v {
+			schema.Properties[name] = s.of(field)
+		}
+		return schema
+	case []Object:
+		item := &Schema{Type: "object"}
+		if len(v) > 0 {
+			item = s.of(v[0])
+		}
+		return &Schema{Type: "array", Items: item}
+	}
+	return s.forType(reflect.TypeOf(v))
+}
+
+func (s *schemas) forType(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case objectIDType:
+		return &Schema{Type: "string", Pattern: objectIDPattern}
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(s.forType(t.Elem()))
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.forType(t.Elem())}
+	case reflect.Map:
+		var values any = true
+		if t.Elem().Kind() != reflect.Interface {
+			values = s.forType(t.Elem())
+		}
+		return &Schema{Type: "object", AdditionalProperties: values}
+	case reflect.Struct:
+		return s.ref(t)
+	}
+	return &Schema{}
+}
+
+// ref đăng ký struct vào components (struct ẩn danh thì viết thẳng)
+func (s *schemas) ref(t reflect.Type) *Schema {
+	if t.Name() == "" {
+		return s.structSchema(t)
+	}
+	name, ok := s.names[t]
+	if !ok {
+		name = strings.ToUpper(t.Name()[:1]) + t.Name()[1:]
+		if _, taken := s.defs[name]; taken {
+			name = path.Base(t.PkgPath()) + "." + name
+		}
+		s.names[t] = name
+		// Giữ chỗ trước để kiểu tự tham chiếu không đệ quy vô hạn
+		s.defs[name] = &Schema{}
+		*s.defs[name] = *s.structSchema(t)
+	}
+	return Ref(name)
+}
+
+func (s *schemas) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	s.addFields(schema, t, isPatch(t))
+	if isPatch(t) {
+		schema.Description = "JSON merge patch (RFC 7386): trường vắng mặt giữ nguyên, null xoá trường cho phép rỗng"
+	}
+	return schema
+}
+
+// addFields: field nhúng không có tag json được gộp vào struct cha như encoding/json
+func (s *schemas) addFields(schema *Schema, t reflect.Type, patch bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded, patch)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property, required := s.field(field, patch)
+		schema.Properties[name] = property
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// field: schema của một trường theo kiểu Go và tag binding. Trong struct patch,
+// field con trỏ chỉ nhận null khi có tag patch:"nullable".
+func (s *schemas) field(field reflect.StructField, patch bool) (*Schema, bool) {
+	t := field.Type
+	canBeNull := false
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		canBeNull = !patch || field.Tag.Get("patch") == "nullable"
+	}
+	schema := s.forType(t)
+	required := false
+	if schema.Ref == "" {
+		required = applyBinding(schema, field.Tag.Get("binding"))
+	}
+	if canBeNull {
+		schema = nullable(schema)
+	}
+	return schema, required && !patch
+}
+
+// applyBinding chép ràng buộc validator sang JSON Schema; các luật sau "dive" áp cho phần tử
+func applyBinding(schema *Schema, tag string) (required bool) {
+	target := schema
+	for _, rule := range strings.Split(tag, ",") {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			if target == schema {
+				required = true
+			}
+		case "dive":
+			if target.Items == nil {
+				return required
+			}
+			target = target.Items
+		case "notblank":
+			if target.MinLength == nil {
+				target.MinLength = intPtr(1)
+			}
+		case "min", "max":
+			n, err := strconv.Atoi(param)
+			if err != nil {
+				continue
+			}
+			list := target.Type == "array"
+			switch {
+			case name == "min" && list:
+				target.MinItems = intPtr(n)
+			case name == "min":
+				target.MinLength = intPtr(n)
+			case list:
+				target.MaxItems = intPtr(n)
+			default:
+				target.MaxLength = intPtr(n)
+			}
+		case "oneof":
+			for _, value := range strings.Fields(param) {
+				target.Enum = append(target.Enum, value)
+			}
+		case "uri":
+			target.Format = "uri"
+		case "objectid":
+			target.Pattern = objectIDPattern
+		case "genre":
+			for _, genre := range dto.Genres {
+				target.Enum = append(target.Enum, genre)
+			}
+		case "future":
+			target.Description = "Phải là thời điểm trong tương lai"
+		}
+	}
+	return required
+}
+
+// nullable: cho phép thêm null (OpenAPI 3.1 bỏ từ khoá nullable, dùng type mảng)
+func nullable(schema *Schema) *Schema {
+	if schema.Ref != "" {
+		return &Schema{OneOf: []*Schema{schema, {Type: "null"}}}
+	}
+	if typ, ok := schema.Type.(string); ok {
+		copied := *schema
+		copied.Type = []string{typ, "null"}
+		return &copied
+	}
+	return schema
+}
+
+// isPatch: struct dùng cho JSON merge patch (có field mang tag patch)
+func isPatch(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("patch"); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func intPtr(n int) *int { return &n }
@@ -0,0 +1,35 @@
+package policy
+
+// Scope của API key: giới hạn thêm trên quyền của role, không cấp thêm quyền.
+// Thao tác không thuộc scope nào (quản trị, bình luận, tài khoản...) chỉ làm được bằng phiên đăng nhập.
+const (
+	ScopeRead          = "read"
+	ScopeChaptersWrite = "chapters:write"
+	ScopeStoriesWrite  = "stories:write"
+)
+
+var Scopes = []string{ScopeRead, ScopeChaptersWrite, ScopeStoriesWrite}
+
+var permissionScopes = map[Permission]string{
+	StoryCreate:    ScopeStoriesWrite,
+	StoryUpdate:    ScopeStoriesWrite,
+	StoryDelete:    ScopeStoriesWrite,
+	ChapterCreate:  ScopeChaptersWrite,
+	ChapterUpdate:  ScopeChaptersWrite,
+	ChapterDelete:  ScopeChaptersWrite,
+	ChapterReorder: ScopeChaptersWrite,
+}
+
+func IsValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeFor trả về scope API key cần có để dùng quyền p ("" = API key không được dùng)
+func ScopeFor(p Permission) string {
+	return permissionScopes[p]
+}
@@ -0,0 +1,32 @@
+package policy
+
+// Quyền của cộng tác viên trên một truyện cụ thể, do chủ truyện mời.
+// Các quyền này áp dụng riêng cho truyện đó, không phụ thuộc role toàn hệ thống
+// (ví dụ một "user" được mời làm translator vẫn đăng được chương cho truyện đó).
+const (
+	CollaboratorCoAuthor   = "co-author"
+	CollaboratorEditor     = "editor"
+	CollaboratorTranslator = "translator"
+)
+
+var collaboratorRights = map[string][]Permission{
+	CollaboratorCoAuthor:   {StoryUpdate, ChapterCreate, ChapterUpdate, ChapterDelete},
+	CollaboratorEditor:     {ChapterUpdate},
+	CollaboratorTranslator: {ChapterCreate, ChapterUpdate},
+}
+
+// IsCollaboratorRole: vai trò có thể mời (chủ truyện là created_by, không mời được)
+func IsCollaboratorRole(role string) bool {
+	_, ok := collaboratorRights[role]
+	return ok
+}
+
+// CollaboratorCan: cộng tác viên với vai trò collabRole có được làm p trên truyện không
+func CollaboratorCan(collabRole string, p Permission) bool {
+	for _, granted := range collaboratorRights[collabRole] {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
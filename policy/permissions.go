@@ -0,0 +1,62 @@
+package policy
+
+// Permission: quyền dạng "tài_nguyên:hành_động".
+//
+// Với các hành động trên tài nguyên có chủ sở hữu (truyện, chương, bình luận),
+// role không được cấp quyền gốc mà được cấp một trong hai biến thể:
+//   - "<quyền>_own": chỉ thao tác trên tài nguyên của chính mình
+//   - "<quyền>_any": thao tác trên mọi tài nguyên
+type Permission string
+
+const (
+	StoryCreate Permission = "story:create"
+	StoryUpdate Permission = "story:update" // _own / _any
+	StoryDelete Permission = "story:delete" // _own / _any
+	StoryBan    Permission = "story:ban"
+
+	StoryManageCollaborators Permission = "story:manage_collaborators" // _own / _any
+	StoryTransfer            Permission = "story:transfer"             // _own: chủ truyện đề nghị chuyển
+	StoryForceTransfer       Permission = "story:force_transfer"       // chuyển ngay, không cần người nhận đồng ý
+
+	ChapterCreate Permission = "chapter:create" // _own / _any (theo truyện chứa chương)
+	ChapterUpdate Permission = "chapter:update" // _own / _any
+	ChapterDelete Permission = "chapter:delete" // _own / _any
+	// ChapterReorder: sắp xếp lại thứ tự chương; cộng tác viên không có quyền này nên _own chỉ dành cho chủ truyện
+	ChapterReorder Permission = "chapter:reorder" // _own / _any
+
+	CommentCreate Permission = "comment:create"
+	CommentDelete Permission = "comment:delete" // _own / _any
+
+	UserList        Permission = "user:list"
+	UserBan         Permission = "user:ban"
+	UserManageRoles Permission = "user:manage_roles"
+
+	AuthorApplicationReview Permission = "author_application:review"
+	RoleManage              Permission = "role:manage"
+)
+
+// Own: biến thể chỉ áp dụng cho tài nguyên của chính mình
+func (p Permission) Own() Permission { return p + "_own" }
+
+// Any: biến thể áp dụng cho mọi tài nguyên
+func (p Permission) Any() Permission { return p + "_any" }
+
+// All liệt kê mọi quyền hợp lệ (dùng để cấp cho admin và kiểm tra khi sửa role)
+var All = []Permission{
+	StoryCreate, StoryUpdate.Own(), StoryUpdate.Any(), StoryDelete.Own(), StoryDelete.Any(), StoryBan,
+	StoryManageCollaborators.Own(), StoryManageCollaborators.Any(), StoryTransfer.Own(), StoryTransfer.Any(), StoryForceTransfer,
+	ChapterCreate.Own(), ChapterCreate.Any(), ChapterUpdate.Own(), ChapterUpdate.Any(), ChapterDelete.Own(), ChapterDelete.Any(),
+	ChapterReorder.Own(), ChapterReorder.Any(),
+	CommentCreate, CommentDelete.Own(), CommentDelete.Any(),
+	UserList, UserBan, UserManageRoles,
+	AuthorApplicationReview, RoleManage,
+}
+
+func IsValid(p Permission) bool {
+	for _, known := range All {
+		if known == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package policy
+
+import (
+	"Truyen_BE/config"
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Role: tập quyền, lưu trong collection Roles (_id = tên role)
+type Role struct {
+	Name        string       `bson:"_id" json:"name"`
+	Permissions []Permission `bson:"permissions" json:"permissions"`
+	Require2FA  bool         `bson:"require_2fa" json:"require_2fa"`
+}
+
+func (r Role) Has(p Permission) bool {
+	for _, granted := range r.Permissions {
+		if granted == p {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultRoles: cấu hình mặc định, document trong DB (nếu có) sẽ ghi đè theo từng role
+func defaultRoles() map[string]Role {
+	reader := []Permission{CommentCreate, CommentDelete.Own()}
+	author := append(append([]Permission{}, reader...),
+		StoryCreate, StoryUpdate.Own(), StoryDelete.Own(), StoryManageCollaborators.Own(), StoryTransfer.Own(),
+		ChapterCreate.Own(), ChapterUpdate.Own(), ChapterDelete.Own(), ChapterReorder.Own(),
+	)
+	moderator := append(append([]Permission{}, reader...),
+		StoryBan, CommentDelete.Any(), UserList, UserBan, AuthorApplicationReview,
+	)
+
+	return map[string]Role{
+		"user":      {Name: "user", Permissions: reader},
+		"author":    {Name: "author", Permissions: author},
+		"moderator": {Name: "moderator", Permissions: moderator},
+		"admin":     {Name: "admin", Permissions: append([]Permission{}, All...), Require2FA: config.App.Auth.RequireAdmin2FA},
+	}
+}
+
+var (
+	mu    sync.RWMutex
+	roles = defaultRoles()
+)
+
+// Get trả về role theo tên (role lạ → không có quyền gì)
+func Get(name string) (Role, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	role, ok := roles[name]
+	return role, ok
+}
+
+// Can: role có đúng quyền p hay không
+func Can(roleName string, p Permission) bool {
+	role, ok := Get(roleName)
+	return ok && role.Has(p)
+}
+
+// Covers: role roleName có mọi quyền của role other (role lạ không bao trùm gì,
+// role other lạ thì không có quyền nào nên luôn bị bao trùm)
+func Covers(roleName, other string) bool {
+	role, ok := Get(roleName)
+	if !ok {
+		return false
+	}
+	replaced, _ := Get(other)
+	for _, p := range replaced.Permissions {
+		if !role.Has(p) {
+			return false
+		}
+	}
+	return true
+}
+
+// List trả về mọi role, sắp theo tên
+func List() []Role {
+	mu.RLock()
+	defer mu.RUnlock()
+	result := make([]Role, 0, len(roles))
+	for _, role := range roles {
+		result = append(result, role)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// Load đọc collection Roles và ghi đè lên cấu hình mặc định
+func Load(ctx context.Context, collection *mongo.Collection) error {
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	var stored []Role
+	if err := cursor.All(ctx, &stored); err != nil {
+		return err
+	}
+
+	loaded := defaultRoles()
+	for _, role := range stored {
+		loaded[role.Name] = role
+	}
+
+	mu.Lock()
+	roles = loaded
+	mu.Unlock()
+	return nil
+}
+
+// StartAutoReload nạp lại role định kỳ để các instance khác thấy thay đổi
+func StartAutoReload(collection *mongo.Collection, interval time.Duration) {
+	go func() {
+		for range time.Tick(interval) {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := Load(ctx, collection); err != nil {
+				log.Printf("⚠️ Không thể nạp lại phân quyền: %v", err)
+			}
+			cancel()
+		}
+	}()
+}
+
+var ErrInvalidRole = errors.New("cấu hình role không hợp lệ")
+
+// Save lưu (upsert) một role vào DB rồi nạp lại
+func Save(ctx context.Context, collection *mongo.Collection, role Role) error {
+	if role.Name == "" {
+		return ErrInvalidRole
+	}
+	for _, p := range role.Permissions {
+		if !IsValid(p) {
+			return ErrInvalidRole
+		}
+	}
+	// Không cho admin tự mất quyền quản lý role (tránh khoá mình ngoài hệ thống)
+	if role.Name == "admin" && !role.Has(RoleManage) {
+		return ErrInvalidRole
+	}
+
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": role.Name}, role, options.Replace().SetUpsert(true))
+	if err != nil {
+		return err
+	}
+	return Load(ctx, collection)
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// applyFields ghi đè các trường (theo tên bson) lên doc giống $set của MongoDB,
+// bằng cách encode doc ra BSON, sửa rồi decode lại
+func applyFields(doc any, fields map[string]any) error {
+	raw, err := bson.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var m bson.M
+	if err := bson.Unmarshal(raw, &m); err != nil {
+		return err
+	}
+	for key, value := range fields {
+		m[key] = value
+	}
+	if raw, err = bson.Marshal(m); err != nil {
+		return err
+	}
+	return bson.Unmarshal(raw, doc)
+}
+
+// paginate cắt danh sách theo Page
+func paginate[T any](items []T, page Page) []T {
+	if page.Skip > 0 {
+		if page.Skip >= int64(len(items)) {
+			return []T{}
+		}
+		items = items[page.Skip:]
+	}
+	if page.Limit > 0 && page.Limit < int64(len(items)) {
+		items = items[:page.Limit]
+	}
+	return items
+}
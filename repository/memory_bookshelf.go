@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type memoryBookshelfItem struct {
+	userID    primitive.ObjectID
+	storyID   primitive.ObjectID
+	chapterID primitive.ObjectID
+	addedAt   time.Time
+	updatedAt time.Time
+}
+
+// MemoryBookshelfRepository lưu tủ sách trong RAM, tên truyện/chương lấy từ repository truyện/chương đi kèm
+type MemoryBookshelfRepository struct {
+	mu       sync.RWMutex
+	items    []memoryBookshelfItem
+	stories  StoryRepository
+	chapters ChapterRepository
+}
+
+func NewMemoryBookshelfRepository(stories StoryRepository, chapters ChapterRepository) *MemoryBookshelfRepository {
+	return &MemoryBookshelfRepository{stories: stories, chapters: chapters}
+}
+
+func (r *MemoryBookshelfRepository) List(ctx context.Context, userID primitive.ObjectID, sortBy string, order int, page Page) ([]BookshelfEntry, error) {
+	r.mu.RLock()
+	items := []memoryBookshelfItem{}
+	for _, item := range r.items {
+		if item.userID == userID {
+			items = append(items, item)
+		}
+	}
+	r.mu.RUnlock()
+
+	entries := make([]BookshelfEntry, 0, len(items))
+	for _, item := range items {
+		entry := BookshelfEntry{AddedAt: item.addedAt, UpdatedAt: item.updatedAt}
+		// Giống $lookup: truyện/chương đã bị xoá thì bỏ trống các trường tương ứng
+		if story, err := r.stories.FindByID(ctx, item.storyID); err == nil {
+			entry.StoryID = &story.ID
+			entry.StoryTitle = story.Title
+		}
+		if chapter, err := r.chapters.FindByID(ctx, item.chapterID); err == nil {
+			entry.LastChapterID = &chapter.ID
+			entry.ChapterNumber = chapter.ChapterNumber
+			entry.ChapterTitle = chapter.Title
+		}
+		entries = append(entries, entry)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		var cmp int
+		switch sortBy {
+		case "added_at":
+			cmp = a.AddedAt.Compare(b.AddedAt)
+		case "story_title":
+			cmp = strings.Compare(a.StoryTitle, b.StoryTitle)
+		case "chapter_number":
+			cmp = a.ChapterNumber - b.ChapterNumber
+		default:
+			cmp = a.UpdatedAt.Compare(b.UpdatedAt)
+		}
+		return cmp*order < 0
+	})
+	return paginate(entries, page), nil
+}
+
+func (r *MemoryBookshelfRepository) SaveProgress(_ context.Context, userID, storyID, chapterID primitive.ObjectID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for i, item := range r.items {
+		if item.userID == userID && item.storyID == storyID {
+			r.items[i].chapterID = chapterID
+			r.items[i].updatedAt = now
+			return false, nil
+		}
+	}
+	r.items = append(r.items, memoryBookshelfItem{
+		userID:    userID,
+		storyID:   storyID,
+		chapterID: chapterID,
+		addedAt:   now,
+		updatedAt: now,
+	})
+	return true, nil
+}
+
+func (r *MemoryBookshelfRepository) Remove(_ context.Context, userID, storyID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.items, func(item memoryBookshelfItem) bool {
+		return item.userID == userID && item.storyID == storyID
+	})
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.items = slices.Delete(r.items, i, i+1)
+	return nil
+}
+
+func (r *MemoryBookshelfRepository) DeleteByStory(_ context.Context, storyID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.items = slices.DeleteFunc(r.items, func(item memoryBookshelfItem) bool { return item.storyID == storyID })
+	return nil
+}
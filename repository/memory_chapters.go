@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryChapterRepository lưu chương trong RAM
+type MemoryChapterRepository struct {
+	mu       sync.RWMutex
+	chapters []models.Chapter
+}
+
+func NewMemoryChapterRepository() *MemoryChapterRepository {
+	return &MemoryChapterRepository{}
+}
+
+func (r *MemoryChapterRepository) indexOf(id primitive.ObjectID) int {
+	return slices.IndexFunc(r.chapters, func(chapter models.Chapter) bool { return chapter.ID == id })
+}
+
+func (r *MemoryChapterRepository) filter(match func(chapter models.Chapter) bool) []models.Chapter {
+	chapters := []models.Chapter{}
+	for _, chapter := range r.chapters {
+		if match(chapter) {
+			chapters = append(chapters, chapter)
+		}
+	}
+	return chapters
+}
+
+func (r *MemoryChapterRepository) FindByID(_ context.Context, id primitive.ObjectID) (models.Chapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(id); i >= 0 {
+		return r.chapters[i], nil
+	}
+	return models.Chapter{}, ErrNotFound
+}
+
+func (r *MemoryChapterRepository) FindByStory(_ context.Context, storyID primitive.ObjectID) ([]models.Chapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chapters := r.filter(func(chapter models.Chapter) bool { return chapter.StoryID == storyID })
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].ChapterNumber < chapters[j].ChapterNumber })
+	return chapters, nil
+}
+
+// FindByStoryAndNumber: models.Chapter không có cờ ẩn/cấm nên mọi chương đều hiển thị
+func (r *MemoryChapterRepository) FindByStoryAndNumber(_ context.Context, storyID primitive.ObjectID, number int) (models.Chapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, chapter := range r.chapters {
+		if chapter.StoryID == storyID && chapter.ChapterNumber == number {
+			return chapter, nil
+		}
+	}
+	return models.Chapter{}, ErrNotFound
+}
+
+func (r *MemoryChapterRepository) FindAdjacent(_ context.Context, storyID primitive.ObjectID, number int) (*models.Chapter, *models.Chapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var prev, next *models.Chapter
+	for _, chapter := range r.chapters {
+		if chapter.StoryID != storyID {
+			continue
+		}
+		if chapter.ChapterNumber < number && (prev == nil || chapter.ChapterNumber > prev.ChapterNumber) {
+			prev = &chapter
+		}
+		if chapter.ChapterNumber > number && (next == nil || chapter.ChapterNumber < next.ChapterNumber) {
+			next = &chapter
+		}
+	}
+	return prev, next, nil
+}
+
+func (r *MemoryChapterRepository) Latest(_ context.Context, storyID primitive.ObjectID) (models.Chapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *models.Chapter
+	for _, chapter := range r.chapters {
+		if chapter.StoryID == storyID && (latest == nil || !chapter.CreatedAt.Before(latest.CreatedAt)) {
+			latest = &chapter
+		}
+	}
+	if latest == nil {
+		return models.Chapter{}, ErrNotFound
+	}
+	return *latest, nil
+}
+
+func (r *MemoryChapterRepository) Newest(_ context.Context, limit int64) ([]models.Chapter, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	chapters := slices.Clone(r.chapters)
+	sort.SliceStable(chapters, func(i, j int) bool { return chapters[i].CreatedAt.After(chapters[j].CreatedAt) })
+	return paginate(chapters, Page{Limit: limit}), nil
+}
+
+func (r *MemoryChapterRepository) CountByStory(_ context.Context, storyID primitive.ObjectID) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return int64(len(r.filter(func(chapter models.Chapter) bool { return chapter.StoryID == storyID }))), nil
+}
+
+func (r *MemoryChapterRepository) MaxNumber(_ context.Context, storyID primitive.ObjectID) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	highest := 0
+	for _, chapter := range r.chapters {
+		if chapter.StoryID == storyID && chapter.ChapterNumber > highest {
+			highest = chapter.ChapterNumber
+		}
+	}
+	return highest, nil
+}
+
+func (r *MemoryChapterRepository) CloseGap(_ context.Context, storyID primitive.ObjectID, number int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, chapter := range r.chapters {
+		if chapter.StoryID == storyID && chapter.ChapterNumber > number {
+			r.chapters[i].ChapterNumber--
+		}
+	}
+	return nil
+}
+
+func (r *MemoryChapterRepository) Renumber(_ context.Context, storyID primitive.ObjectID, order []primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for number, id := range order {
+		if i := r.indexOf(id); i >= 0 && r.chapters[i].StoryID == storyID {
+			r.chapters[i].ChapterNumber = number + 1
+		}
+	}
+	return nil
+}
+
+func (r *MemoryChapterRepository) Insert(_ context.Context, chapter models.Chapter) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(chapter.ID) >= 0 {
+		return ErrDuplicate
+	}
+	r.chapters = append(r.chapters, chapter)
+	return nil
+}
+
+func (r *MemoryChapterRepository) Update(_ context.Context, id primitive.ObjectID, fields map[string]any) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	chapter := r.chapters[i]
+	if err := applyFields(&chapter, fields); err != nil {
+		return err
+	}
+	r.chapters[i] = chapter
+	return nil
+}
+
+func (r *MemoryChapterRepository) IncrementViews(_ context.Context, id primitive.ObjectID) (models.Chapter, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return models.Chapter{}, ErrNotFound
+	}
+	r.chapters[i].ViewCount++
+	return r.chapters[i], nil
+}
+
+func (r *MemoryChapterRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.chapters = slices.Delete(r.chapters, i, i+1)
+	return nil
+}
+
+func (r *MemoryChapterRepository) DeleteByStory(_ context.Context, storyID primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.chapters = slices.DeleteFunc(r.chapters, func(chapter models.Chapter) bool { return chapter.StoryID == storyID })
+	return nil
+}
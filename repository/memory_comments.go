@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryCommentRepository lưu bình luận trong RAM
+type MemoryCommentRepository struct {
+	mu       sync.RWMutex
+	comments []models.Comment
+}
+
+func NewMemoryCommentRepository() *MemoryCommentRepository {
+	return &MemoryCommentRepository{}
+}
+
+func (r *MemoryCommentRepository) FindByChapter(_ context.Context, chapterID primitive.ObjectID, page Page) ([]models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	comments := []models.Comment{}
+	for _, comment := range r.comments {
+		if comment.ChapterID == chapterID {
+			comments = append(comments, comment)
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool { return comments[i].CreatedAt.Before(comments[j].CreatedAt) })
+	return paginate(comments, page), nil
+}
+
+func (r *MemoryCommentRepository) FindByID(_ context.Context, id primitive.ObjectID) (models.Comment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i := slices.IndexFunc(r.comments, func(comment models.Comment) bool { return comment.ID == id })
+	if i < 0 {
+		return models.Comment{}, ErrNotFound
+	}
+	return r.comments[i], nil
+}
+
+func (r *MemoryCommentRepository) Insert(_ context.Context, comment models.Comment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.comments = append(r.comments, comment)
+	return nil
+}
+
+func (r *MemoryCommentRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.IndexFunc(r.comments, func(comment models.Comment) bool { return comment.ID == id })
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.comments = slices.Delete(r.comments, i, i+1)
+	return nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemorySessionRepository lưu phiên đăng nhập trong RAM
+type MemorySessionRepository struct {
+	mu       sync.RWMutex
+	sessions map[primitive.ObjectID]models.Session
+}
+
+func NewMemorySessionRepository() *MemorySessionRepository {
+	return &MemorySessionRepository{sessions: make(map[primitive.ObjectID]models.Session)}
+}
+
+func (r *MemorySessionRepository) FindByID(_ context.Context, id, userID primitive.ObjectID) (models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.UserID != userID {
+		return models.Session{}, ErrNotFound
+	}
+	return session, nil
+}
+
+func (r *MemorySessionRepository) FindActive(_ context.Context, userID primitive.ObjectID, now time.Time) ([]models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	sessions := []models.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.IsActive(now) {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *MemorySessionRepository) FindByRefreshToken(_ context.Context, tokenHash string) (models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, session := range r.sessions {
+		if session.RefreshTokenHash == tokenHash {
+			return session, nil
+		}
+	}
+	return models.Session{}, ErrNotFound
+}
+
+func (r *MemorySessionRepository) Insert(_ context.Context, session models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.sessions[session.ID]; exists {
+		return ErrDuplicate
+	}
+	r.sessions[session.ID] = session
+	return nil
+}
+
+func (r *MemorySessionRepository) Touch(_ context.Context, id primitive.ObjectID, seenAt time.Time, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok {
+		return ErrNotFound
+	}
+	session.LastSeenAt = seenAt
+	session.IP = ip
+	r.sessions[id] = session
+	return nil
+}
+
+func (r *MemorySessionRepository) Rotate(_ context.Context, id primitive.ObjectID, oldHash string, next models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	session, ok := r.sessions[id]
+	if !ok || session.RefreshTokenHash != oldHash {
+		return ErrNotFound
+	}
+	session.RefreshTokenHash = next.RefreshTokenHash
+	session.PreviousTokenHash = oldHash
+	session.LastSeenAt = next.LastSeenAt
+	session.ExpiresAt = next.ExpiresAt
+	session.UserAgent = next.UserAgent
+	session.IP = next.IP
+	r.sessions[id] = session
+	return nil
+}
+
+// revoke thu hồi mọi phiên còn hiệu lực khớp match, trả về số phiên bị thu hồi
+func (r *MemorySessionRepository) revoke(match func(session models.Session) bool) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	var revoked int64
+	for id, session := range r.sessions {
+		if session.RevokedAt != nil || !match(session) {
+			continue
+		}
+		session.RevokedAt = &now
+		r.sessions[id] = session
+		revoked++
+	}
+	return revoked
+}
+
+func (r *MemorySessionRepository) Revoke(_ context.Context, id, userID primitive.ObjectID) error {
+	if r.revoke(func(session models.Session) bool { return session.ID == id && session.UserID == userID }) == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeByPreviousToken(_ context.Context, tokenHash string) error {
+	r.revoke(func(session models.Session) bool { return session.PreviousTokenHash == tokenHash })
+	return nil
+}
+
+func (r *MemorySessionRepository) RevokeAll(_ context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.revoke(func(session models.Session) bool { return session.UserID == userID }), nil
+}
+
+func (r *MemorySessionRepository) RevokeOthers(_ context.Context, userID, keepID primitive.ObjectID) (int64, error) {
+	return r.revoke(func(session models.Session) bool { return session.UserID == userID && session.ID != keepID }), nil
+}
@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryStoryRepository lưu truyện trong RAM, giữ thứ tự thêm vào như thứ tự tự nhiên của MongoDB
+type MemoryStoryRepository struct {
+	mu      sync.RWMutex
+	stories []models.Story
+}
+
+func NewMemoryStoryRepository() *MemoryStoryRepository {
+	return &MemoryStoryRepository{}
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func (f StoryFilter) matches(story models.Story) bool {
+	if f.VisibleOnly && (story.IsHidden || story.IsBanned) {
+		return false
+	}
+	if f.FeaturedOnly && !story.IsFeatured {
+		return false
+	}
+	if f.TitleContains != "" && !containsFold(story.Title, f.TitleContains) {
+		return false
+	}
+	if f.AuthorContains != "" && !containsFold(story.Author, f.AuthorContains) {
+		return false
+	}
+	if f.Genre != "" && !slices.Contains(story.Genres, f.Genre) {
+		return false
+	}
+	if f.Status != "" && story.Status != f.Status {
+		return false
+	}
+	if !f.MemberID.IsZero() && story.CreatedBy != f.MemberID &&
+		!slices.ContainsFunc(story.Collaborators, func(c models.Collaborator) bool { return c.UserID == f.MemberID }) {
+		return false
+	}
+	if !f.OwnerID.IsZero() && story.CreatedBy != f.OwnerID {
+		return false
+	}
+	return true
+}
+
+func (r *MemoryStoryRepository) indexOf(id primitive.ObjectID) int {
+	return slices.IndexFunc(r.stories, func(story models.Story) bool { return story.ID == id })
+}
+
+func (r *MemoryStoryRepository) Find(_ context.Context, filter StoryFilter, order StorySort, page Page) ([]models.Story, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stories := []models.Story{}
+	for _, story := range r.stories {
+		if filter.matches(story) {
+			stories = append(stories, story)
+		}
+	}
+
+	sort.SliceStable(stories, func(i, j int) bool {
+		a, b := stories[i], stories[j]
+		switch order {
+		case SortUpdatedDesc:
+			return a.UpdatedAt.After(b.UpdatedAt)
+		case SortViewsDesc:
+			return a.ViewCount > b.ViewCount
+		case SortChaptersDesc:
+			return a.ChaptersCount > b.ChaptersCount
+		case SortTitleAsc:
+			return a.Title < b.Title
+		}
+		return false
+	})
+	return paginate(stories, page), nil
+}
+
+func (r *MemoryStoryRepository) Count(_ context.Context, filter StoryFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, story := range r.stories {
+		if filter.matches(story) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryStoryRepository) FindByID(_ context.Context, id primitive.ObjectID) (models.Story, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if i := r.indexOf(id); i >= 0 {
+		return r.stories[i], nil
+	}
+	return models.Story{}, ErrNotFound
+}
+
+func (r *MemoryStoryRepository) FindByTitle(_ context.Context, title string) (models.Story, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, story := range r.stories {
+		if story.Title == title {
+			return story, nil
+		}
+	}
+	return models.Story{}, ErrNotFound
+}
+
+func (r *MemoryStoryRepository) Insert(_ context.Context, story models.Story) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.indexOf(story.ID) >= 0 {
+		return ErrDuplicate
+	}
+	r.stories = append(r.stories, story)
+	return nil
+}
+
+// modify chạy fn trên truyện có id, trả ErrNotFound nếu không có
+func (r *MemoryStoryRepository) modify(id primitive.ObjectID, fn func(story *models.Story) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	story := r.stories[i]
+	if err := fn(&story); err != nil {
+		return err
+	}
+	r.stories[i] = story
+	return nil
+}
+
+func (r *MemoryStoryRepository) Update(_ context.Context, id primitive.ObjectID, fields map[string]any) error {
+	return r.modify(id, func(story *models.Story) error {
+		return applyFields(story, fields)
+	})
+}
+
+func (r *MemoryStoryRepository) SetBannedByTitle(ctx context.Context, title string, banned bool) error {
+	story, err := r.FindByTitle(ctx, title)
+	if err != nil {
+		return err
+	}
+	return r.modify(story.ID, func(story *models.Story) error {
+		story.IsBanned = banned
+		story.DeletedAt = nil
+		if banned {
+			now := time.Now()
+			story.DeletedAt = &now
+		}
+		return nil
+	})
+}
+
+func (r *MemoryStoryRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := r.indexOf(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+	r.stories = slices.Delete(r.stories, i, i+1)
+	return nil
+}
+
+func (r *MemoryStoryRepository) IncrementChapterCount(_ context.Context, id primitive.ObjectID, delta int) error {
+	return r.modify(id, func(story *models.Story) error {
+		story.ChaptersCount += delta
+		if delta > 0 {
+			story.UpdatedAt = time.Now()
+		}
+		return nil
+	})
+}
+
+func (r *MemoryStoryRepository) IncrementViews(_ context.Context, id primitive.ObjectID) error {
+	return r.modify(id, func(story *models.Story) error {
+		story.ViewCount++
+		return nil
+	})
+}
+
+func (r *MemoryStoryRepository) GenreCounts(_ context.Context) ([]GenreCount, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	counts := map[string]int{}
+	for _, story := range r.stories {
+		for _, genre := range story.Genres {
+			counts[genre]++
+		}
+	}
+
+	result := []GenreCount{}
+	for genre, count := range counts {
+		result = append(result, GenreCount{Genre: genre, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Genre < result[j].Genre
+	})
+	return result, nil
+}
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryUserRepository lưu user trong RAM, username/email là duy nhất như unique index trên MongoDB
+type MemoryUserRepository struct {
+	mu    sync.RWMutex
+	users map[primitive.ObjectID]models.User
+}
+
+func NewMemoryUserRepository() *MemoryUserRepository {
+	return &MemoryUserRepository{users: make(map[primitive.ObjectID]models.User)}
+}
+
+func (f UserFilter) matches(user models.User) bool {
+	if f.Query != "" && !containsFold(user.Username, f.Query) && !containsFold(user.Email, f.Query) {
+		return false
+	}
+	if f.Role != "" && user.Role != f.Role {
+		return false
+	}
+	switch f.Status {
+	case "":
+		return true
+	case "banned":
+		return user.IsBanned(f.Now)
+	case "active":
+		return user.Status == "active" || (user.Status == "banned" && !user.IsBanned(f.Now))
+	default:
+		return user.Status == f.Status
+	}
+}
+
+func (r *MemoryUserRepository) Find(_ context.Context, filter UserFilter, page Page) ([]models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	users := []models.User{}
+	for _, user := range r.users {
+		if filter.matches(user) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].CreatedAt.After(users[j].CreatedAt) })
+	return paginate(users, page), nil
+}
+
+func (r *MemoryUserRepository) Count(_ context.Context, filter UserFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, user := range r.users {
+		if filter.matches(user) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryUserRepository) FindByID(_ context.Context, id primitive.ObjectID) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return models.User{}, ErrNotFound
+	}
+	return user, nil
+}
+
+func (r *MemoryUserRepository) FindByUsername(_ context.Context, username string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Username == username {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r *MemoryUserRepository) FindByEmail(_ context.Context, email string) (models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return models.User{}, ErrNotFound
+}
+
+func (r *MemoryUserRepository) taken(match func(user models.User) bool) bool {
+	for _, user := range r.users {
+		if match(user) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryUserRepository) UsernameTaken(_ context.Context, username string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.taken(func(user models.User) bool { return user.Username == username }), nil
+}
+
+func (r *MemoryUserRepository) EmailTaken(_ context.Context, email string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.taken(func(user models.User) bool { return user.Email == email }), nil
+}
+
+func (r *MemoryUserRepository) Insert(_ context.Context, user models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.users[user.ID]
+	if exists || r.taken(func(other models.User) bool {
+		return other.Username == user.Username || (user.Email != "" && other.Email == user.Email)
+	}) {
+		return ErrDuplicate
+	}
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) update(id primitive.ObjectID, apply func(user *models.User) bool) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[id]
+	if !ok {
+		return false, ErrNotFound
+	}
+	if !apply(&user) {
+		return false, nil
+	}
+	r.users[id] = user
+	return true, nil
+}
+
+func (r *MemoryUserRepository) SetPassword(_ context.Context, id primitive.ObjectID, hash string) error {
+	_, err := r.update(id, func(user *models.User) bool {
+		user.Password = hash
+		return true
+	})
+	return err
+}
+
+func (r *MemoryUserRepository) ConsumeTOTPStep(_ context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	return r.update(id, func(user *models.User) bool {
+		if user.TOTPLastStep >= step {
+			return false
+		}
+		user.TOTPLastStep = step
+		return true
+	})
+}
+
+func (r *MemoryUserRepository) ConsumeRecoveryCode(_ context.Context, id primitive.ObjectID, hashes []string) (bool, error) {
+	return r.update(id, func(user *models.User) bool {
+		codes := slices.DeleteFunc(slices.Clone(user.RecoveryCodes), func(code string) bool {
+			return slices.Contains(hashes, code)
+		})
+		if len(codes) == len(user.RecoveryCodes) {
+			return false
+		}
+		user.RecoveryCodes = codes
+		return true
+	})
+}
+
+// set cập nhật user, ErrNotFound nếu không có user hoặc apply trả false (không khớp điều kiện)
+func (r *MemoryUserRepository) set(id primitive.ObjectID, apply func(user *models.User) bool) error {
+	updated, err := r.update(id, apply)
+	if err == nil && !updated {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *MemoryUserRepository) SetPendingTOTPSecret(_ context.Context, id primitive.ObjectID, secret string) error {
+	return r.set(id, func(user *models.User) bool {
+		user.TOTPPendingSecret = secret
+		return true
+	})
+}
+
+func (r *MemoryUserRepository) EnableTOTP(_ context.Context, id primitive.ObjectID, secret string, step int64, recoveryHashes []string) error {
+	return r.set(id, func(user *models.User) bool {
+		user.TOTPEnabled = true
+		user.TOTPSecret = secret
+		user.TOTPLastStep = step
+		user.RecoveryCodes = slices.Clone(recoveryHashes)
+		user.TOTPPendingSecret = ""
+		return true
+	})
+}
+
+func (r *MemoryUserRepository) DisableTOTP(_ context.Context, id primitive.ObjectID) error {
+	return r.set(id, func(user *models.User) bool {
+		user.TOTPEnabled = false
+		user.TOTPSecret = ""
+		user.TOTPLastStep = 0
+		user.RecoveryCodes = nil
+		user.TOTPPendingSecret = ""
+		return true
+	})
+}
+
+func (r *MemoryUserRepository) UpdateProfile(_ context.Context, profile models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, ok := r.users[profile.ID]
+	if !ok {
+		return ErrNotFound
+	}
+	if profile.Email != "" && r.taken(func(other models.User) bool {
+		return other.ID != profile.ID && other.Email == profile.Email
+	}) {
+		return ErrDuplicate
+	}
+	user.DisplayName = profile.DisplayName
+	user.Bio = profile.Bio
+	user.AvatarURL = profile.AvatarURL
+	user.Email = profile.Email
+	user.EmailVerified = profile.EmailVerified
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *MemoryUserRepository) MarkEmailVerified(_ context.Context, id primitive.ObjectID, email string) error {
+	return r.set(id, func(user *models.User) bool {
+		if user.Email != email {
+			return false
+		}
+		user.EmailVerified = true
+		return true
+	})
+}
+
+func (r *MemoryUserRepository) Ban(_ context.Context, id primitive.ObjectID, reason string, until *time.Time) error {
+	return r.set(id, func(user *models.User) bool {
+		user.Status = "banned"
+		user.BanReason = reason
+		user.BannedUntil = until
+		return true
+	})
+}
+
+func (r *MemoryUserRepository) Unban(_ context.Context, id primitive.ObjectID) error {
+	return r.set(id, func(user *models.User) bool {
+		if user.Status != "banned" {
+			return false
+		}
+		user.Status = "active"
+		user.BanReason = ""
+		user.BannedUntil = nil
+		return true
+	})
+}
+
+func (r *MemoryUserRepository) SetRole(_ context.Context, id primitive.ObjectID, role string) (string, error) {
+	var previous string
+	err := r.set(id, func(user *models.User) bool {
+		previous = user.Role
+		user.Role = role
+		return true
+	})
+	return previous, err
+}
+
+func (r *MemoryUserRepository) ReplaceRole(_ context.Context, id primitive.ObjectID, from, to string) (bool, error) {
+	return r.update(id, func(user *models.User) bool {
+		if user.Role != from || from == to {
+			return false
+		}
+		user.Role = to
+		return true
+	})
+}
+
+// Delete chỉ xoá user: dữ liệu liên quan ở các repository in-memory khác không được dọn
+func (r *MemoryUserRepository) Delete(_ context.Context, id primitive.ObjectID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.users[id]; !ok {
+		return ErrNotFound
+	}
+	delete(r.users, id)
+	return nil
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+type MongoBookshelfRepository struct {
+	bookshelf *mongo.Collection
+}
+
+func NewMongoBookshelfRepository(db *mongo.Database) *MongoBookshelfRepository {
+	return &MongoBookshelfRepository{bookshelf: db.Collection("Bookshelf")}
+}
+
+// bookshelfDefaultUpdatedAt: giá trị thay cho updated_at bị thiếu ở dữ liệu cũ
+var bookshelfDefaultUpdatedAt = time.Date(2025, 4, 22, 0, 0, 0, 0, time.UTC)
+
+func (r *MongoBookshelfRepository) List(ctx context.Context, userID primitive.ObjectID, sortBy string, order int, page Page) ([]BookshelfEntry, error) {
+	pipeline := mongo.Pipeline{
+		// Lọc theo user_id
+		{{Key: "$match", Value: bson.D{{Key: "user_id", Value: userID}}}},
+
+		// Join với collection "stories"
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "Stories"},
+			{Key: "localField", Value: "story_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "story"},
+		}}},
+
+		{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$story"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+
+		// Join với collection "chapters"
+		{{Key: "$lookup", Value: bson.D{
+			{Key: "from", Value: "Chapters"},
+			{Key: "localField", Value: "last_chapter_id"},
+			{Key: "foreignField", Value: "_id"},
+			{Key: "as", Value: "chapter"},
+		}}},
+
+		{{Key: "$unwind", Value: bson.D{{Key: "path", Value: "$chapter"}, {Key: "preserveNullAndEmptyArrays", Value: true}}}},
+
+		{{Key: "$project", Value: bson.D{
+			{Key: "_id", Value: 0},
+			{Key: "story_id", Value: "$story._id"},
+			{Key: "story_title", Value: "$story.title"},
+			{Key: "last_chapter_id", Value: "$chapter._id"},
+			{Key: "chapter_number", Value: "$chapter.chapter_number"},
+			{Key: "chapter_title", Value: "$chapter.title"},
+			{Key: "added_at", Value: 1},
+			{Key: "updated_at", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$updated_at", bookshelfDefaultUpdatedAt}}}},
+		}}},
+
+		{{Key: "$sort", Value: bson.D{{Key: sortBy, Value: order}}}},
+		{{Key: "$skip", Value: page.Skip}},
+	}
+	if page.Limit > 0 {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: page.Limit}})
+	}
+
+	cursor, err := r.bookshelf.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	entries := []BookshelfEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *MongoBookshelfRepository) SaveProgress(ctx context.Context, userID, storyID, chapterID primitive.ObjectID) (bool, error) {
+	filter := bson.M{"user_id": userID, "story_id": storyID}
+
+	err := r.bookshelf.FindOne(ctx, filter).Err()
+	if err == mongo.ErrNoDocuments {
+		_, err = r.bookshelf.InsertOne(ctx, bson.M{
+			"user_id":         userID,
+			"story_id":        storyID,
+			"chapter_id":      chapterID,
+			"added_at":        time.Now(),
+			"updated_at":      time.Now(),
+			"last_chapter_id": chapterID,
+		})
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	_, err = r.bookshelf.UpdateOne(ctx, filter, bson.M{
+		"$set": bson.M{
+			"chapter_id":      chapterID,
+			"last_chapter_id": chapterID,
+			"updated_at":      time.Now(),
+		},
+	})
+	return false, err
+}
+
+func (r *MongoBookshelfRepository) Remove(ctx context.Context, userID, storyID primitive.ObjectID) error {
+	result, err := r.bookshelf.DeleteOne(ctx, bson.M{"user_id": userID, "story_id": storyID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoBookshelfRepository) DeleteByStory(ctx context.Context, storyID primitive.ObjectID) error {
+	_, err := r.bookshelf.DeleteMany(ctx, bson.M{"story_id": storyID})
+	return err
+}
@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoChapterRepository struct {
+	chapters *mongo.Collection
+}
+
+func NewMongoChapterRepository(db *mongo.Database) *MongoChapterRepository {
+	return &MongoChapterRepository{chapters: db.Collection("Chapters")}
+}
+
+func (r *MongoChapterRepository) findOne(ctx context.Context, filter bson.M, opts ...*options.FindOneOptions) (models.Chapter, error) {
+	var chapter models.Chapter
+	err := r.chapters.FindOne(ctx, filter, opts...).Decode(&chapter)
+	if err == mongo.ErrNoDocuments {
+		return chapter, ErrNotFound
+	}
+	return chapter, err
+}
+
+func (r *MongoChapterRepository) find(ctx context.Context, filter bson.M, opts ...*options.FindOptions) ([]models.Chapter, error) {
+	cursor, err := r.chapters.Find(ctx, filter, opts...)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	chapters := []models.Chapter{}
+	if err := cursor.All(ctx, &chapters); err != nil {
+		return nil, err
+	}
+	return chapters, nil
+}
+
+func (r *MongoChapterRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Chapter, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoChapterRepository) FindByStory(ctx context.Context, storyID primitive.ObjectID) ([]models.Chapter, error) {
+	return r.find(ctx, bson.M{"story_id": storyID}, options.Find().SetSort(bson.D{{Key: "chapter_number", Value: 1}}))
+}
+
+func (r *MongoChapterRepository) FindByStoryAndNumber(ctx context.Context, storyID primitive.ObjectID, number int) (models.Chapter, error) {
+	// $ne thay vì false: chương chưa từng bị ẩn/cấm không có hai trường này
+	return r.findOne(ctx, bson.M{
+		"story_id":       storyID,
+		"chapter_number": number,
+		"is_Banned":      bson.M{"$ne": true},
+		"is_hidden":      bson.M{"$ne": true},
+	})
+}
+
+func (r *MongoChapterRepository) FindAdjacent(ctx context.Context, storyID primitive.ObjectID, number int) (*models.Chapter, *models.Chapter, error) {
+	var prev, next *models.Chapter
+
+	chapter, err := r.findOne(ctx,
+		bson.M{"story_id": storyID, "chapter_number": bson.M{"$lt": number}},
+		options.FindOne().SetSort(bson.D{{Key: "chapter_number", Value: -1}}),
+	)
+	if err == nil {
+		prev = &chapter
+	} else if err != ErrNotFound {
+		return nil, nil, err
+	}
+
+	chapter, err = r.findOne(ctx,
+		bson.M{"story_id": storyID, "chapter_number": bson.M{"$gt": number}},
+		options.FindOne().SetSort(bson.D{{Key: "chapter_number", Value: 1}}),
+	)
+	if err == nil {
+		next = &chapter
+	} else if err != ErrNotFound {
+		return nil, nil, err
+	}
+
+	return prev, next, nil
+}
+
+func (r *MongoChapterRepository) Latest(ctx context.Context, storyID primitive.ObjectID) (models.Chapter, error) {
+	return r.findOne(ctx,
+		bson.M{"story_id": storyID},
+		options.FindOne().SetSort(bson.D{{Key: "created_at", Value: -1}}),
+	)
+}
+
+func (r *MongoChapterRepository) Newest(ctx context.Context, limit int64) ([]models.Chapter, error) {
+	return r.find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(limit))
+}
+
+func (r *MongoChapterRepository) CountByStory(ctx context.Context, storyID primitive.ObjectID) (int64, error) {
+	return r.chapters.CountDocuments(ctx, bson.M{"story_id": storyID})
+}
+
+func (r *MongoChapterRepository) MaxNumber(ctx context.Context, storyID primitive.ObjectID) (int, error) {
+	last, err := r.findOne(ctx,
+		bson.M{"story_id": storyID},
+		options.FindOne().SetSort(bson.D{{Key: "chapter_number", Value: -1}}),
+	)
+	if err == ErrNotFound {
+		return 0, nil
+	}
+	return last.ChapterNumber, err
+}
+
+func (r *MongoChapterRepository) CloseGap(ctx context.Context, storyID primitive.ObjectID, number int) error {
+	_, err := r.chapters.UpdateMany(ctx,
+		bson.M{"story_id": storyID, "chapter_number": bson.M{"$gt": number}},
+		bson.M{"$inc": bson.M{"chapter_number": -1}},
+	)
+	return err
+}
+
+func (r *MongoChapterRepository) Renumber(ctx context.Context, storyID primitive.ObjectID, order []primitive.ObjectID) error {
+	if len(order) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(order))
+	for i, id := range order {
+		writes = append(writes, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "story_id": storyID}).
+			SetUpdate(bson.M{"$set": bson.M{"chapter_number": i + 1}}))
+	}
+	_, err := r.chapters.BulkWrite(ctx, writes)
+	return err
+}
+
+func (r *MongoChapterRepository) Insert(ctx context.Context, chapter models.Chapter) error {
+	_, err := r.chapters.InsertOne(ctx, chapter)
+	return err
+}
+
+func (r *MongoChapterRepository) Update(ctx context.Context, id primitive.ObjectID, fields map[string]any) error {
+	result, err := r.chapters.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoChapterRepository) IncrementViews(ctx context.Context, id primitive.ObjectID) (models.Chapter, error) {
+	var chapter models.Chapter
+	err := r.chapters.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$inc": bson.M{"view_count": 1}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&chapter)
+	if err == mongo.ErrNoDocuments {
+		return chapter, ErrNotFound
+	}
+	return chapter, err
+}
+
+func (r *MongoChapterRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.chapters.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoChapterRepository) DeleteByStory(ctx context.Context, storyID primitive.ObjectID) error {
+	_, err := r.chapters.DeleteMany(ctx, bson.M{"story_id": storyID})
+	return err
+}
@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoCommentRepository struct {
+	comments *mongo.Collection
+}
+
+func NewMongoCommentRepository(db *mongo.Database) *MongoCommentRepository {
+	return &MongoCommentRepository{comments: db.Collection("Comments")}
+}
+
+func (r *MongoCommentRepository) FindByChapter(ctx context.Context, chapterID primitive.ObjectID, page Page) ([]models.Comment, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetSkip(page.Skip).
+		SetLimit(page.Limit)
+
+	cursor, err := r.comments.Find(ctx, bson.M{"chapter_id": chapterID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	comments := []models.Comment{}
+	if err := cursor.All(ctx, &comments); err != nil {
+		return nil, err
+	}
+	return comments, nil
+}
+
+func (r *MongoCommentRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Comment, error) {
+	var comment models.Comment
+	err := r.comments.FindOne(ctx, bson.M{"_id": id}).Decode(&comment)
+	if err == mongo.ErrNoDocuments {
+		return comment, ErrNotFound
+	}
+	return comment, err
+}
+
+func (r *MongoCommentRepository) Insert(ctx context.Context, comment models.Comment) error {
+	_, err := r.comments.InsertOne(ctx, comment)
+	return err
+}
+
+func (r *MongoCommentRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	result, err := r.comments.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
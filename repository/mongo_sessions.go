@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoSessionRepository struct {
+	sessions *mongo.Collection
+}
+
+func NewMongoSessionRepository(db *mongo.Database) *MongoSessionRepository {
+	return &MongoSessionRepository{sessions: db.Collection("Sessions")}
+}
+
+func (r *MongoSessionRepository) FindByID(ctx context.Context, id, userID primitive.ObjectID) (models.Session, error) {
+	var session models.Session
+	err := r.sessions.FindOne(ctx, bson.M{"_id": id, "user_id": userID}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return session, ErrNotFound
+	}
+	return session, err
+}
+
+func (r *MongoSessionRepository) FindActive(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.Session, error) {
+	cursor, err := r.sessions.Find(ctx,
+		bson.M{"user_id": userID, "revoked_at": nil, "expires_at": bson.M{"$gt": now}},
+		options.Find().SetSort(bson.D{{Key: "last_seen_at", Value: -1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	sessions := []models.Session{}
+	if err := cursor.All(ctx, &sessions); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *MongoSessionRepository) FindByRefreshToken(ctx context.Context, tokenHash string) (models.Session, error) {
+	var session models.Session
+	err := r.sessions.FindOne(ctx, bson.M{"refresh_token_hash": tokenHash}).Decode(&session)
+	if err == mongo.ErrNoDocuments {
+		return session, ErrNotFound
+	}
+	return session, err
+}
+
+func (r *MongoSessionRepository) Insert(ctx context.Context, session models.Session) error {
+	_, err := r.sessions.InsertOne(ctx, session)
+	return err
+}
+
+func (r *MongoSessionRepository) Touch(ctx context.Context, id primitive.ObjectID, seenAt time.Time, ip string) error {
+	_, err := r.sessions.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{"last_seen_at": seenAt, "ip": ip}},
+	)
+	return err
+}
+
+func (r *MongoSessionRepository) Rotate(ctx context.Context, id primitive.ObjectID, oldHash string, next models.Session) error {
+	result, err := r.sessions.UpdateOne(ctx,
+		bson.M{"_id": id, "refresh_token_hash": oldHash},
+		bson.M{"$set": bson.M{
+			"refresh_token_hash":  next.RefreshTokenHash,
+			"previous_token_hash": oldHash,
+			"last_seen_at":        next.LastSeenAt,
+			"expires_at":          next.ExpiresAt,
+			"user_agent":          next.UserAgent,
+			"ip":                  next.IP,
+		}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// revoke thu hồi mọi phiên còn hiệu lực khớp filter, trả về số phiên bị thu hồi
+func (r *MongoSessionRepository) revoke(ctx context.Context, filter bson.M) (int64, error) {
+	filter["revoked_at"] = nil
+	result, err := r.sessions.UpdateMany(ctx, filter, bson.M{"$set": bson.M{"revoked_at": time.Now()}})
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+func (r *MongoSessionRepository) Revoke(ctx context.Context, id, userID primitive.ObjectID) error {
+	revoked, err := r.revoke(ctx, bson.M{"_id": id, "user_id": userID})
+	if err == nil && revoked == 0 {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (r *MongoSessionRepository) RevokeByPreviousToken(ctx context.Context, tokenHash string) error {
+	_, err := r.revoke(ctx, bson.M{"previous_token_hash": tokenHash})
+	return err
+}
+
+func (r *MongoSessionRepository) RevokeAll(ctx context.Context, userID primitive.ObjectID) (int64, error) {
+	return r.revoke(ctx, bson.M{"user_id": userID})
+}
+
+func (r *MongoSessionRepository) RevokeOthers(ctx context.Context, userID, keepID primitive.ObjectID) (int64, error) {
+	return r.revoke(ctx, bson.M{"user_id": userID, "_id": bson.M{"$ne": keepID}})
+}
@@ -0,0 +1,196 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoStoryRepository struct {
+	stories     *mongo.Collection
+	invitations *mongo.Collection
+	transfers   *mongo.Collection
+}
+
+func NewMongoStoryRepository(db *mongo.Database) *MongoStoryRepository {
+	return &MongoStoryRepository{
+		stories:     db.Collection("Stories"),
+		invitations: db.Collection("StoryInvitations"),
+		transfers:   db.Collection("StoryTransfers"),
+	}
+}
+
+func storyFilterQuery(filter StoryFilter) bson.M {
+	query := bson.M{}
+	if filter.VisibleOnly {
+		query["is_hidden"] = false
+		query["is_banned"] = false
+	}
+	if filter.FeaturedOnly {
+		query["is_featured"] = true
+	}
+	// Từ khoá là chuỗi thường, không phải regex do người dùng tự viết
+	if filter.TitleContains != "" {
+		query["title"] = bson.M{"$regex": regexp.QuoteMeta(filter.TitleContains), "$options": "i"}
+	}
+	if filter.AuthorContains != "" {
+		query["author"] = bson.M{"$regex": regexp.QuoteMeta(filter.AuthorContains), "$options": "i"}
+	}
+	if filter.Genre != "" {
+		query["genres"] = filter.Genre
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if !filter.MemberID.IsZero() {
+		query["$or"] = bson.A{
+			bson.M{"created_by": filter.MemberID},
+			bson.M{"collaborators.user_id": filter.MemberID},
+		}
+	}
+	if !filter.OwnerID.IsZero() {
+		query["created_by"] = filter.OwnerID
+	}
+	return query
+}
+
+func storySortDoc(sort StorySort) bson.D {
+	switch sort {
+	case SortUpdatedDesc:
+		return bson.D{{Key: "updated_at", Value: -1}}
+	case SortViewsDesc:
+		return bson.D{{Key: "view_count", Value: -1}}
+	case SortChaptersDesc:
+		return bson.D{{Key: "chapters_count", Value: -1}}
+	case SortTitleAsc:
+		return bson.D{{Key: "title", Value: 1}}
+	}
+	return nil
+}
+
+func (r *MongoStoryRepository) Find(ctx context.Context, filter StoryFilter, sort StorySort, page Page) ([]models.Story, error) {
+	opts := options.Find().SetSkip(page.Skip).SetLimit(page.Limit)
+	if doc := storySortDoc(sort); doc != nil {
+		opts.SetSort(doc)
+	}
+
+	cursor, err := r.stories.Find(ctx, storyFilterQuery(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	stories := []models.Story{}
+	if err := cursor.All(ctx, &stories); err != nil {
+		return nil, err
+	}
+	return stories, nil
+}
+
+func (r *MongoStoryRepository) Count(ctx context.Context, filter StoryFilter) (int64, error) {
+	return r.stories.CountDocuments(ctx, storyFilterQuery(filter))
+}
+
+func (r *MongoStoryRepository) findOne(ctx context.Context, filter bson.M) (models.Story, error) {
+	var story models.Story
+	err := r.stories.FindOne(ctx, filter).Decode(&story)
+	if err == mongo.ErrNoDocuments {
+		return story, ErrNotFound
+	}
+	return story, err
+}
+
+func (r *MongoStoryRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.Story, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoStoryRepository) FindByTitle(ctx context.Context, title string) (models.Story, error) {
+	return r.findOne(ctx, bson.M{"title": title})
+}
+
+func (r *MongoStoryRepository) Insert(ctx context.Context, story models.Story) error {
+	_, err := r.stories.InsertOne(ctx, story)
+	return err
+}
+
+func (r *MongoStoryRepository) updateOne(ctx context.Context, filter, update bson.M) error {
+	result, err := r.stories.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoStoryRepository) Update(ctx context.Context, id primitive.ObjectID, fields map[string]any) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": fields})
+}
+
+func (r *MongoStoryRepository) SetBannedByTitle(ctx context.Context, title string, banned bool) error {
+	var deletedAt *time.Time
+	if banned {
+		now := time.Now()
+		deletedAt = &now
+	}
+	return r.updateOne(ctx, bson.M{"title": title}, bson.M{"$set": bson.M{
+		"is_banned":  banned,
+		"deleted_at": deletedAt,
+	}})
+}
+
+func (r *MongoStoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	_, _ = r.invitations.DeleteMany(ctx, bson.M{"story_id": id})
+	_, _ = r.transfers.DeleteMany(ctx, bson.M{"story_id": id})
+
+	result, err := r.stories.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoStoryRepository) IncrementChapterCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	update := bson.M{"$inc": bson.M{"chapters_count": delta}}
+	if delta > 0 {
+		update["$set"] = bson.M{"updated_at": time.Now()}
+	}
+	return r.updateOne(ctx, bson.M{"_id": id}, update)
+}
+
+func (r *MongoStoryRepository) IncrementViews(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"view_count": 1}})
+}
+
+func (r *MongoStoryRepository) GenreCounts(ctx context.Context) ([]GenreCount, error) {
+	pipeline := []bson.M{
+		{"$unwind": "$genres"},
+		{"$group": bson.M{
+			"_id":   "$genres",
+			"count": bson.M{"$sum": 1},
+		}},
+		{"$sort": bson.M{"count": -1}},
+	}
+
+	cursor, err := r.stories.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := []GenreCount{}
+	if err := cursor.All(ctx, &counts); err != nil {
+		return nil, err
+	}
+	return counts, nil
+}
@@ -0,0 +1,300 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type MongoUserRepository struct {
+	db    *mongo.Database
+	users *mongo.Collection
+}
+
+func NewMongoUserRepository(db *mongo.Database) *MongoUserRepository {
+	return &MongoUserRepository{db: db, users: db.Collection("Users")}
+}
+
+func userFilterQuery(filter UserFilter) bson.M {
+	query := bson.M{}
+	and := bson.A{}
+	// Từ khoá là chuỗi thường, không phải regex do người dùng tự viết
+	if filter.Query != "" {
+		pattern := regexp.QuoteMeta(filter.Query)
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"username": bson.M{"$regex": pattern, "$options": "i"}},
+			bson.M{"email": bson.M{"$regex": pattern, "$options": "i"}},
+		}})
+	}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	switch filter.Status {
+	case "":
+	case "banned":
+		query["status"] = "banned"
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"banned_until": nil},
+			bson.M{"banned_until": bson.M{"$gt": filter.Now}},
+		}})
+	case "active":
+		and = append(and, bson.M{"$or": bson.A{
+			bson.M{"status": "active"},
+			bson.M{"status": "banned", "banned_until": bson.M{"$lte": filter.Now}},
+		}})
+	default:
+		query["status"] = filter.Status
+	}
+	if len(and) > 0 {
+		query["$and"] = and
+	}
+	return query
+}
+
+func (r *MongoUserRepository) Find(ctx context.Context, filter UserFilter, page Page) ([]models.User, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetSkip(page.Skip).
+		SetLimit(page.Limit)
+	cursor, err := r.users.Find(ctx, userFilterQuery(filter), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+func (r *MongoUserRepository) Count(ctx context.Context, filter UserFilter) (int64, error) {
+	return r.users.CountDocuments(ctx, userFilterQuery(filter))
+}
+
+func (r *MongoUserRepository) findOne(ctx context.Context, filter bson.M) (models.User, error) {
+	var user models.User
+	err := r.users.FindOne(ctx, filter).Decode(&user)
+	if err == mongo.ErrNoDocuments {
+		return user, ErrNotFound
+	}
+	return user, err
+}
+
+func (r *MongoUserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (models.User, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+func (r *MongoUserRepository) FindByUsername(ctx context.Context, username string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"username": username})
+}
+
+func (r *MongoUserRepository) FindByEmail(ctx context.Context, email string) (models.User, error) {
+	return r.findOne(ctx, bson.M{"email": email})
+}
+
+func (r *MongoUserRepository) UsernameTaken(ctx context.Context, username string) (bool, error) {
+	count, err := r.users.CountDocuments(ctx, bson.M{"username": username})
+	return count > 0, err
+}
+
+func (r *MongoUserRepository) EmailTaken(ctx context.Context, email string) (bool, error) {
+	count, err := r.users.CountDocuments(ctx, bson.M{"email": email})
+	return count > 0, err
+}
+
+func (r *MongoUserRepository) Insert(ctx context.Context, user models.User) error {
+	_, err := r.users.InsertOne(ctx, user)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *MongoUserRepository) SetPassword(ctx context.Context, id primitive.ObjectID, hash string) error {
+	result, err := r.users.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"password": hash}})
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) ConsumeTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error) {
+	// Lọc theo last_step cũ: hai request cùng mã thì chỉ một request thắng
+	result, err := r.users.UpdateOne(ctx,
+		bson.M{"_id": id, "$or": bson.A{
+			bson.M{"totp_last_step": bson.M{"$lt": step}},
+			bson.M{"totp_last_step": bson.M{"$exists": false}},
+		}},
+		bson.M{"$set": bson.M{"totp_last_step": step}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+func (r *MongoUserRepository) ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hashes []string) (bool, error) {
+	result, err := r.users.UpdateOne(ctx,
+		bson.M{"_id": id, "recovery_codes": bson.M{"$in": hashes}},
+		bson.M{"$pull": bson.M{"recovery_codes": bson.M{"$in": hashes}}},
+	)
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount == 1, nil
+}
+
+// updateOne cập nhật user khớp filter, ErrNotFound nếu không có user nào khớp
+func (r *MongoUserRepository) updateOne(ctx context.Context, filter, update bson.M) error {
+	result, err := r.users.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (r *MongoUserRepository) SetPendingTOTPSecret(ctx context.Context, id primitive.ObjectID, secret string) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"totp_pending_secret": secret}})
+}
+
+func (r *MongoUserRepository) EnableTOTP(ctx context.Context, id primitive.ObjectID, secret string, step int64, recoveryHashes []string) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set": bson.M{
+			"totp_enabled":   true,
+			"totp_secret":    secret,
+			"totp_last_step": step,
+			"recovery_codes": recoveryHashes,
+		},
+		"$unset": bson.M{"totp_pending_secret": ""},
+	})
+}
+
+func (r *MongoUserRepository) DisableTOTP(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateOne(ctx, bson.M{"_id": id}, bson.M{
+		"$set":   bson.M{"totp_enabled": false},
+		"$unset": bson.M{"totp_secret": "", "totp_last_step": "", "recovery_codes": "", "totp_pending_secret": ""},
+	})
+}
+
+func (r *MongoUserRepository) UpdateProfile(ctx context.Context, user models.User) error {
+	set := bson.M{"email_verified": user.EmailVerified}
+	unset := bson.M{}
+	for key, value := range map[string]string{
+		"display_name": user.DisplayName,
+		"bio":          user.Bio,
+		"avatar_url":   user.AvatarURL,
+		"email":        user.Email,
+	} {
+		if value == "" {
+			unset[key] = ""
+		} else {
+			set[key] = value
+		}
+	}
+	update := bson.M{"$set": set}
+	if len(unset) > 0 {
+		update["$unset"] = unset
+	}
+	err := r.updateOne(ctx, bson.M{"_id": user.ID}, update)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDuplicate
+	}
+	return err
+}
+
+func (r *MongoUserRepository) MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string) error {
+	return r.updateOne(ctx, bson.M{"_id": id, "email": email}, bson.M{"$set": bson.M{"email_verified": true}})
+}
+
+func (r *MongoUserRepository) Ban(ctx context.Context, id primitive.ObjectID, reason string, until *time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"status": "banned", "ban_reason": reason},
+	}
+	if until != nil {
+		update["$set"].(bson.M)["banned_until"] = *until
+	} else {
+		update["$unset"] = bson.M{"banned_until": ""}
+	}
+	return r.updateOne(ctx, bson.M{"_id": id}, update)
+}
+
+func (r *MongoUserRepository) Unban(ctx context.Context, id primitive.ObjectID) error {
+	return r.updateOne(ctx, bson.M{"_id": id, "status": "banned"}, bson.M{
+		"$set":   bson.M{"status": "active"},
+		"$unset": bson.M{"ban_reason": "", "banned_until": ""},
+	})
+}
+
+func (r *MongoUserRepository) SetRole(ctx context.Context, id primitive.ObjectID, role string) (string, error) {
+	var before models.User
+	err := r.users.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"role": role}}).Decode(&before)
+	if err == mongo.ErrNoDocuments {
+		return "", ErrNotFound
+	}
+	return before.Role, err
+}
+
+func (r *MongoUserRepository) ReplaceRole(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error) {
+	result, err := r.users.UpdateOne(ctx, bson.M{"_id": id, "role": from}, bson.M{"$set": bson.M{"role": to}})
+	if err != nil {
+		return false, err
+	}
+	return result.ModifiedCount > 0, nil
+}
+
+func (r *MongoUserRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	// 1. Ẩn danh bình luận (giữ nội dung để không vỡ mạch thảo luận)
+	if _, err := r.db.Collection("Comments").UpdateMany(ctx,
+		bson.M{"user_id": id},
+		bson.M{"$set": bson.M{"user_id": primitive.NilObjectID, "updated_at": time.Now()}},
+	); err != nil {
+		return err
+	}
+
+	// 2. Xoá tủ sách
+	if _, err := r.db.Collection("Bookshelf").DeleteMany(ctx, bson.M{"user_id": id}); err != nil {
+		return err
+	}
+
+	// 3. Xoá dữ liệu đăng nhập
+	for _, collection := range []string{"Sessions", "PasswordResets", "EmailVerifications", "APIKeys", "UserIdentities"} {
+		_, _ = r.db.Collection(collection).DeleteMany(ctx, bson.M{"user_id": id})
+	}
+	_, _ = r.db.Collection("Follows").DeleteMany(ctx, bson.M{"$or": bson.A{
+		bson.M{"follower_id": id},
+		bson.M{"followee_id": id},
+	}})
+	_, _ = r.db.Collection("StoryInvitations").DeleteMany(ctx, bson.M{"$or": bson.A{
+		bson.M{"inviter_id": id},
+		bson.M{"invitee_id": id},
+	}})
+	_, _ = r.db.Collection("StoryTransfers").DeleteMany(ctx, bson.M{"to_user_id": id, "status": "pending"})
+	_, _ = r.db.Collection("Stories").UpdateMany(ctx,
+		bson.M{"collaborators.user_id": id},
+		bson.M{"$pull": bson.M{"collaborators": bson.M{"user_id": id}}},
+	)
+
+	// 4. Xoá tài khoản
+	result, err := r.users.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"Truyen_BE/models"
+	"context"
+	"errors"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var (
+	ErrNotFound  = errors.New("repository: không tìm thấy")
+	ErrDuplicate = errors.New("repository: dữ liệu đã tồn tại")
+)
+
+// Page: phân trang, Limit = 0 là không giới hạn
+type Page struct {
+	Skip  int64
+	Limit int64
+}
+
+// StorySort: thứ tự sắp xếp truyện, rỗng = thứ tự lưu trữ
+type StorySort string
+
+const (
+	SortUpdatedDesc  StorySort = "updated_desc"
+	SortViewsDesc    StorySort = "views_desc"
+	SortChaptersDesc StorySort = "chapters_desc"
+	SortTitleAsc     StorySort = "title_asc"
+)
+
+// StoryFilter: điều kiện lọc truyện, trường rỗng = bỏ qua
+type StoryFilter struct {
+	VisibleOnly    bool   // bỏ truyện bị ẩn hoặc bị ban
+	FeaturedOnly   bool   // chỉ truyện đề cử
+	TitleContains  string // không phân biệt hoa thường
+	AuthorContains string // không phân biệt hoa thường
+	Genre          string
+	Status         string
+	MemberID       primitive.ObjectID // chủ sở hữu hoặc cộng tác viên
+	OwnerID        primitive.ObjectID // chỉ chủ sở hữu
+}
+
+// GenreCount: số truyện của một thể loại
+type GenreCount struct {
+	Genre string `bson:"_id" json:"_id"`
+	Count int    `bson:"count" json:"count"`
+}
+
+type StoryRepository interface {
+	Find(ctx context.Context, filter StoryFilter, sort StorySort, page Page) ([]models.Story, error)
+	Count(ctx context.Context, filter StoryFilter) (int64, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Story, error)
+	FindByTitle(ctx context.Context, title string) (models.Story, error)
+	Insert(ctx context.Context, story models.Story) error
+	// Update ghi đè các trường theo tên bson
+	Update(ctx context.Context, id primitive.ObjectID, fields map[string]any) error
+	SetBannedByTitle(ctx context.Context, title string, banned bool) error
+	// Delete xoá truyện kèm lời mời cộng tác và yêu cầu chuyển quyền của truyện
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	// IncrementChapterCount cộng delta vào chapters_count; thêm chương (delta > 0) thì cập nhật cả updated_at
+	IncrementChapterCount(ctx context.Context, id primitive.ObjectID, delta int) error
+	IncrementViews(ctx context.Context, id primitive.ObjectID) error
+	GenreCounts(ctx context.Context) ([]GenreCount, error)
+}
+
+type ChapterRepository interface {
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Chapter, error)
+	// FindByStory sắp theo số chương
+	FindByStory(ctx context.Context, storyID primitive.ObjectID) ([]models.Chapter, error)
+	// FindByStoryAndNumber bỏ qua chương bị ẩn hoặc bị cấm
+	FindByStoryAndNumber(ctx context.Context, storyID primitive.ObjectID, number int) (models.Chapter, error)
+	// FindAdjacent trả về chương liền trước và liền sau (nil nếu không có)
+	FindAdjacent(ctx context.Context, storyID primitive.ObjectID, number int) (prev, next *models.Chapter, err error)
+	Latest(ctx context.Context, storyID primitive.ObjectID) (models.Chapter, error)
+	Newest(ctx context.Context, limit int64) ([]models.Chapter, error)
+	CountByStory(ctx context.Context, storyID primitive.ObjectID) (int64, error)
+	// MaxNumber trả số chương lớn nhất của truyện, 0 nếu truyện chưa có chương
+	MaxNumber(ctx context.Context, storyID primitive.ObjectID) (int, error)
+	// CloseGap lùi số của các chương sau number một đơn vị (dùng sau khi xoá chương number)
+	CloseGap(ctx context.Context, storyID primitive.ObjectID, number int) error
+	// Renumber đánh số lại 1..n theo thứ tự order; order phải gồm đúng các chương của truyện
+	Renumber(ctx context.Context, storyID primitive.ObjectID, order []primitive.ObjectID) error
+	Insert(ctx context.Context, chapter models.Chapter) error
+	Update(ctx context.Context, id primitive.ObjectID, fields map[string]any) error
+	// IncrementViews tăng view_count và trả về chương sau khi cập nhật
+	IncrementViews(ctx context.Context, id primitive.ObjectID) (models.Chapter, error)
+	Delete(ctx context.Context, id primitive.ObjectID) error
+	DeleteByStory(ctx context.Context, storyID primitive.ObjectID) error
+}
+
+// UserFilter: điều kiện lọc user cho trang quản trị, trường rỗng = bỏ qua
+type UserFilter struct {
+	Query string // username hoặc email chứa chuỗi này, không phân biệt hoa thường
+	Role  string
+	// Status theo hiệu lực tại Now: "banned" chỉ gồm lệnh khoá còn hạn,
+	// khoá tạm đã hết hạn (status vẫn "banned" trong DB) được tính là "active"
+	Status string
+	Now    time.Time
+}
+
+type UserRepository interface {
+	// Find sắp theo thời gian tạo, mới trước
+	Find(ctx context.Context, filter UserFilter, page Page) ([]models.User, error)
+	Count(ctx context.Context, filter UserFilter) (int64, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.User, error)
+	FindByUsername(ctx context.Context, username string) (models.User, error)
+	FindByEmail(ctx context.Context, email string) (models.User, error)
+	UsernameTaken(ctx context.Context, username string) (bool, error)
+	EmailTaken(ctx context.Context, email string) (bool, error)
+	// Insert trả ErrDuplicate khi trùng username/email
+	Insert(ctx context.Context, user models.User) error
+	// SetPassword ghi bcrypt hash mới
+	SetPassword(ctx context.Context, id primitive.ObjectID, hash string) error
+	// ConsumeTOTPStep ghi bước TOTP vừa dùng, false nếu bước đó không mới hơn bước đã ghi (mã bị dùng lại)
+	ConsumeTOTPStep(ctx context.Context, id primitive.ObjectID, step int64) (bool, error)
+	// ConsumeRecoveryCode gỡ mã khôi phục khớp một trong các hash, false nếu không có mã nào khớp
+	ConsumeRecoveryCode(ctx context.Context, id primitive.ObjectID, hashes []string) (bool, error)
+	// SetPendingTOTPSecret lưu secret chờ xác nhận khi bật 2FA
+	SetPendingTOTPSecret(ctx context.Context, id primitive.ObjectID, secret string) error
+	// EnableTOTP bật 2FA với secret đang chờ, bước vừa dùng và hash các mã khôi phục
+	EnableTOTP(ctx context.Context, id primitive.ObjectID, secret string, step int64, recoveryHashes []string) error
+	// DisableTOTP tắt 2FA và xoá secret, mã khôi phục
+	DisableTOTP(ctx context.Context, id primitive.ObjectID) error
+	// UpdateProfile ghi display_name, bio, avatar_url, email, email_verified của user; chuỗi rỗng là xoá field.
+	// Trả ErrDuplicate khi email đã thuộc tài khoản khác
+	UpdateProfile(ctx context.Context, user models.User) error
+	// MarkEmailVerified chỉ xác minh khi user vẫn dùng đúng email, ErrNotFound nếu email đã đổi
+	MarkEmailVerified(ctx context.Context, id primitive.ObjectID, email string) error
+	// Ban khoá tài khoản, until = nil là khoá vĩnh viễn
+	Ban(ctx context.Context, id primitive.ObjectID, reason string, until *time.Time) error
+	// Unban mở khoá, ErrNotFound nếu tài khoản không bị khoá
+	Unban(ctx context.Context, id primitive.ObjectID) error
+	// SetRole đổi role và trả về role trước đó
+	SetRole(ctx context.Context, id primitive.ObjectID, role string) (string, error)
+	// ReplaceRole chỉ đổi khi role hiện tại vẫn là from, false nếu role đã bị đổi song song
+	ReplaceRole(ctx context.Context, id primitive.ObjectID, from, to string) (bool, error)
+	// Delete xoá tài khoản kèm dữ liệu đăng nhập, tủ sách, lượt theo dõi, lời mời; bình luận được ẩn danh
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type CommentRepository interface {
+	// FindByChapter sắp theo thời gian tạo, cũ trước
+	FindByChapter(ctx context.Context, chapterID primitive.ObjectID, page Page) ([]models.Comment, error)
+	FindByID(ctx context.Context, id primitive.ObjectID) (models.Comment, error)
+	Insert(ctx context.Context, comment models.Comment) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+}
+
+type SessionRepository interface {
+	// FindByID chỉ trả phiên thuộc đúng userID
+	FindByID(ctx context.Context, id, userID primitive.ObjectID) (models.Session, error)
+	// FindActive trả các phiên chưa thu hồi, chưa hết hạn của user, dùng gần nhất trước
+	FindActive(ctx context.Context, userID primitive.ObjectID, now time.Time) ([]models.Session, error)
+	FindByRefreshToken(ctx context.Context, tokenHash string) (models.Session, error)
+	Insert(ctx context.Context, session models.Session) error
+	// Touch cập nhật lần dùng gần nhất và IP của phiên
+	Touch(ctx context.Context, id primitive.ObjectID, seenAt time.Time, ip string) error
+	// Rotate thay refresh token (lọc theo hash cũ để hai request đồng thời không cùng thành công),
+	// ghi lần dùng, hạn, user agent và IP từ next; ErrNotFound nếu hash cũ đã bị thay
+	Rotate(ctx context.Context, id primitive.ObjectID, oldHash string, next models.Session) error
+	// Revoke thu hồi một phiên còn hiệu lực của user, ErrNotFound nếu không có
+	Revoke(ctx context.Context, id, userID primitive.ObjectID) error
+	// RevokeByPreviousToken thu hồi phiên có refresh token cũ trùng hash (token đã xoay vòng bị dùng lại)
+	RevokeByPreviousToken(ctx context.Context, tokenHash string) error
+	// RevokeAll thu hồi mọi phiên còn hiệu lực của user, trả số phiên bị thu hồi
+	RevokeAll(ctx context.Context, userID primitive.ObjectID) (int64, error)
+	// RevokeOthers thu hồi mọi phiên còn hiệu lực của user trừ phiên keepID, trả số phiên bị thu hồi
+	RevokeOthers(ctx context.Context, userID, keepID primitive.ObjectID) (int64, error)
+}
+
+// BookshelfEntry: một dòng trong tủ sách kèm tên truyện và chương đang đọc
+type BookshelfEntry struct {
+	StoryID       *primitive.ObjectID `bson:"story_id,omitempty" json:"story_id,omitempty"`
+	StoryTitle    string              `bson:"story_title,omitempty" json:"story_title,omitempty"`
+	LastChapterID *primitive.ObjectID `bson:"last_chapter_id,omitempty" json:"last_chapter_id,omitempty"`
+	ChapterNumber int                 `bson:"chapter_number,omitempty" json:"chapter_number,omitempty"`
+	ChapterTitle  string              `bson:"chapter_title,omitempty" json:"chapter_title,omitempty"`
+	AddedAt       time.Time           `bson:"added_at" json:"added_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// BookshelfSortFields: các trường được phép sắp xếp tủ sách
+var BookshelfSortFields = map[string]bool{
+	"updated_at":     true,
+	"added_at":       true,
+	"story_title":    true,
+	"chapter_number": true,
+}
+
+type BookshelfRepository interface {
+	// List sắp theo sortBy (một trong BookshelfSortFields), order 1 tăng dần / -1 giảm dần
+	List(ctx context.Context, userID primitive.ObjectID, sortBy string, order int, page Page) ([]BookshelfEntry, error)
+	// SaveProgress thêm truyện vào tủ sách hoặc cập nhật chương đang đọc, created = true nếu vừa thêm
+	SaveProgress(ctx context.Context, userID, storyID, chapterID primitive.ObjectID) (created bool, err error)
+	Remove(ctx context.Context, userID, storyID primitive.ObjectID) error
+	DeleteByStory(ctx context.Context, storyID primitive.ObjectID) error
+}
+
+// Repositories: bộ repository dùng cho handlers
+type Repositories struct {
+	Stories   StoryRepository
+	Chapters  ChapterRepository
+	Users     UserRepository
+	Comments  CommentRepository
+	Bookshelf BookshelfRepository
+	Sessions  SessionRepository
+}
+
+// NewMongo: repository trên MongoDB (dùng khi chạy thật)
+func NewMongo(db *mongo.Database) Repositories {
+	return Repositories{
+		Stories:   NewMongoStoryRepository(db),
+		Chapters:  NewMongoChapterRepository(db),
+		Users:     NewMongoUserRepository(db),
+		Comments:  NewMongoCommentRepository(db),
+		Bookshelf: NewMongoBookshelfRepository(db),
+		Sessions:  NewMongoSessionRepository(db),
+	}
+}
+
+// NewMemory: repository trong RAM (dùng cho test), tủ sách đọc tên truyện/chương từ chính bộ này
+func NewMemory() Repositories {
+	stories := NewMemoryStoryRepository()
+	chapters := NewMemoryChapterRepository()
+	return Repositories{
+		Stories:   stories,
+		Chapters:  chapters,
+		Users:     NewMemoryUserRepository(),
+		Comments:  NewMemoryCommentRepository(),
+		Bookshelf: NewMemoryBookshelfRepository(stories, chapters),
+		Sessions:  NewMemorySessionRepository(),
+	}
+}
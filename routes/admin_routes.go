@@ -3,15 +3,30 @@ package routes
 import (
 	"Truyen_BE/controllers"
 	 "Truyen_BE/middleware"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AdminRoutes(r *gin.Engine) {
+func AdminRoutes(r *gin.Engine, repos repository.Repositories) {
+	h := controllers.NewHandler(repos)
 	admin := r.Group("/admin")
-	admin.Use(middlewares.AuthMiddleware(), middlewares.AdminOnly())
+	admin.Use(middlewares.AuthMiddleware(repos))
 	{
-		admin.PUT("/stories/:title/ban", controllers.BanStory)
-		admin.PUT("/stories/:title/unban", controllers.UnbanStory)
+		admin.PUT("/stories/:title/ban", middlewares.Require(policy.StoryBan, nil), h.BanStory)
+		admin.PUT("/stories/:title/unban", middlewares.Require(policy.StoryBan, nil), h.UnbanStory)
+		admin.POST("/story-transfers", middlewares.Require(policy.StoryForceTransfer, nil), h.ForceTransferStory)
+		admin.GET("/users", middlewares.Require(policy.UserList, nil), h.ListUsers)
+		admin.GET("/users/:id", middlewares.Require(policy.UserList, nil), h.GetUserDetail)
+		admin.PUT("/users/:id/ban", middlewares.Require(policy.UserBan, nil), h.BanUser)
+		admin.PUT("/users/:id/unban", middlewares.Require(policy.UserBan, nil), h.UnbanUser)
+		admin.PUT("/users/:id/role", middlewares.Require(policy.UserManageRoles, nil), h.ChangeUserRole)
+		admin.DELETE("/users/:id/lockout", middlewares.Require(policy.UserBan, nil), h.ClearLoginLockout)
+		admin.GET("/author-applications", middlewares.Require(policy.AuthorApplicationReview, nil), controllers.ListAuthorApplications)
+		admin.PUT("/author-applications/:id/approve", middlewares.Require(policy.AuthorApplicationReview, nil), h.ApproveAuthorApplication)
+		admin.PUT("/author-applications/:id/reject", middlewares.Require(policy.AuthorApplicationReview, nil), h.RejectAuthorApplication)
+		admin.GET("/roles", middlewares.Require(policy.RoleManage, nil), controllers.ListRoles)
+		admin.PUT("/roles/:name", middlewares.Require(policy.RoleManage, nil), controllers.UpdateRole)
 	}
 }
@@ -3,17 +3,19 @@ package routes
 import (
 	"Truyen_BE/controllers"
 	middlewares "Truyen_BE/middleware"
+	"Truyen_BE/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func BookshelfRoutes(router *gin.Engine) {
+func BookshelfRoutes(router *gin.Engine, repos repository.Repositories) {
+	h := controllers.NewHandler(repos)
 	bookshelfGroup := router.Group("/bookshelf")
-	bookshelfGroup.Use(middlewares.AuthMiddleware())
+	bookshelfGroup.Use(middlewares.AuthMiddleware(repos), middlewares.RejectAPIKeyWrites())
 	{
 
-		bookshelfGroup.GET("", controllers.GetBookshelf)                     
-		bookshelfGroup.DELETE("/:story_id", controllers.RemoveFromBookshelf) 
-		bookshelfGroup.POST("", controllers.UpdateLastChapter)              
+		bookshelfGroup.GET("", h.GetBookshelf)                     
+		bookshelfGroup.DELETE("/:story_id", h.RemoveFromBookshelf) 
+		bookshelfGroup.POST("", h.UpdateLastChapter)              
 	}
 }
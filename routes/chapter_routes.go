@@ -3,32 +3,46 @@ package routes
 import (
 	"Truyen_BE/controllers"
 	middlewares "Truyen_BE/middleware"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func ChapterRoutes(router *gin.Engine) {
+func ChapterRoutes(router *gin.Engine, repos repository.Repositories) {
+	h := controllers.NewHandler(repos)
 	chapterGroup := router.Group("/stories/chapters")
-	chapterGroup.Use(middlewares.LoggingMiddleware)
 	{
-		chapterGroup.GET("/:story_id/:number", controllers.GetChapterByStoryAndNumber)
-		chapterGroup.GET("/id/:id", controllers.GetChapterByID)
-		chapterGroup.GET("/newest", controllers.GetNewestChapters)
+		chapterGroup.GET("/:story_id/:number", h.GetChapterByStoryAndNumber)
+		chapterGroup.GET("/id/:id", h.GetChapterByID)
+		chapterGroup.GET("/newest", h.GetNewestChapters)
 		chapterGroup.POST("",
-			middlewares.AuthMiddleware(),
-			middlewares.IsAuthorOfStory(),
-			controllers.InsertChapter)
+			middlewares.AuthMiddleware(repos),
+			middlewares.Require(policy.ChapterCreate, middlewares.StoryFromBody(repos)),
+			h.InsertChapter)
 
 		chapterGroup.PUT("/:id",
-			middlewares.AuthMiddleware(),
-			middlewares.IsAuthorOfChapter(), 
-			controllers.UpdateChapter)
+			middlewares.AuthMiddleware(repos),
+			middlewares.Require(policy.ChapterUpdate, middlewares.ChapterByID(repos, "id")),
+			h.UpdateChapter)
+		chapterGroup.PATCH("/:id",
+			middlewares.AuthMiddleware(repos),
+			middlewares.Require(policy.ChapterUpdate, middlewares.ChapterByID(repos, "id")),
+			h.UpdateChapter)
 
 		chapterGroup.DELETE("/:id",
-			middlewares.AuthMiddleware(),
-			middlewares.IsAuthorOfChapter(),
-			controllers.DeleteChapter)
-		chapterGroup.POST("/comment", middlewares.AuthMiddleware(), controllers.InsertComment)
-		chapterGroup.GET("/comments/:chapter_id", controllers.GetCommentsByChapterID)
+			middlewares.AuthMiddleware(repos),
+			middlewares.Require(policy.ChapterDelete, middlewares.ChapterByID(repos, "id")),
+			h.DeleteChapter)
+		chapterGroup.POST("/comment",
+			middlewares.AuthMiddleware(repos),
+			middlewares.RequireVerifiedEmail(),
+			middlewares.Require(policy.CommentCreate, nil),
+			h.InsertComment)
+		chapterGroup.GET("/comments/:chapter_id", h.GetCommentsByChapterID)
+		chapterGroup.DELETE("/comments/:id",
+			middlewares.AuthMiddleware(repos),
+			middlewares.Require(policy.CommentDelete, middlewares.CommentByID(repos, "id")),
+			h.DeleteComment)
 	}
 }
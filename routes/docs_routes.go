@@ -0,0 +1,14 @@
+package routes
+
+import (
+	"Truyen_BE/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DocsRoutes: tài liệu OpenAPI và trang Swagger UI đọc tài liệu đó
+func DocsRoutes(r *gin.Engine) {
+	r.GET("/openapi.json", openapi.Handler(Spec()))
+	r.GET("/docs", openapi.SwaggerUI())
+	r.GET("/docs/assets/*filepath", openapi.SwaggerAssets())
+}
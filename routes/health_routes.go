@@ -0,0 +1,13 @@
+package routes
+
+import (
+	"Truyen_BE/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthRoutes: endpoint cho liveness/readiness probe, không cần đăng nhập
+func HealthRoutes(r *gin.Engine) {
+	r.GET("/healthz", controllers.Healthz)
+	r.GET("/readyz", controllers.Readyz)
+}
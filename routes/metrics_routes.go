@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"Truyen_BE/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsRoutes: GET /metrics cho Prometheus scrape
+func MetricsRoutes(r *gin.Engine) {
+	r.GET("/metrics", gin.WrapH(metrics.Default.Handler()))
+}
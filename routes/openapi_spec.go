@@ -0,0 +1,409 @@
+package routes
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/dto"
+	"Truyen_BE/models"
+	"Truyen_BE/openapi"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	specOnce sync.Once
+	spec     *openapi.Document
+)
+
+// Spec: tài liệu OpenAPI của mọi route NewRouter đăng ký. Thêm route mới thì thêm dòng
+// tương ứng vào apiRoutes, test trong router_test.go sẽ báo nếu quên.
+func Spec() *openapi.Document {
+	specOnce.Do(func() {
+		spec = openapi.Build(openapi.Info{
+			Title:       "Truyen_BE API",
+			Version:     "1.0.0",
+			Description: "API đọc và đăng truyện. Lỗi luôn có dạng Error với mã ổn định trong trường code.",
+		}, apiTags, apiRoutes)
+	})
+	return spec
+}
+
+// storyListItem: phần tử của GET /stories (truyện kèm chương mới nhất)
+type storyListItem struct {
+	models.Story
+	LatestChapterID    string `json:"latest_chapter_id"`
+	LatestChapterTitle string `json:"latest_chapter_title"`
+}
+
+var (
+	message = openapi.Object{"message": ""}
+
+	tokens = openapi.Object{"token": "", "refresh_token": "", "expires_in": 0}
+
+	// loginResult: bật 2FA thì chỉ có message, mfa_required, mfa_token, expires_in
+	loginResult = openapi.Object{
+		"message": "", "token": "", "refresh_token": "", "expires_in": 0,
+		"mfa_required": false, "mfa_token": "",
+		"user": openapi.Object{"id": "", "username": "", "role": "", "status": ""},
+	}
+
+	oidcStart = openapi.Object{"authorization_url": "", "state": "", "expires_in": 0}
+)
+
+func pageQuery(limit string) []openapi.Param {
+	return []openapi.Param{
+		{Name: "page", Default: "1", Integer: true, Description: "Trang, bắt đầu từ 1"},
+		{Name: "limit", Default: limit, Integer: true, Description: "Số phần tử mỗi trang"},
+	}
+}
+
+func paged(name string, items any) openapi.Object {
+	return openapi.Object{"page": 0, "limit": 0, "total": int64(0), name: items}
+}
+
+var apiTags = []openapi.Tag{
+	{Name: "stories", Description: "Truyện"},
+	{Name: "chapters", Description: "Chương và bình luận"},
+	{Name: "collaborators", Description: "Cộng tác viên và chuyển quyền sở hữu truyện"},
+	{Name: "auth", Description: "Đăng ký, đăng nhập, token, đăng nhập qua OIDC"},
+	{Name: "account", Description: "Tài khoản của người dùng đang đăng nhập"},
+	{Name: "users", Description: "Hồ sơ công khai và theo dõi"},
+	{Name: "bookshelf", Description: "Tủ sách"},
+	{Name: "admin", Description: "Quản trị"},
+	{Name: "system", Description: "Health check, metrics, khoá JWT, tài liệu, file tĩnh"},
+}
+
+var apiRoutes = []openapi.Route{
+	// Truyện
+	{Method: http.MethodGet, Path: "/stories", Tag: "stories", Summary: "Danh sách truyện kèm chương mới nhất",
+		Query: pageQuery("10"), Response: paged("stories", []storyListItem{})},
+	{Method: http.MethodGet, Path: "/stories/search", Tag: "stories", Summary: "Tìm truyện theo tên",
+		Query:    []openapi.Param{{Name: "name", Description: "Một phần tên truyện, không phân biệt hoa thường"}},
+		Response: []models.Story{}},
+	{Method: http.MethodGet, Path: "/stories/filter", Tag: "stories", Summary: "Lọc và sắp xếp truyện",
+		Query: append([]openapi.Param{
+			{Name: "genre"},
+			{Name: "status", Enum: []string{dto.StoryActive, dto.StoryCompleted, dto.StoryPaused}},
+			{Name: "author", Description: "Một phần tên tác giả"},
+			{Name: "sort", Default: string(repository.SortUpdatedDesc), Enum: []string{
+				string(repository.SortUpdatedDesc), string(repository.SortViewsDesc),
+				string(repository.SortChaptersDesc), string(repository.SortTitleAsc),
+			}},
+		}, pageQuery("10")...),
+		Response: paged("stories", []models.Story{})},
+	{Method: http.MethodGet, Path: "/stories/ranking", Tag: "stories", Summary: "Truyện nhiều lượt xem nhất",
+		Query: []openapi.Param{{Name: "limit", Default: "10", Integer: true}}, Response: []models.Story{}},
+	{Method: http.MethodGet, Path: "/stories/featured", Tag: "stories", Summary: "Truyện đề cử", Response: []models.Story{}},
+	{Method: http.MethodGet, Path: "/stories/newest", Tag: "stories", Summary: "Truyện mới cập nhật",
+		Query: []openapi.Param{{Name: "limit", Default: "10", Integer: true}}, Response: []models.StoryWithLatestChapter{}},
+	{Method: http.MethodGet, Path: "/stories/genre", Tag: "stories", Summary: "Số truyện theo thể loại", Response: []repository.GenreCount{}},
+	{Method: http.MethodGet, Path: "/stories/genre/:genre", Tag: "stories", Summary: "Truyện của một thể loại", Response: []models.Story{}},
+	{Method: http.MethodGet, Path: "/stories/:id", Tag: "stories", Summary: "Chi tiết truyện",
+		Response: models.Story{}, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodGet, Path: "/stories/:id/chapters", Tag: "stories", Summary: "Danh sách chương của truyện",
+		Response: openapi.Object{"story_id": "", "chapters": []models.Chapter{}}},
+	{Method: http.MethodGet, Path: "/stories/:id/export", Tag: "stories", Summary: "Xuất truyện kèm toàn bộ chương",
+		Response: openapi.Object{"story": models.Story{}, "chapters": []models.Chapter{}}, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPost, Path: "/stories", Tag: "stories", Summary: "Đăng truyện mới",
+		Auth: true, Permission: policy.StoryCreate, Body: dto.CreateStory{},
+		Response: openapi.Object{"message": "", "id": ""}},
+	{Method: http.MethodPut, Path: "/stories/:id", Tag: "stories", Summary: "Sửa truyện (như PATCH)",
+		Auth: true, Permission: policy.StoryUpdate, Body: dto.StoryPatch{}, MergePatch: true,
+		Response: message, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPatch, Path: "/stories/:id", Tag: "stories", Summary: "Sửa truyện bằng JSON merge patch",
+		Auth: true, Permission: policy.StoryUpdate, Body: dto.StoryPatch{}, MergePatch: true,
+		Response: message, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodDelete, Path: "/stories/:id", Tag: "stories", Summary: "Xoá truyện cùng các chương",
+		Auth: true, Permission: policy.StoryDelete, Response: message, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodDelete, Path: "/my-stories/:title", Tag: "stories", Summary: "Tác giả xoá truyện theo tên",
+		Auth: true, Permission: policy.StoryDelete, Response: message,
+		Errors: []apperror.Code{apperror.StoryNotFound, apperror.StoryDeleteForbidden}},
+
+	// Chương và bình luận
+	{Method: http.MethodGet, Path: "/stories/chapters/:story_id/:number", Tag: "chapters", Summary: "Chương theo số thứ tự",
+		Response: models.Chapter{}, Errors: []apperror.Code{apperror.ChapterNotFound}},
+	{Method: http.MethodGet, Path: "/stories/chapters/id/:id", Tag: "chapters", Summary: "Đọc chương (tăng lượt xem) kèm chương trước/sau",
+		Response: openapi.Object{"chapter": models.Chapter{}, "previous": (*models.Chapter)(nil), "next": (*models.Chapter)(nil)},
+		Errors:   []apperror.Code{apperror.ChapterNotFound}},
+	{Method: http.MethodGet, Path: "/stories/chapters/newest", Tag: "chapters", Summary: "Chương mới đăng", Response: []models.Chapter{}},
+	{Method: http.MethodPost, Path: "/stories/chapters", Tag: "chapters", Summary: "Đăng chương mới (số chương do server đánh)",
+		Auth: true, Permission: policy.ChapterCreate, Body: dto.CreateChapter{},
+		Response: openapi.Object{"message": "", "id": "", "chapter_number": 0}, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPut, Path: "/stories/chapters/:id", Tag: "chapters", Summary: "Sửa chương (như PATCH)",
+		Auth: true, Permission: policy.ChapterUpdate, Body: dto.ChapterPatch{}, MergePatch: true,
+		Response: message, Errors: []apperror.Code{apperror.ChapterNotFound}},
+	{Method: http.MethodPatch, Path: "/stories/chapters/:id", Tag: "chapters", Summary: "Sửa chương bằng JSON merge patch",
+		Auth: true, Permission: policy.ChapterUpdate, Body: dto.ChapterPatch{}, MergePatch: true,
+		Response: message, Errors: []apperror.Code{apperror.ChapterNotFound}},
+	{Method: http.MethodPut, Path: "/stories/:id/chapters/order", Tag: "chapters", Summary: "Sắp xếp lại thứ tự chương (chỉ chủ truyện)",
+		Auth: true, Permission: policy.ChapterReorder, Body: dto.ReorderChapters{},
+		Response: openapi.Object{"message": "", "chapters": []models.Chapter{}}, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodDelete, Path: "/stories/chapters/:id", Tag: "chapters", Summary: "Xoá chương, đánh lại số các chương sau",
+		Auth: true, Permission: policy.ChapterDelete, Response: message, Errors: []apperror.Code{apperror.ChapterNotFound}},
+	{Method: http.MethodPost, Path: "/stories/chapters/comment", Tag: "chapters", Summary: "Bình luận chương (cần email đã xác minh)",
+		Auth: true, Permission: policy.CommentCreate, Body: dto.CreateComment{},
+		Response: openapi.Object{"message": "", "comment": openapi.Object{
+			"id": "", "content": "", "chapter_id": "", "story_id": "", "user_id": "", "created_at": time.Time{},
+		}},
+		Errors: []apperror.Code{apperror.EmailNotVerified}},
+	{Method: http.MethodGet, Path: "/stories/chapters/comments/:chapter_id", Tag: "chapters", Summary: "Bình luận của chương, cũ trước",
+		Query: pageQuery("10"), Response: openapi.Object{"chapter_id": "", "page": 0, "limit": 0, "comments": []models.Comment{}}},
+	{Method: http.MethodDelete, Path: "/stories/chapters/comments/:id", Tag: "chapters", Summary: "Xoá bình luận",
+		Auth: true, Permission: policy.CommentDelete, Response: message, Errors: []apperror.Code{apperror.CommentNotFound}},
+
+	// Cộng tác viên và chuyển quyền
+	{Method: http.MethodGet, Path: "/stories/:id/collaborators", Tag: "collaborators", Summary: "Chủ truyện và cộng tác viên",
+		Response: openapi.Object{
+			"owner":         openapi.Object{"user_id": "", "username": "", "role": ""},
+			"collaborators": []models.Collaborator{},
+		},
+		Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPost, Path: "/stories/:id/collaborators/invitations", Tag: "collaborators", Summary: "Mời cộng tác",
+		Auth: true, Permission: policy.StoryManageCollaborators, Body: dto.InviteCollaborator{},
+		Response: openapi.Object{"message": "", "invitation": models.StoryInvitation{}},
+		Errors: []apperror.Code{apperror.StoryNotFound, apperror.UserNotFound, apperror.AlreadyOwner,
+			apperror.AlreadyCollaborator, apperror.InvitationPending}},
+	{Method: http.MethodGet, Path: "/stories/:id/collaborators/invitations", Tag: "collaborators", Summary: "Lời mời đang chờ của truyện",
+		Auth: true, Permission: policy.StoryManageCollaborators,
+		Response: openapi.Object{"invitations": []models.StoryInvitation{}}, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodDelete, Path: "/stories/:id/collaborators/invitations/:invitation_id", Tag: "collaborators", Summary: "Huỷ lời mời",
+		Auth: true, Permission: policy.StoryManageCollaborators, Response: message,
+		Errors: []apperror.Code{apperror.StoryNotFound, apperror.InvitationNotFound}},
+	{Method: http.MethodDelete, Path: "/stories/:id/collaborators/:user_id", Tag: "collaborators", Summary: "Xoá cộng tác viên",
+		Auth: true, Permission: policy.StoryManageCollaborators, Response: message,
+		Errors: []apperror.Code{apperror.StoryNotFound, apperror.CollaboratorNotFound}},
+	{Method: http.MethodPost, Path: "/stories/:id/transfer", Tag: "collaborators", Summary: "Đề nghị chuyển truyện cho tác giả khác",
+		Auth: true, Permission: policy.StoryTransfer, Body: dto.TransferStory{},
+		Response: openapi.Object{"message": "", "transfer": models.StoryTransfer{}},
+		Errors: []apperror.Code{apperror.StoryNotFound, apperror.UserNotFound, apperror.RecipientNotAuthor,
+			apperror.AlreadyOwner, apperror.TransferPending}},
+	{Method: http.MethodDelete, Path: "/stories/:id/transfer", Tag: "collaborators", Summary: "Huỷ đề nghị chuyển truyện",
+		Auth: true, Permission: policy.StoryTransfer, Response: message,
+		Errors: []apperror.Code{apperror.StoryNotFound, apperror.TransferNotFound}},
+
+	// Đăng ký, đăng nhập
+	{Method: http.MethodPost, Path: "/users/register", Tag: "auth", Summary: "Đăng ký tài khoản",
+		Body: dto.Register{},
+		Response: openapi.Object{"message": "", "user": openapi.Object{
+			"id": "", "username": "", "role": "", "status": "", "email": "", "email_verified": false,
+		}},
+		Errors: []apperror.Code{apperror.UsernameTaken, apperror.EmailTaken, apperror.AccountExists}},
+	{Method: http.MethodPost, Path: "/users/auth/login", Tag: "auth", Summary: "Đăng nhập bằng mật khẩu",
+		Body: dto.Login{}, Response: loginResult,
+		Errors: []apperror.Code{apperror.InvalidCredentials, apperror.AccountBanned, apperror.TooManyAttempts}},
+	{Method: http.MethodPost, Path: "/users/auth/login/2fa", Tag: "auth", Summary: "Bước 2 của đăng nhập khi bật 2FA",
+		Body: dto.Login2FA{}, Response: loginResult,
+		Errors: []apperror.Code{apperror.Unauthenticated, apperror.MFATokenExpired, apperror.InvalidTOTPCode,
+			apperror.TwoFactorDisabled, apperror.UserNotFound, apperror.AccountBanned, apperror.TooManyAttempts}},
+	{Method: http.MethodPost, Path: "/users/auth/refresh", Tag: "auth", Summary: "Đổi refresh token lấy cặp token mới",
+		Body: dto.RefreshToken{}, Response: tokens,
+		Errors: []apperror.Code{apperror.RefreshTokenInvalid, apperror.SessionExpired, apperror.UserNotFound, apperror.AccountBanned}},
+	{Method: http.MethodPost, Path: "/users/auth/logout", Tag: "auth", Summary: "Thu hồi phiên hiện tại",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/auth/forgot-password", Tag: "auth", Summary: "Gửi link đặt lại mật khẩu",
+		Description: "Luôn trả cùng một thông báo để không lộ email nào đã đăng ký",
+		Body:        dto.ForgotPassword{}, Response: message},
+	{Method: http.MethodPost, Path: "/users/auth/reset-password", Tag: "auth", Summary: "Đặt lại mật khẩu bằng token trong link",
+		Body: dto.ResetPassword{}, Response: message, Errors: []apperror.Code{apperror.LinkInvalid}},
+	{Method: http.MethodPost, Path: "/users/auth/verify-email", Tag: "auth", Summary: "Xác minh email bằng token trong link",
+		Body: dto.VerifyEmail{}, Response: message, Errors: []apperror.Code{apperror.LinkInvalid, apperror.EmailChanged}},
+	{Method: http.MethodGet, Path: "/users/auth/oidc/providers", Tag: "auth", Summary: "Các provider OIDC đã cấu hình",
+		Response: openapi.Object{"providers": []string{}}},
+	{Method: http.MethodGet, Path: "/users/auth/oidc/:provider/start", Tag: "auth", Summary: "Bắt đầu đăng nhập qua OIDC",
+		Response: oidcStart, Errors: []apperror.Code{apperror.OIDCProviderNotFound}},
+	{Method: http.MethodPost, Path: "/users/auth/oidc/:provider/callback", Tag: "auth", Summary: "Hoàn tất đăng nhập OIDC",
+		Body: dto.OIDCCallback{}, Response: loginResult,
+		Errors: []apperror.Code{apperror.OIDCProviderNotFound, apperror.OIDCStateInvalid, apperror.OIDCStateMismatch,
+			apperror.OIDCAuthFailed, apperror.UserNotFound, apperror.AccountBanned, apperror.AccountBeingCreated,
+			apperror.EmailBelongsToAccount}},
+
+	// Tài khoản đang đăng nhập
+	{Method: http.MethodGet, Path: "/users/me", Tag: "account", Summary: "Thông tin tài khoản",
+		Auth: true,
+		Response: openapi.Object{
+			"user_id": "", "username": "", "role": "", "created_at": time.Time{}, "status": "", "email": "",
+			"email_verified": false, "display_name": "", "bio": "", "avatar_url": "", "totp_enabled": false,
+		},
+		Errors: []apperror.Code{apperror.UserNotFound}},
+	{Method: http.MethodPatch, Path: "/users/me", Tag: "account", Summary: "Sửa hồ sơ, null hoặc chuỗi rỗng để xoá trường",
+		Auth: true, Body: dto.ProfilePatch{}, Response: openapi.Object{"message": "", "user": models.User{}},
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.EmailTaken, apperror.NothingToUpdate, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodDelete, Path: "/users/me", Tag: "account", Summary: "Xoá tài khoản (tài khoản SSO xác nhận bằng 2FA hoặc provider)",
+		Auth: true, Body: dto.DeleteAccount{}, Response: message,
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.WrongPassword, apperror.ReauthRequired,
+			apperror.TwoFactorDisabled, apperror.InvalidTOTPCode, apperror.OIDCProviderNotFound, apperror.OIDCStateInvalid,
+			apperror.OIDCStateMismatch, apperror.OIDCAuthFailed, apperror.IdentityNotFound,
+			apperror.OwnsStories, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPut, Path: "/users/me/password", Tag: "account", Summary: "Đổi hoặc đặt mật khẩu (đặt lần đầu phải xác nhận bằng 2FA hoặc provider), đăng xuất các phiên khác",
+		Auth: true, Body: dto.ChangePassword{}, Response: message,
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.WrongPassword, apperror.ReauthRequired,
+			apperror.TwoFactorDisabled, apperror.InvalidTOTPCode, apperror.OIDCProviderNotFound, apperror.OIDCStateInvalid,
+			apperror.OIDCStateMismatch, apperror.OIDCAuthFailed, apperror.IdentityNotFound, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/me/email/verification", Tag: "account", Summary: "Gửi lại email xác minh",
+		Auth: true, Response: message,
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.EmailMissing, apperror.EmailAlreadyVerified, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/me/2fa/setup", Tag: "account", Summary: "Tạo secret TOTP chờ xác nhận",
+		Auth: true, Response: openapi.Object{"secret": "", "otpauth_uri": ""},
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.TwoFactorEnabled, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/me/2fa/enable", Tag: "account", Summary: "Bật 2FA, trả mã khôi phục một lần",
+		Auth: true, Body: dto.TOTPCode{}, Response: openapi.Object{"message": "", "recovery_codes": []string{}},
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.TwoFactorNotSetUp, apperror.InvalidTOTPCode, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/me/2fa/disable", Tag: "account", Summary: "Tắt 2FA",
+		Auth: true, Body: dto.DisableTOTP{}, Response: message,
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.WrongPassword, apperror.InvalidTOTPCode,
+			apperror.TwoFactorDisabled, apperror.TwoFactorRequired, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodGet, Path: "/users/me/author-application", Tag: "account", Summary: "Đơn đăng ký tác giả gần nhất",
+		Auth: true, Response: models.AuthorApplication{}, Errors: []apperror.Code{apperror.ApplicationNotFound}},
+	{Method: http.MethodPost, Path: "/users/me/author-application", Tag: "account", Summary: "Gửi đơn đăng ký tác giả (cần email đã xác minh)",
+		Auth: true, Body: dto.AuthorApplication{},
+		Response: openapi.Object{"message": "", "application": models.AuthorApplication{}},
+		Errors:   []apperror.Code{apperror.EmailNotVerified, apperror.AlreadyAuthor, apperror.RoleNotPromotable, apperror.ApplicationPending, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodGet, Path: "/users/me/sessions", Tag: "account", Summary: "Các phiên đăng nhập còn hiệu lực",
+		Auth: true,
+		Response: openapi.Object{"sessions": []openapi.Object{{
+			"id": "", "user_agent": "", "ip": "", "created_at": time.Time{}, "last_seen_at": time.Time{},
+			"expires_at": time.Time{}, "current": false,
+		}}}},
+	{Method: http.MethodDelete, Path: "/users/me/sessions", Tag: "account", Summary: "Đăng xuất mọi thiết bị khác",
+		Auth: true, Response: openapi.Object{"message": "", "revoked": int64(0)}, Errors: []apperror.Code{apperror.APIKeyNotAllowed}},
+	{Method: http.MethodDelete, Path: "/users/me/sessions/:id", Tag: "account", Summary: "Thu hồi một phiên",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.SessionNotFound, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodGet, Path: "/users/me/identities", Tag: "account", Summary: "Tài khoản ngoài đã liên kết",
+		Auth: true, Response: openapi.Object{"identities": []models.UserIdentity{}, "available_providers": []string{}}},
+	{Method: http.MethodPost, Path: "/users/me/identities/:provider/start", Tag: "account", Summary: "Bắt đầu liên kết tài khoản ngoài",
+		Auth: true, Response: oidcStart, Errors: []apperror.Code{apperror.OIDCProviderNotFound, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/me/identities/:provider/callback", Tag: "account", Summary: "Hoàn tất liên kết tài khoản ngoài",
+		Auth: true, Body: dto.OIDCCallback{}, Response: message,
+		Errors: []apperror.Code{apperror.OIDCProviderNotFound, apperror.OIDCStateInvalid, apperror.OIDCStateMismatch,
+			apperror.OIDCAuthFailed, apperror.IdentityAlreadyLinked, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodDelete, Path: "/users/me/identities/:provider", Tag: "account", Summary: "Gỡ liên kết tài khoản ngoài",
+		Auth: true, Response: message,
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.IdentityNotFound, apperror.LastLoginMethod, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodGet, Path: "/users/me/api-keys", Tag: "account", Summary: "API key của tôi",
+		Auth: true, Response: openapi.Object{"api_keys": []models.APIKey{}}},
+	{Method: http.MethodPost, Path: "/users/me/api-keys", Tag: "account", Summary: "Tạo API key, key gốc chỉ trả về một lần",
+		Auth: true, Body: dto.CreateAPIKey{}, Response: openapi.Object{"message": "", "key": "", "api_key": models.APIKey{}},
+		Errors: []apperror.Code{apperror.TooManyAPIKeys, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodDelete, Path: "/users/me/api-keys/:id", Tag: "account", Summary: "Thu hồi API key",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.APIKeyNotFound, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodGet, Path: "/users/stories", Tag: "account", Summary: "Truyện tôi sở hữu hoặc cộng tác",
+		Auth: true, Response: openapi.Object{"stories": []models.Story{}}},
+	{Method: http.MethodGet, Path: "/users/me/invitations", Tag: "account", Summary: "Lời mời cộng tác đang chờ",
+		Auth: true, Response: openapi.Object{"invitations": []models.StoryInvitation{}}},
+	{Method: http.MethodPost, Path: "/users/me/invitations/:id/accept", Tag: "account", Summary: "Nhận lời mời cộng tác",
+		Auth: true, Response: openapi.Object{"message": "", "collaborator": models.Collaborator{}},
+		Errors: []apperror.Code{apperror.InvitationNotFound, apperror.StoryChanged, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/me/invitations/:id/decline", Tag: "account", Summary: "Từ chối lời mời cộng tác",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.InvitationNotFound, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodDelete, Path: "/users/me/collaborations/:story_id", Tag: "account", Summary: "Rời khỏi truyện đang cộng tác",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.CollaboratorNotFound, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodGet, Path: "/users/me/transfers", Tag: "account", Summary: "Đề nghị chuyển truyện gửi tới tôi",
+		Auth: true, Response: openapi.Object{"transfers": []models.StoryTransfer{}}},
+	{Method: http.MethodPost, Path: "/users/me/transfers/:id/accept", Tag: "account", Summary: "Nhận chuyển truyện",
+		Auth: true, Response: openapi.Object{"message": "", "story_id": ""},
+		Errors: []apperror.Code{apperror.TransferNotFound, apperror.AuthorOnly, apperror.StoryChanged, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodPost, Path: "/users/me/transfers/:id/decline", Tag: "account", Summary: "Từ chối chuyển truyện",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.TransferNotFound, apperror.APIKeyNotAllowed}},
+
+	// Hồ sơ công khai
+	{Method: http.MethodGet, Path: "/users/:username", Tag: "users", Summary: "Hồ sơ công khai",
+		Response: openapi.Object{
+			"id": "", "username": "", "display_name": "", "avatar_url": "", "bio": "", "role": "",
+			"joined_at": time.Time{}, "follower_count": int64(0), "story_count": int64(0), "total_views": int64(0),
+		},
+		Errors: []apperror.Code{apperror.UserNotFound}},
+	{Method: http.MethodGet, Path: "/users/:username/stories", Tag: "users", Summary: "Truyện công khai của người dùng",
+		Query: pageQuery("10"), Response: paged("stories", []models.Story{}), Errors: []apperror.Code{apperror.UserNotFound}},
+	{Method: http.MethodPost, Path: "/users/:username/follow", Tag: "users", Summary: "Theo dõi",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.UserNotFound, apperror.SelfAction, apperror.APIKeyNotAllowed}},
+	{Method: http.MethodDelete, Path: "/users/:username/follow", Tag: "users", Summary: "Bỏ theo dõi",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.UserNotFound, apperror.FollowNotFound, apperror.APIKeyNotAllowed}},
+
+	// Tủ sách
+	{Method: http.MethodGet, Path: "/bookshelf", Tag: "bookshelf", Summary: "Tủ sách của tôi",
+		Auth: true,
+		Query: append(pageQuery("10"),
+			openapi.Param{Name: "sortBy", Default: "updated_at", Enum: []string{"updated_at", "added_at", "story_title", "chapter_number"}},
+			openapi.Param{Name: "sortOrder", Default: "-1", Integer: true, Enum: []string{"1", "-1"}},
+		),
+		Response: []repository.BookshelfEntry{}},
+	{Method: http.MethodPost, Path: "/bookshelf", Tag: "bookshelf", Summary: "Thêm truyện hoặc cập nhật chương đọc cuối",
+		Auth: true, Body: dto.BookshelfProgress{}, Response: message, Errors: []apperror.Code{apperror.APIKeyNotAllowed}},
+	{Method: http.MethodDelete, Path: "/bookshelf/:story_id", Tag: "bookshelf", Summary: "Bỏ truyện khỏi tủ sách",
+		Auth: true, Response: message, Errors: []apperror.Code{apperror.BookshelfEntryNotFound, apperror.APIKeyNotAllowed}},
+
+	// Quản trị
+	{Method: http.MethodPut, Path: "/admin/stories/:title/ban", Tag: "admin", Summary: "Ban truyện (ẩn khỏi danh sách)",
+		Auth: true, Permission: policy.StoryBan, Response: message, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPut, Path: "/admin/stories/:title/unban", Tag: "admin", Summary: "Bỏ ban truyện",
+		Auth: true, Permission: policy.StoryBan, Response: message, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPut, Path: "/admin/stories/ban/:title", Tag: "admin", Summary: "Ban truyện (đường dẫn cũ)",
+		Auth: true, Permission: policy.StoryBan, Response: message, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPut, Path: "/admin/stories/unban/:title", Tag: "admin", Summary: "Bỏ ban truyện (đường dẫn cũ)",
+		Auth: true, Permission: policy.StoryBan, Response: message, Errors: []apperror.Code{apperror.StoryNotFound}},
+	{Method: http.MethodPost, Path: "/admin/story-transfers", Tag: "admin", Summary: "Chuyển truyện không cần người nhận đồng ý",
+		Auth: true, Permission: policy.StoryForceTransfer, Body: dto.ForceTransfer{},
+		Response: openapi.Object{"message": "", "story_id": "", "owner_id": ""},
+		Errors: []apperror.Code{apperror.StoryNotFound, apperror.UserNotFound, apperror.RecipientNotAuthor,
+			apperror.AlreadyOwner, apperror.StoryChanged}},
+	{Method: http.MethodGet, Path: "/admin/users", Tag: "admin", Summary: "Tìm người dùng",
+		Auth: true, Permission: policy.UserList,
+		Query: append([]openapi.Param{
+			{Name: "q", Description: "Một phần username hoặc email"},
+			{Name: "role"},
+			{Name: "status", Enum: []string{"active", "banned"}, Description: "banned chỉ gồm tài khoản đang bị khoá, khoá tạm đã hết hạn tính là active"},
+		}, pageQuery("20")...),
+		Response: paged("users", []models.User{})},
+	{Method: http.MethodGet, Path: "/admin/users/:id", Tag: "admin", Summary: "Chi tiết người dùng kèm lịch sử quản trị",
+		Auth: true, Permission: policy.UserList,
+		Response: openapi.Object{"user": models.User{}, "history": []models.UserAuditLog{}}, Errors: []apperror.Code{apperror.UserNotFound}},
+	{Method: http.MethodPut, Path: "/admin/users/:id/ban", Tag: "admin", Summary: "Khoá tài khoản và thu hồi mọi phiên",
+		Auth: true, Permission: policy.UserBan, Body: dto.BanUser{},
+		Response: openapi.Object{"message": "", "revoked_sessions": int64(0)},
+		Errors:   []apperror.Code{apperror.UserNotFound, apperror.SelfAction, apperror.AdminProtected}},
+	{Method: http.MethodPut, Path: "/admin/users/:id/unban", Tag: "admin", Summary: "Mở khoá tài khoản",
+		Auth: true, Permission: policy.UserBan, Body: dto.UnbanUser{}, Response: message,
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.SelfAction}},
+	{Method: http.MethodPut, Path: "/admin/users/:id/role", Tag: "admin", Summary: "Đổi vai trò",
+		Auth: true, Permission: policy.UserManageRoles, Body: dto.ChangeRole{}, Response: openapi.Object{"message": "", "role": ""},
+		Errors: []apperror.Code{apperror.UserNotFound, apperror.SelfAction}},
+	{Method: http.MethodDelete, Path: "/admin/users/:id/lockout", Tag: "admin", Summary: "Gỡ khoá đăng nhập do nhập sai nhiều lần",
+		Auth: true, Permission: policy.UserBan,
+		Query:    []openapi.Param{{Name: "ip", Description: "Gỡ thêm khoá theo IP"}},
+		Response: message, Errors: []apperror.Code{apperror.UserNotFound}},
+	{Method: http.MethodGet, Path: "/admin/author-applications", Tag: "admin", Summary: "Đơn đăng ký tác giả, cũ nhất trước",
+		Auth: true, Permission: policy.AuthorApplicationReview,
+		Query: append([]openapi.Param{
+			{Name: "status", Default: "pending", Enum: []string{"pending", "approved", "rejected"}},
+		}, pageQuery("20")...),
+		Response: paged("applications", []models.AuthorApplication{})},
+	{Method: http.MethodPut, Path: "/admin/author-applications/:id/approve", Tag: "admin", Summary: "Duyệt đơn, nâng người gửi lên tác giả",
+		Auth: true, Permission: policy.AuthorApplicationReview, Body: dto.ReviewApplication{},
+		Response: openapi.Object{"message": "", "application": models.AuthorApplication{}}, Errors: []apperror.Code{apperror.ApplicationNotFound, apperror.RoleNotPromotable}},
+	{Method: http.MethodPut, Path: "/admin/author-applications/:id/reject", Tag: "admin", Summary: "Từ chối đơn (bắt buộc có message)",
+		Auth: true, Permission: policy.AuthorApplicationReview, Body: dto.ReviewApplication{},
+		Response: openapi.Object{"message": "", "application": models.AuthorApplication{}}, Errors: []apperror.Code{apperror.ApplicationNotFound}},
+	{Method: http.MethodGet, Path: "/admin/roles", Tag: "admin", Summary: "Các role và toàn bộ quyền",
+		Auth: true, Permission: policy.RoleManage, Response: openapi.Object{"roles": []policy.Role{}, "permissions": []policy.Permission{}}},
+	{Method: http.MethodPut, Path: "/admin/roles/:name", Tag: "admin", Summary: "Tạo hoặc thay tập quyền của role",
+		Auth: true, Permission: policy.RoleManage, Body: dto.UpdateRole{}, Response: openapi.Object{"message": "", "role": policy.Role{}}},
+
+	// Hệ thống
+	{Method: http.MethodPost, Path: "/upload", Tag: "system", Summary: "Upload ảnh",
+		File: "file", Response: openapi.Object{"url": ""}, Errors: []apperror.Code{apperror.FileTooLarge}},
+	{Method: http.MethodGet, Path: "/static/*filepath", Tag: "system", Summary: "File đã upload", ContentType: "application/octet-stream"},
+	{Method: http.MethodHead, Path: "/static/*filepath", Tag: "system", Summary: "File đã upload (chỉ header)"},
+	{Method: http.MethodGet, Path: "/.well-known/jwks.json", Tag: "system", Summary: "Public key kiểm tra access token",
+		Response: openapi.Object{"keys": []map[string]string{}}},
+	{Method: http.MethodGet, Path: "/healthz", Tag: "system", Summary: "Liveness probe", Response: openapi.Object{"status": ""}},
+	{Method: http.MethodGet, Path: "/readyz", Tag: "system", Summary: "Readiness probe: MongoDB và thư mục upload",
+		Response:  openapi.Object{"status": "", "checks": map[string]string{}},
+		Responses: map[int]any{http.StatusServiceUnavailable: openapi.Object{"status": "", "checks": map[string]string{}}}},
+	{Method: http.MethodGet, Path: "/metrics", Tag: "system", Summary: "Metrics cho Prometheus", ContentType: "text/plain"},
+	{Method: http.MethodGet, Path: "/openapi.json", Tag: "system", Summary: "Tài liệu này", Response: openapi.Object{}},
+	{Method: http.MethodGet, Path: "/docs", Tag: "system", Summary: "Swagger UI", ContentType: "text/html"},
+	{Method: http.MethodGet, Path: "/docs/assets/*filepath", Tag: "system", Summary: "CSS/JS của Swagger UI (nhúng sẵn)",
+		ContentType: "application/octet-stream"},
+}
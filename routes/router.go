@@ -0,0 +1,52 @@
+package routes
+
+import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	middlewares "Truyen_BE/middleware"
+	"Truyen_BE/repository"
+	"Truyen_BE/utils"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// NewRouter dựng gin engine đầy đủ (CORS, routes, file tĩnh) theo config.App.
+// Handlers và middleware đọc dữ liệu qua repos: main truyền bản MongoDB, test truyền bản in-memory.
+func NewRouter(repos repository.Repositories) *gin.Engine {
+	r := gin.New()
+	// Log JSON theo từng request thay cho logger dạng text của gin.
+	// ErrorHandler đứng trước Recovery để panic cũng được render theo định dạng lỗi chung.
+	r.Use(middlewares.RequestID(), middlewares.RequestLogger(), middlewares.Metrics(),
+		middlewares.ErrorHandler(), middlewares.Recovery())
+	r.MaxMultipartMemory = config.App.Upload.MaxBytes
+	// Cấu hình CORS
+	r.Use(cors.New(cors.Config{
+		AllowOrigins:     config.App.CORS.AllowedOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "Accept", "X-Requested-With", "X-API-Key", middlewares.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", "Content-Type", middlewares.RequestIDHeader},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}))
+
+	// Gắn các routes
+	StoryRoutes(r, repos)
+	ChapterRoutes(r, repos)
+	UserRoutes(r, repos)
+	BookshelfRoutes(r, repos)
+	AdminRoutes(r, repos)
+	WellKnownRoutes(r)
+	HealthRoutes(r)
+	MetricsRoutes(r)
+	DocsRoutes(r)
+	uploadDir, _ := filepath.Abs(config.App.Upload.Dir)
+	r.Static("/static", uploadDir)
+	r.POST("/upload", utils.UploadImage)
+	r.NoRoute(func(c *gin.Context) {
+		apperror.Abort(c, apperror.RouteNotFound)
+	})
+	return r
+}
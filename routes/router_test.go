@@ -0,0 +1,794 @@
+package routes_test
+
+import (
+	"Truyen_BE/config"
+	"Truyen_BE/logging"
+	"Truyen_BE/metrics"
+	"Truyen_BE/models"
+	"Truyen_BE/openapi"
+	"Truyen_BE/repository"
+	"Truyen_BE/routes"
+	"Truyen_BE/throttle"
+	"Truyen_BE/utils"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const testOrigin = "http://frontend.test"
+
+var loadKeysOnce sync.Once
+
+// testServer: engine đầy đủ như cmd/main.go chạy trên httptest.Server, dữ liệu nằm trong RAM
+type testServer struct {
+	*httptest.Server
+	repos repository.Repositories
+}
+
+// testUser: user đã đăng nhập qua API, token là access token thật
+type testUser struct {
+	ID       primitive.ObjectID
+	Username string
+	Token    string
+}
+
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	loadKeysOnce.Do(func() {
+		// Không cấu hình file khoá → khoá ký tạm thời
+		if err := utils.LoadJWTKeys(config.JWTConfig{}); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	previousConfig, previousThrottle := config.App, throttle.Default
+	t.Cleanup(func() {
+		config.App, throttle.Default = previousConfig, previousThrottle
+	})
+	config.App = config.Defaults()
+	config.App.CORS.AllowedOrigins = []string{testOrigin}
+	config.App.Upload.Dir = t.TempDir()
+	throttle.Default = throttle.NewGuard(throttle.NewMemoryStore())
+
+	repos := repository.NewMemory()
+	srv := httptest.NewServer(routes.NewRouter(repos))
+	t.Cleanup(srv.Close)
+	return &testServer{Server: srv, repos: repos}
+}
+
+// do gửi request JSON; token rỗng là khách chưa đăng nhập
+func (s *testServer) do(t *testing.T, method, path, token string, body any) (int, []byte) {
+	t.Helper()
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequest(method, s.URL+path, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, raw
+}
+
+// expect gửi request, kiểm tra status và decode body vào out (nếu khác nil)
+func (s *testServer) expect(t *testing.T, status int, method, path, token string, body, out any) {
+	t.Helper()
+	code, raw := s.do(t, method, path, token, body)
+	if code != status {
+		t.Fatalf("%s %s: status = %d, muốn %d, body: %s", method, path, code, status, raw)
+	}
+	if out != nil {
+		if err := json.Unmarshal(raw, out); err != nil {
+			t.Fatalf("%s %s: response không phải JSON hợp lệ: %v\n%s", method, path, err, raw)
+		}
+	}
+}
+
+func (s *testServer) login(t *testing.T, username, password string) string {
+	t.Helper()
+	var body struct {
+		Token string `json:"token"`
+	}
+	s.expect(t, http.StatusOK, http.MethodPost, "/users/auth/login", "", gin.H{"username": username, "password": password}, &body)
+	if body.Token == "" {
+		t.Fatal("đăng nhập không trả token")
+	}
+	return body.Token
+}
+
+// register đăng ký qua API (role mặc định user) rồi đăng nhập
+func (s *testServer) register(t *testing.T, username string) testUser {
+	t.Helper()
+	var body struct {
+		User struct {
+			ID string `json:"id"`
+		} `json:"user"`
+	}
+	s.expect(t, http.StatusOK, http.MethodPost, "/users/register", "", gin.H{"username": username, "password": "mật-khẩu-dài"}, &body)
+	id, err := primitive.ObjectIDFromHex(body.User.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return testUser{ID: id, Username: username, Token: s.login(t, username, "mật-khẩu-dài")}
+}
+
+// userWithRole tạo sẵn user có role cho trước (API không cho tự nâng role) rồi đăng nhập
+func (s *testServer) userWithRole(t *testing.T, username, role string) testUser {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte("mật-khẩu-dài"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := models.User{
+		ID:            primitive.NewObjectID(),
+		Username:      username,
+		Password:      string(hash),
+		Role:          role,
+		Status:        "active",
+		EmailVerified: true,
+		CreatedAt:     time.Now(),
+	}
+	if err := s.repos.Users.Insert(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	return testUser{ID: user.ID, Username: username, Token: s.login(t, username, "mật-khẩu-dài")}
+}
+
+func (s *testServer) createStory(t *testing.T, author testUser, title string) string {
+	t.Helper()
+	var body struct {
+		ID string `json:"id"`
+	}
+	s.expect(t, http.StatusOK, http.MethodPost, "/stories", author.Token, gin.H{"title": title, "genres": []string{"fantasy"}}, &body)
+	return body.ID
+}
+
+func (s *testServer) createChapter(t *testing.T, author testUser, storyID, title string) (string, int) {
+	t.Helper()
+	var body struct {
+		ID            string `json:"id"`
+		ChapterNumber int    `json:"chapter_number"`
+	}
+	s.expect(t, http.StatusOK, http.MethodPost, "/stories/chapters", author.Token, gin.H{"story_id": storyID, "title": title}, &body)
+	return body.ID, body.ChapterNumber
+}
+
+func TestRegisterLoginAndCurrentUser(t *testing.T) {
+	s := newTestServer(t)
+	reader := s.register(t, "reader")
+
+	var me struct {
+		Username string `json:"username"`
+		Role     string `json:"role"`
+	}
+	s.expect(t, http.StatusOK, http.MethodGet, "/users/me", reader.Token, nil, &me)
+	if me.Username != "reader" || me.Role != "user" {
+		t.Fatalf("/users/me sai: %+v", me)
+	}
+
+	s.expect(t, http.StatusUnauthorized, http.MethodPost, "/users/auth/login", "", gin.H{"username": "reader", "password": "sai"}, nil)
+	s.expect(t, http.StatusUnauthorized, http.MethodGet, "/users/me", "", nil, nil)
+	s.expect(t, http.StatusUnauthorized, http.MethodGet, "/users/me", "không-phải-jwt", nil, nil)
+	s.expect(t, http.StatusConflict, http.MethodPost, "/users/register", "", gin.H{"username": "reader", "password": "mật-khẩu-khác"}, nil)
+}
+
+func TestCORSAllowsConfiguredOriginOnly(t *testing.T) {
+	s := newTestServer(t)
+
+	preflight := func(origin string) *http.Response {
+		req, _ := http.NewRequest(http.MethodOptions, s.URL+"/stories", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+		req.Header.Set("Access-Control-Request-Headers", "Authorization, Content-Type")
+		resp, err := s.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp
+	}
+
+	resp := preflight(testOrigin)
+	if resp.StatusCode != http.StatusNoContent || resp.Header.Get("Access-Control-Allow-Origin") != testOrigin {
+		t.Fatalf("preflight từ origin hợp lệ: status %d, allow-origin %q", resp.StatusCode, resp.Header.Get("Access-Control-Allow-Origin"))
+	}
+	if resp := preflight("http://evil.test"); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("preflight từ origin lạ: status %d, muốn 403", resp.StatusCode)
+	}
+}
+
+func TestStoryCRUD(t *testing.T) {
+	s := newTestServer(t)
+	author := s.userWithRole(t, "author", "author")
+	other := s.userWithRole(t, "other", "author")
+
+	storyID := s.createStory(t, author, "Truyện A")
+
+	var story models.Story
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/"+storyID, "", nil, &story)
+	if story.Title != "Truyện A" || story.CreatedBy != author.ID {
+		t.Fatalf("truyện sai: %+v", story)
+	}
+
+	s.expect(t, http.StatusOK, http.MethodPut, "/stories/"+storyID, author.Token, gin.H{"title": "Truyện B"}, nil)
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/"+storyID, "", nil, &story)
+	if story.Title != "Truyện B" {
+		t.Fatalf("title = %q, muốn Truyện B", story.Title)
+	}
+
+	var list struct {
+		Total int64 `json:"total"`
+	}
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories", "", nil, &list)
+	if list.Total != 1 {
+		t.Fatalf("total = %d, muốn 1", list.Total)
+	}
+
+	// Tác giả khác không sửa/xoá được truyện không phải của mình
+	s.expect(t, http.StatusForbidden, http.MethodPut, "/stories/"+storyID, other.Token, gin.H{"title": "Chiếm"}, nil)
+	s.expect(t, http.StatusForbidden, http.MethodDelete, "/stories/"+storyID, other.Token, nil, nil)
+
+	s.expect(t, http.StatusOK, http.MethodDelete, "/stories/"+storyID, author.Token, nil, nil)
+	s.expect(t, http.StatusNotFound, http.MethodGet, "/stories/"+storyID, "", nil, nil)
+	s.expect(t, http.StatusNotFound, http.MethodPut, "/stories/"+storyID, author.Token, gin.H{"title": "x"}, nil)
+}
+
+func TestChapterInsertAndRenumber(t *testing.T) {
+	s := newTestServer(t)
+	author := s.userWithRole(t, "author", "author")
+	other := s.userWithRole(t, "other", "author")
+	storyID := s.createStory(t, author, "Truyện A")
+
+	firstID, first := s.createChapter(t, author, storyID, "Mở đầu")
+	secondID, second := s.createChapter(t, author, storyID, "Tiếp theo")
+	thirdID, third := s.createChapter(t, author, storyID, "Kết")
+	if first != 1 || second != 2 || third != 3 {
+		t.Fatalf("số chương = %d, %d, %d, muốn 1, 2, 3", first, second, third)
+	}
+
+	var story models.Story
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/"+storyID, "", nil, &story)
+	if story.ChaptersCount != 3 {
+		t.Fatalf("chapters_count = %d, muốn 3", story.ChaptersCount)
+	}
+
+	// Số chương không sửa qua PUT/PATCH chương, chỉ qua endpoint sắp xếp
+	s.expect(t, http.StatusBadRequest, http.MethodPut, "/stories/chapters/"+secondID, author.Token, gin.H{"chapter_number": 3}, nil)
+	s.expect(t, http.StatusOK, http.MethodPatch, "/stories/chapters/"+secondID, author.Token, gin.H{"title": "Chương hai"}, nil)
+
+	order := "/stories/" + storyID + "/chapters/order"
+	// Danh sách phải gồm đủ và đúng các chương của truyện
+	s.expect(t, http.StatusBadRequest, http.MethodPut, order, author.Token, gin.H{"chapter_ids": []string{thirdID, firstID}}, nil)
+	s.expect(t, http.StatusBadRequest, http.MethodPut, order, author.Token, gin.H{"chapter_ids": []string{thirdID, firstID, firstID}}, nil)
+	s.expect(t, http.StatusBadRequest, http.MethodPut, order, author.Token,
+		gin.H{"chapter_ids": []string{thirdID, firstID, secondID, primitive.NewObjectID().Hex()}}, nil)
+	s.expect(t, http.StatusForbidden, http.MethodPut, order, other.Token, gin.H{"chapter_ids": []string{thirdID, firstID, secondID}}, nil)
+
+	// Đưa chương "Kết" lên đầu: 3 → 1, 1 → 2, 2 → 3
+	var reordered struct {
+		Chapters []models.Chapter `json:"chapters"`
+	}
+	s.expect(t, http.StatusOK, http.MethodPut, order, author.Token, gin.H{"chapter_ids": []string{thirdID, firstID, secondID}}, &reordered)
+	if len(reordered.Chapters) != 3 || reordered.Chapters[0].ID.Hex() != thirdID || reordered.Chapters[2].ChapterNumber != 3 {
+		t.Fatalf("thứ tự sau khi sắp xếp sai: %+v", reordered.Chapters)
+	}
+	var chapter models.Chapter
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/chapters/"+storyID+"/3", "", nil, &chapter)
+	if chapter.ID.Hex() != secondID || chapter.Title != "Chương hai" {
+		t.Fatalf("chương 3 sai: %+v", chapter)
+	}
+
+	var list struct {
+		Chapters []models.Chapter `json:"chapters"`
+	}
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/"+storyID+"/chapters", "", nil, &list)
+	if len(list.Chapters) != 3 || list.Chapters[0].ID.Hex() != thirdID || list.Chapters[1].ID.Hex() != firstID {
+		t.Fatalf("danh sách chương sai: %+v", list.Chapters)
+	}
+
+	s.expect(t, http.StatusForbidden, http.MethodPost, "/stories/chapters", other.Token, gin.H{"story_id": storyID, "title": "Chen ngang"}, nil)
+	s.expect(t, http.StatusForbidden, http.MethodPut, "/stories/chapters/"+secondID, other.Token, gin.H{"title": "Sửa trộm"}, nil)
+	s.expect(t, http.StatusForbidden, http.MethodDelete, "/stories/chapters/"+secondID, other.Token, nil, nil)
+
+	// Xoá chương giữa → các chương sau lùi số, chương mới nối tiếp không trùng số
+	s.expect(t, http.StatusOK, http.MethodDelete, "/stories/chapters/"+firstID, author.Token, nil, nil)
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/chapters/"+storyID+"/2", "", nil, &chapter)
+	if chapter.ID.Hex() != secondID {
+		t.Fatalf("chương 2 sau khi xoá sai: %+v", chapter)
+	}
+	if _, number := s.createChapter(t, author, storyID, "Ngoại truyện"); number != 3 {
+		t.Fatalf("số chương mới = %d, muốn 3", number)
+	}
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/"+storyID, "", nil, &story)
+	if story.ChaptersCount != 3 {
+		t.Fatalf("chapters_count = %d, muốn 3", story.ChaptersCount)
+	}
+}
+
+func TestComments(t *testing.T) {
+	s := newTestServer(t)
+	author := s.userWithRole(t, "author", "author")
+	reader := s.register(t, "reader")
+	stranger := s.register(t, "stranger")
+	moderator := s.userWithRole(t, "mod", "moderator")
+
+	storyID := s.createStory(t, author, "Truyện A")
+	chapterID, _ := s.createChapter(t, author, storyID, "Một")
+
+	post := func(user testUser, content string) string {
+		t.Helper()
+		var body struct {
+			Comment struct {
+				ID string `json:"id"`
+			} `json:"comment"`
+		}
+		s.expect(t, http.StatusOK, http.MethodPost, "/stories/chapters/comment", user.Token, gin.H{
+			"story_id":   storyID,
+			"chapter_id": chapterID,
+			"content":    content,
+		}, &body)
+		return body.Comment.ID
+	}
+	first := post(reader, "Hay quá")
+	second := post(reader, "Hóng chương mới")
+
+	s.expect(t, http.StatusUnauthorized, http.MethodPost, "/stories/chapters/comment", "", gin.H{"story_id": storyID, "chapter_id": chapterID, "content": "Khách"}, nil)
+
+	var page struct {
+		Comments []models.Comment `json:"comments"`
+	}
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/chapters/comments/"+chapterID, "", nil, &page)
+	if len(page.Comments) != 2 || page.Comments[0].Content != "Hay quá" || page.Comments[0].UserID != reader.ID {
+		t.Fatalf("bình luận sai: %+v", page.Comments)
+	}
+
+	// Người khác không xoá được; chủ bình luận và moderator thì được
+	s.expect(t, http.StatusForbidden, http.MethodDelete, "/stories/chapters/comments/"+first, stranger.Token, nil, nil)
+	s.expect(t, http.StatusOK, http.MethodDelete, "/stories/chapters/comments/"+first, reader.Token, nil, nil)
+	s.expect(t, http.StatusOK, http.MethodDelete, "/stories/chapters/comments/"+second, moderator.Token, nil, nil)
+	s.expect(t, http.StatusNotFound, http.MethodDelete, "/stories/chapters/comments/"+second, moderator.Token, nil, nil)
+
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/chapters/comments/"+chapterID, "", nil, &page)
+	if len(page.Comments) != 0 {
+		t.Fatalf("bình luận vẫn còn: %+v", page.Comments)
+	}
+}
+
+func TestBookshelf(t *testing.T) {
+	s := newTestServer(t)
+	author := s.userWithRole(t, "author", "author")
+	reader := s.register(t, "reader")
+	storyID := s.createStory(t, author, "Đang đọc")
+	firstID, _ := s.createChapter(t, author, storyID, "Một")
+	secondID, _ := s.createChapter(t, author, storyID, "Hai")
+
+	s.expect(t, http.StatusUnauthorized, http.MethodGet, "/bookshelf", "", nil, nil)
+
+	s.expect(t, http.StatusOK, http.MethodPost, "/bookshelf", reader.Token, gin.H{"story_id": storyID, "last_chapter_id": firstID}, nil)
+	s.expect(t, http.StatusOK, http.MethodPost, "/bookshelf", reader.Token, gin.H{"story_id": storyID, "last_chapter_id": secondID}, nil)
+
+	var entries []repository.BookshelfEntry
+	s.expect(t, http.StatusOK, http.MethodGet, "/bookshelf", reader.Token, nil, &entries)
+	if len(entries) != 1 || entries[0].StoryTitle != "Đang đọc" || entries[0].ChapterNumber != 2 {
+		t.Fatalf("tủ sách sai: %+v", entries)
+	}
+
+	// Tủ sách của người khác không bị lộ
+	s.expect(t, http.StatusOK, http.MethodGet, "/bookshelf", author.Token, nil, &entries)
+	if len(entries) != 0 {
+		t.Fatalf("tủ sách của tác giả phải rỗng: %+v", entries)
+	}
+
+	s.expect(t, http.StatusOK, http.MethodDelete, "/bookshelf/"+storyID, reader.Token, nil, nil)
+	s.expect(t, http.StatusNotFound, http.MethodDelete, "/bookshelf/"+storyID, reader.Token, nil, nil)
+}
+
+func TestBanAndUnbanStory(t *testing.T) {
+	s := newTestServer(t)
+	author := s.userWithRole(t, "author", "author")
+	moderator := s.userWithRole(t, "mod", "moderator")
+	admin := s.userWithRole(t, "admin", "admin")
+	storyID := s.createStory(t, author, "Vi phạm")
+	title := url.PathEscape("Vi phạm")
+
+	total := func() int64 {
+		t.Helper()
+		var list struct {
+			Total int64 `json:"total"`
+		}
+		s.expect(t, http.StatusOK, http.MethodGet, "/stories", "", nil, &list)
+		return list.Total
+	}
+
+	s.expect(t, http.StatusForbidden, http.MethodPut, "/admin/stories/"+title+"/ban", author.Token, nil, nil)
+
+	s.expect(t, http.StatusOK, http.MethodPut, "/admin/stories/"+title+"/ban", moderator.Token, nil, nil)
+	if n := total(); n != 0 {
+		t.Fatalf("truyện bị ban vẫn hiện trong danh sách (total = %d)", n)
+	}
+	var story models.Story
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/"+storyID, "", nil, &story)
+	if !story.IsBanned {
+		t.Fatalf("truyện chưa bị ban: %+v", story)
+	}
+
+	// Đường dẫn cũ /admin/stories/unban/:title vẫn dùng được
+	s.expect(t, http.StatusOK, http.MethodPut, "/admin/stories/unban/"+title, admin.Token, nil, nil)
+	if n := total(); n != 1 {
+		t.Fatalf("total = %d sau khi bỏ ban, muốn 1", n)
+	}
+}
+
+func TestPermissionDenials(t *testing.T) {
+	s := newTestServer(t)
+	author := s.userWithRole(t, "author", "author")
+	reader := s.register(t, "reader")
+	moderator := s.userWithRole(t, "mod", "moderator")
+	storyID := s.createStory(t, author, "Của tác giả")
+	chapterID, _ := s.createChapter(t, author, storyID, "Một")
+	title := url.PathEscape("Của tác giả")
+
+	cases := []struct {
+		name   string
+		user   *testUser
+		method string
+		path   string
+		body   any
+		status int
+	}{
+		{"khách tạo truyện", nil, http.MethodPost, "/stories", gin.H{"title": "x"}, http.StatusUnauthorized},
+		{"reader tạo truyện", &reader, http.MethodPost, "/stories", gin.H{"title": "x"}, http.StatusForbidden},
+		{"reader sửa truyện", &reader, http.MethodPut, "/stories/" + storyID, gin.H{"title": "x"}, http.StatusForbidden},
+		{"moderator xoá truyện", &moderator, http.MethodDelete, "/stories/" + storyID, nil, http.StatusForbidden},
+		{"reader thêm chương", &reader, http.MethodPost, "/stories/chapters", gin.H{"story_id": storyID, "title": "x"}, http.StatusForbidden},
+		{"reader xoá chương", &reader, http.MethodDelete, "/stories/chapters/" + chapterID, nil, http.StatusForbidden},
+		{"reader ban truyện", &reader, http.MethodPut, "/admin/stories/" + title + "/ban", nil, http.StatusForbidden},
+		{"tác giả ban truyện", &author, http.MethodPut, "/admin/stories/ban/" + title, nil, http.StatusForbidden},
+		{"moderator đổi role", &moderator, http.MethodPut, "/admin/users/" + reader.ID.Hex() + "/role", gin.H{"role": "admin"}, http.StatusForbidden},
+		{"tác giả xem danh sách user", &author, http.MethodGet, "/admin/users", nil, http.StatusForbidden},
+		{"truyện không tồn tại", &author, http.MethodPut, "/stories/" + primitive.NewObjectID().Hex(), gin.H{"title": "x"}, http.StatusNotFound},
+		{"ID truyện sai định dạng", &author, http.MethodPut, "/stories/abc", gin.H{"title": "x"}, http.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			token := ""
+			if tc.user != nil {
+				token = tc.user.Token
+			}
+			s.expect(t, tc.status, tc.method, tc.path, token, tc.body, nil)
+		})
+	}
+
+	// Các request bị chặn không được làm thay đổi dữ liệu
+	var story models.Story
+	s.expect(t, http.StatusOK, http.MethodGet, "/stories/"+storyID, "", nil, &story)
+	if story.Title != "Của tác giả" || story.IsBanned || story.ChaptersCount != 1 {
+		t.Fatalf("truyện bị thay đổi: %+v", story)
+	}
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	s := newTestServer(t)
+
+	var health struct {
+		Status string `json:"status"`
+	}
+	s.expect(t, http.StatusOK, http.MethodGet, "/healthz", "", nil, &health)
+	if health.Status != "ok" {
+		t.Fatalf("status = %q, muốn ok", health.Status)
+	}
+
+	// Không có MongoDB trong test → chưa sẵn sàng, nhưng thư mục upload vẫn ghi được
+	var ready struct {
+		Status string            `json:"status"`
+		Checks map[string]string `json:"checks"`
+	}
+	s.expect(t, http.StatusServiceUnavailable, http.MethodGet, "/readyz", "", nil, &ready)
+	if ready.Status != "not_ready" || ready.Checks["mongodb"] != "fail" || ready.Checks["upload_dir"] != "ok" {
+		t.Fatalf("readyz sai: %+v", ready)
+	}
+
+	// Thư mục chỉ đọc: root vẫn ghi được nên khi đó thay bằng một file thường ở đúng đường dẫn
+	readOnly := filepath.Join(t.TempDir(), "chỉ-đọc")
+	if err := os.Mkdir(readOnly, 0o555); err != nil {
+		t.Fatal(err)
+	}
+	if probe, err := os.CreateTemp(readOnly, "probe-*"); err == nil {
+		probe.Close()
+		if err := os.RemoveAll(readOnly); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(readOnly, nil, 0o444); err != nil {
+			t.Fatal(err)
+		}
+	}
+	config.App.Upload.Dir = readOnly
+	s.expect(t, http.StatusServiceUnavailable, http.MethodGet, "/readyz", "", nil, &ready)
+	// Chỉ báo "fail", không lộ đường dẫn hay lỗi gốc ra endpoint công khai
+	if ready.Checks["upload_dir"] != "fail" {
+		t.Fatalf("thư mục upload không ghi được vẫn báo ok: %+v", ready)
+	}
+}
+
+func TestRequestIDAndStructuredLog(t *testing.T) {
+	s := newTestServer(t)
+	reader := s.register(t, "reader")
+
+	var logs bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(logging.New(&logs, slog.LevelDebug))
+	t.Cleanup(func() { slog.SetDefault(previous) })
+
+	req, _ := http.NewRequest(http.MethodGet, s.URL+"/users/me", nil)
+	req.Header.Set("Authorization", "Bearer "+reader.Token)
+	req.Header.Set("X-Request-ID", "client-id-123")
+	resp, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); got != "client-id-123" {
+		t.Fatalf("X-Request-ID = %q, muốn giữ nguyên ID của client", got)
+	}
+
+	var entry struct {
+		Msg       string            `json:"msg"`
+		RequestID string            `json:"request_id"`
+		Route     string            `json:"route"`
+		Status    int               `json:"status"`
+		UserID    string            `json:"user_id"`
+		Headers   map[string]string `json:"headers"`
+	}
+	if err := json.Unmarshal(logs.Bytes(), &entry); err != nil {
+		t.Fatalf("log không phải một dòng JSON: %v\n%s", err, logs.String())
+	}
+	if entry.Msg != "http request" || entry.RequestID != "client-id-123" || entry.Route != "/users/me" ||
+		entry.Status != http.StatusOK || entry.UserID != reader.ID.Hex() {
+		t.Fatalf("log request sai: %+v", entry)
+	}
+	if entry.Headers["Authorization"] != "[REDACTED]" || bytes.Contains(logs.Bytes(), []byte(reader.Token)) {
+		t.Fatalf("token lọt vào log: %s", logs.String())
+	}
+
+	// ID không hợp lệ bị thay bằng ID tự sinh
+	req, _ = http.NewRequest(http.MethodGet, s.URL+"/healthz", nil)
+	req.Header.Set("X-Request-ID", "có dấu cách {\"giả\": 1}")
+	resp, err = s.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-ID"); len(got) != 32 {
+		t.Fatalf("X-Request-ID tự sinh = %q", got)
+	}
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	s := newTestServer(t)
+	author := s.userWithRole(t, "author", "author")
+	storyID := s.createStory(t, author, "Đo đếm")
+
+	published := metrics.ChaptersPublished.Value()
+	failures := metrics.LoginFailures.Value()
+	healthz := metrics.HTTPRequests.Value("GET", "/healthz", "200")
+
+	s.createChapter(t, author, storyID, "Một")
+	s.expect(t, http.StatusUnauthorized, http.MethodPost, "/users/auth/login", "", gin.H{"username": "author", "password": "sai"}, nil)
+	s.expect(t, http.StatusOK, http.MethodGet, "/healthz", "", nil, nil)
+	s.expect(t, http.StatusNotFound, http.MethodGet, "/khong-co/"+primitive.NewObjectID().Hex(), "", nil, nil)
+	s.expect(t, http.StatusNotFound, "X0", "/healthz", "", nil, nil)
+	s.expect(t, http.StatusNotFound, "X1", "/khong-co", "", nil, nil)
+
+	if got := metrics.ChaptersPublished.Value(); got != published+1 {
+		t.Fatalf("chapters_published = %v, muốn %v", got, published+1)
+	}
+	if got := metrics.LoginFailures.Value(); got != failures+1 {
+		t.Fatalf("login_failures = %v, muốn %v", got, failures+1)
+	}
+	if got := metrics.HTTPRequests.Value("GET", "/healthz", "200"); got != healthz+1 {
+		t.Fatalf("http_requests /healthz = %v, muốn %v", got, healthz+1)
+	}
+
+	code, raw := s.do(t, http.MethodGet, "/metrics", "", nil)
+	if code != http.StatusOK {
+		t.Fatalf("/metrics status = %d", code)
+	}
+	body := string(raw)
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="POST",route="/stories/chapters",status="200"}`,
+		`http_requests_total{method="GET",route="unmatched",status="404"}`,
+		`http_requests_total{method="OTHER",route="unmatched",status="404"}`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_bucket{method="GET",route="/healthz",le="+Inf"}`,
+		"# TYPE mongodb_command_duration_seconds histogram",
+		"truyen_chapters_published_total ",
+		"truyen_stories_banned_total ",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics thiếu %q", want)
+		}
+	}
+	if strings.Contains(body, "/khong-co/") {
+		t.Error("path thô không được thành label")
+	}
+	if strings.Contains(body, `method="X0"`) || strings.Contains(body, `method="X1"`) {
+		t.Error("method lạ không được thành label")
+	}
+}
+
+func TestErrorEnvelopeAndLanguage(t *testing.T) {
+	s := newTestServer(t)
+
+	type errorBody struct {
+		Error   string `json:"error"`
+		Code    string `json:"code"`
+		Details []struct {
+			Field   string `json:"field"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"details"`
+		RequestID string `json:"request_id"`
+	}
+	send := func(method, path, acceptLanguage string, body any) (int, errorBody) {
+		t.Helper()
+		var reader io.Reader
+		if body != nil {
+			raw, _ := json.Marshal(body)
+			reader = bytes.NewReader(raw)
+		}
+		req, _ := http.NewRequest(method, s.URL+path, reader)
+		req.Header.Set("Content-Type", "application/json")
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		resp, err := s.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out errorBody
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatalf("%s %s: body lỗi không phải JSON: %v", method, path, err)
+		}
+		if out.RequestID == "" || out.RequestID != resp.Header.Get("X-Request-ID") {
+			t.Fatalf("%s %s: request_id = %q, header = %q", method, path, out.RequestID, resp.Header.Get("X-Request-ID"))
+		}
+		return resp.StatusCode, out
+	}
+
+	missing := "/stories/" + primitive.NewObjectID().Hex()
+	status, body := send(http.MethodGet, missing, "", nil)
+	if status != http.StatusNotFound || body.Code != "STORY_NOT_FOUND" || body.Error != "Không tìm thấy truyện" {
+		t.Fatalf("mặc định tiếng Việt: %d %+v", status, body)
+	}
+	status, body = send(http.MethodGet, missing, "en-US,en;q=0.9,vi;q=0.8", nil)
+	if status != http.StatusNotFound || body.Code != "STORY_NOT_FOUND" || body.Error != "Story not found" {
+		t.Fatalf("Accept-Language en: %d %+v", status, body)
+	}
+	// Ngôn ngữ chưa hỗ trợ bị bỏ qua, chọn theo trọng số q
+	_, body = send(http.MethodGet, missing, "fr-FR, en;q=0.3, vi;q=0.7", nil)
+	if body.Error != "Không tìm thấy truyện" {
+		t.Fatalf("chọn ngôn ngữ theo q sai: %+v", body)
+	}
+
+	status, body = send(http.MethodPost, "/users/register", "en", gin.H{"username": " ", "password": ""})
+	if status != http.StatusBadRequest || body.Code != "VALIDATION_FAILED" || len(body.Details) != 2 {
+		t.Fatalf("lỗi validate: %d %+v", status, body)
+	}
+	for i, field := range []string{"username", "password"} {
+		detail := body.Details[i]
+		if detail.Field != field || detail.Code != "REQUIRED" || detail.Message != "Is required" {
+			t.Fatalf("details[%d] = %+v", i, detail)
+		}
+	}
+
+	status, body = send(http.MethodGet, "/khong-ton-tai", "", nil)
+	if status != http.StatusNotFound || body.Code != "ROUTE_NOT_FOUND" {
+		t.Fatalf("route lạ: %d %+v", status, body)
+	}
+}
+
+func TestOpenAPICoversRoutes(t *testing.T) {
+	newTestServer(t)
+	spec := routes.Spec()
+
+	registered := map[string]bool{}
+	for _, route := range routes.NewRouter(repository.NewMemory()).Routes() {
+		path := openapi.PathFromGin(route.Path)
+		registered[route.Method+" "+path] = true
+		if spec.Paths[path].Operation(route.Method) == nil {
+			t.Errorf("route %s %s chưa có trong tài liệu OpenAPI (thêm vào apiRoutes)", route.Method, route.Path)
+		}
+	}
+	for path, item := range spec.Paths {
+		for _, method := range []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"} {
+			if item.Operation(method) != nil && !registered[method+" "+path] {
+				t.Errorf("tài liệu OpenAPI có %s %s nhưng router không đăng ký", method, path)
+			}
+		}
+	}
+}
+
+func TestOpenAPIEndpoints(t *testing.T) {
+	s := newTestServer(t)
+
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	s.expect(t, http.StatusOK, http.MethodGet, "/openapi.json", "", nil, &doc)
+	if doc.OpenAPI != openapi.Version {
+		t.Fatalf("openapi = %q, muốn %q", doc.OpenAPI, openapi.Version)
+	}
+	if _, ok := doc.Paths["/stories/{id}"]["patch"]; !ok {
+		t.Fatal("thiếu PATCH /stories/{id}")
+	}
+	for _, name := range []string{"Error", "FieldError", "CreateStory", "StoryPatch", "Story"} {
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			t.Errorf("components.schemas thiếu %s", name)
+		}
+	}
+
+	// Trang và mọi asset nó tham chiếu đều do server phục vụ, không phụ thuộc CDN
+	code, raw := s.do(t, http.MethodGet, "/docs", "", nil)
+	if code != http.StatusOK || strings.Contains(string(raw), "https://") {
+		t.Fatalf("/docs status = %d, body: %.300s", code, raw)
+	}
+	assets := regexp.MustCompile(`(?:src|href)="([^"]+)"`).FindAllStringSubmatch(string(raw), -1)
+	if len(assets) < 3 {
+		t.Fatalf("/docs thiếu CSS/JS: %s", raw)
+	}
+	for _, asset := range assets {
+		code, body := s.do(t, http.MethodGet, asset[1], "", nil)
+		if code != http.StatusOK || len(body) == 0 {
+			t.Errorf("GET %s: status = %d", asset[1], code)
+		}
+	}
+	if _, body := s.do(t, http.MethodGet, "/docs/assets/swagger-initializer.js", "", nil); !strings.Contains(string(body), "/openapi.json") {
+		t.Errorf("swagger-initializer.js không đọc /openapi.json: %s", body)
+	}
+	// Giấy phép Apache-2.0 của bundle phải đi kèm khi phân phối
+	for _, name := range []string{"LICENSE", "NOTICE", "swagger-ui-bundle.js.LICENSE.txt"} {
+		if code, body := s.do(t, http.MethodGet, "/docs/assets/"+name, "", nil); code != http.StatusOK || len(body) == 0 {
+			t.Errorf("GET /docs/assets/%s: status = %d", name, code)
+		}
+	}
+}
@@ -3,40 +3,57 @@ package routes
 import (
 	"Truyen_BE/controllers"
 	middlewares "Truyen_BE/middleware"
+	"Truyen_BE/policy"
+	"Truyen_BE/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func StoryRoutes(router *gin.Engine) {
+func StoryRoutes(router *gin.Engine, repos repository.Repositories) {
+	h := controllers.NewHandler(repos)
 
 	storyGroup := router.Group("/stories") 
 	{
-		storyGroup.GET("", controllers.GetStories)
-		storyGroup.GET("/search", controllers.SearchStoriesByName)
-		storyGroup.GET("/:id/chapters", controllers.GetChaptersByStoryID)
-		storyGroup.GET("/filter", controllers.FilterStories)
-		storyGroup.GET("/ranking", controllers.GetTopRankedStories)
-		storyGroup.GET("/:id/export", controllers.ExportStoryChapters)
-		storyGroup.GET("/featured", controllers.GetFeaturedStories)
-		storyGroup.GET("/:id", controllers.GetStoryContent)
-		storyGroup.GET("/genre", controllers.GetGenresWithCount)
-		storyGroup.GET("/genre/:genre", controllers.GetAllStoriesOfGenre)
-		storyGroup.GET("/newest", controllers.GetNewestUpdatedStoryList)
-		storyGroup.POST("", middlewares.AuthMiddleware(), middlewares.RequireRole("author"), controllers.InsertStory)
-		storyGroup.PUT("/:id", middlewares.AuthMiddleware(), middlewares.AuthorOnly(), controllers.UpdateStory)
-		storyGroup.DELETE("/:id", middlewares.AuthMiddleware(), middlewares.AuthorOnly(), controllers.DeleteStory)
+		storyGroup.GET("", h.GetStories)
+		storyGroup.GET("/search", h.SearchStoriesByName)
+		storyGroup.GET("/:id/chapters", h.GetChaptersByStoryID)
+		storyGroup.GET("/filter", h.FilterStories)
+		storyGroup.GET("/ranking", h.GetTopRankedStories)
+		storyGroup.GET("/:id/export", h.ExportStoryChapters)
+		storyGroup.GET("/featured", h.GetFeaturedStories)
+		storyGroup.GET("/:id", h.GetStoryContent)
+		storyGroup.GET("/genre", h.GetGenresWithCount)
+		storyGroup.GET("/genre/:genre", h.GetAllStoriesOfGenre)
+		storyGroup.GET("/newest", h.GetNewestUpdatedStoryList)
+		storyGroup.POST("", middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryCreate, nil), h.InsertStory)
+		storyGroup.PUT("/:id", middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryUpdate, middlewares.StoryByID(repos, "id")), h.UpdateStory)
+		storyGroup.PATCH("/:id", middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryUpdate, middlewares.StoryByID(repos, "id")), h.UpdateStory)
+		storyGroup.DELETE("/:id", middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryDelete, middlewares.StoryByID(repos, "id")), h.DeleteStory)
+		storyGroup.PUT("/:id/chapters/order", middlewares.AuthMiddleware(repos), middlewares.Require(policy.ChapterReorder, middlewares.StoryByID(repos, "id")), h.ReorderChapters)
+		storyGroup.GET("/:id/collaborators", h.GetStoryCollaborators)
+		storyGroup.POST("/:id/transfer", middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryTransfer, middlewares.StoryByID(repos, "id")), h.RequestStoryTransfer)
+		storyGroup.DELETE("/:id/transfer", middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryTransfer, middlewares.StoryByID(repos, "id")), controllers.CancelStoryTransfer)
+	}
+
+	collaborators := router.Group("/stories/:id/collaborators")
+	collaborators.Use(middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryManageCollaborators, middlewares.StoryByID(repos, "id")))
+	{
+		collaborators.POST("/invitations", h.InviteCollaborator)
+		collaborators.GET("/invitations", controllers.GetStoryInvitations)
+		collaborators.DELETE("/invitations/:invitation_id", controllers.CancelStoryInvitation)
+		collaborators.DELETE("/:user_id", controllers.RemoveCollaborator)
 	}
 
 	author := router.Group("/my-stories")
-	author.Use(middlewares.AuthMiddleware(), middlewares.AuthorOnly()) 
+	author.Use(middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryDelete, middlewares.StoryByTitle(repos, "title")))
 	{
-		author.DELETE("/:title", controllers.DeleteStoryByAuthor)
+		author.DELETE("/:title", h.DeleteStoryByAuthor)
 	}
 
 	admin := router.Group("/admin/stories")
-	admin.Use(middlewares.AuthMiddleware(), middlewares.AdminOnly())
+	admin.Use(middlewares.AuthMiddleware(repos), middlewares.Require(policy.StoryBan, nil))
 	{
-		admin.PUT("/ban/:title", controllers.BanStory)
-		admin.PUT("/unban/:title", controllers.UnbanStory)
+		admin.PUT("/ban/:title", h.BanStory)
+		admin.PUT("/unban/:title", h.UnbanStory)
 	}
 }
@@ -3,21 +3,62 @@ package routes
 import (
 	"Truyen_BE/controllers"
 	middlewares "Truyen_BE/middleware"
+	"Truyen_BE/repository"
 
 	"github.com/gin-gonic/gin"
 )
 
-func UserRoutes(router *gin.Engine) {
+func UserRoutes(router *gin.Engine, repos repository.Repositories) {
+	h := controllers.NewHandler(repos)
 	public := router.Group("/users")
 	{
-		public.POST("/register", controllers.RegisterUser)
-		public.POST("/auth/login", controllers.LoginUser)
+		public.POST("/register", h.RegisterUser)
+		public.POST("/auth/login", h.LoginUser)
+		public.POST("/auth/login/2fa", h.LoginWith2FA)
+		public.POST("/auth/refresh", h.RefreshToken)
+		public.POST("/auth/forgot-password", h.ForgotPassword)
+		public.POST("/auth/reset-password", h.ResetPassword)
+		public.POST("/auth/verify-email", h.VerifyEmail)
+		public.GET("/auth/oidc/providers", controllers.ListOIDCProviders)
+		public.GET("/auth/oidc/:provider/start", controllers.StartOIDCLogin)
+		public.POST("/auth/oidc/:provider/callback", h.OIDCLoginCallback)
+		public.GET("/:username", h.GetPublicProfile)
+		public.GET("/:username/stories", h.GetPublicUserStories)
 	}
 
 	protected := router.Group("/users")
-	protected.Use(middlewares.AuthMiddleware())
+	protected.Use(middlewares.AuthMiddleware(repos), middlewares.RejectAPIKeyWrites())
 	{
-		protected.GET("/me", controllers.GetCurrentUser)
-		protected.GET("/stories", controllers.GetUserStories)
+		protected.POST("/auth/logout", h.LogoutUser)
+		protected.GET("/me", h.GetCurrentUser)
+		protected.PATCH("/me", h.UpdateMyProfile)
+		protected.DELETE("/me", h.DeleteMyAccount)
+		protected.PUT("/me/password", h.ChangeMyPassword)
+		protected.POST("/me/email/verification", h.ResendVerificationEmail)
+		protected.POST("/me/2fa/setup", h.Setup2FA)
+		protected.POST("/me/2fa/enable", h.Enable2FA)
+		protected.POST("/me/2fa/disable", h.Disable2FA)
+		protected.GET("/me/author-application", controllers.GetMyAuthorApplication)
+		protected.POST("/me/author-application", middlewares.RequireVerifiedEmail(), controllers.SubmitAuthorApplication)
+		protected.GET("/me/sessions", h.GetMySessions)
+		protected.DELETE("/me/sessions", h.RevokeOtherSessions)
+		protected.DELETE("/me/sessions/:id", h.RevokeMySession)
+		protected.GET("/me/identities", controllers.GetMyIdentities)
+		protected.POST("/me/identities/:provider/start", controllers.StartOIDCLink)
+		protected.POST("/me/identities/:provider/callback", controllers.OIDCLinkCallback)
+		protected.DELETE("/me/identities/:provider", h.UnlinkIdentity)
+		protected.GET("/me/api-keys", controllers.GetMyAPIKeys)
+		protected.POST("/me/api-keys", controllers.CreateAPIKey)
+		protected.DELETE("/me/api-keys/:id", controllers.RevokeAPIKey)
+		protected.GET("/stories", h.GetUserStories)
+		protected.GET("/me/invitations", controllers.GetMyInvitations)
+		protected.POST("/me/invitations/:id/accept", controllers.AcceptInvitation)
+		protected.POST("/me/invitations/:id/decline", controllers.DeclineInvitation)
+		protected.DELETE("/me/collaborations/:story_id", controllers.LeaveCollaboration)
+		protected.GET("/me/transfers", controllers.GetMyStoryTransfers)
+		protected.POST("/me/transfers/:id/accept", controllers.AcceptStoryTransfer)
+		protected.POST("/me/transfers/:id/decline", controllers.DeclineStoryTransfer)
+		protected.POST("/:username/follow", h.FollowUser)
+		protected.DELETE("/:username/follow", h.UnfollowUser)
 	}
 }
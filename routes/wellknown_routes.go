@@ -0,0 +1,11 @@
+package routes
+
+import (
+	"Truyen_BE/controllers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func WellKnownRoutes(router *gin.Engine) {
+	router.GET("/.well-known/jwks.json", controllers.GetJWKS)
+}
@@ -0,0 +1,114 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrMissingIDToken = errors.New("sso: token response không có id_token")
+	ErrNonceMismatch  = errors.New("sso: nonce không khớp")
+)
+
+// ProviderConfig: cấu hình một nhà cung cấp OpenID Connect
+type ProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string // ngoài "openid"
+}
+
+// Identity: thông tin đã xác thực lấy từ ID token
+type Identity struct {
+	Provider          string
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	Name              string
+	PreferredUsername string
+}
+
+// Provider: client authorization code + PKCE của một issuer
+type Provider struct {
+	Name     string
+	oauth2   oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// NewProvider đọc discovery document của issuer (/.well-known/openid-configuration)
+func NewProvider(ctx context.Context, cfg ProviderConfig) (*Provider, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("sso: discovery %s: %w", cfg.Name, err)
+	}
+
+	return &Provider{
+		Name: cfg.Name,
+		oauth2: oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     provider.Endpoint(),
+			Scopes:       append([]string{oidc.ScopeOpenID}, cfg.Scopes...),
+		},
+		// Verifier kiểm tra chữ ký theo JWKS của issuer, iss, aud = client_id và exp
+		verifier: provider.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}, nil
+}
+
+// AuthCodeURL: URL chuyển người dùng sang trang đăng nhập của provider.
+// codeVerifier là bí mật PKCE, chỉ gửi code_challenge (S256) đi.
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	return p.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(codeVerifier))
+}
+
+// Exchange đổi authorization code lấy token, xác thực ID token và nonce
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier, nonce string) (*Identity, error) {
+	token, err := p.oauth2.Exchange(ctx, code, oauth2.VerifierOption(codeVerifier))
+	if err != nil {
+		return nil, fmt.Errorf("sso: đổi code %s: %w", p.Name, err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, ErrMissingIDToken
+	}
+
+	idToken, err := p.verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, fmt.Errorf("sso: id_token %s: %w", p.Name, err)
+	}
+	if idToken.Nonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	var claims struct {
+		Email             string `json:"email"`
+		EmailVerified     bool   `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+	}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, fmt.Errorf("sso: đọc claims %s: %w", p.Name, err)
+	}
+
+	return &Identity{
+		Provider:          p.Name,
+		Subject:           idToken.Subject,
+		Email:             claims.Email,
+		EmailVerified:     claims.EmailVerified,
+		Name:              claims.Name,
+		PreferredUsername: claims.PreferredUsername,
+	}, nil
+}
+
+// GenerateCodeVerifier tạo bí mật PKCE mới cho mỗi lần đăng nhập
+func GenerateCodeVerifier() string {
+	return oauth2.GenerateVerifier()
+}
@@ -0,0 +1,332 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID     = "truyen-test"
+	testClientSecret = "secret"
+	testRedirectURL  = "http://localhost:3000/auth/callback"
+)
+
+// fakeIssuer: OIDC issuer tối giản chạy trên httptest (discovery, JWKS, token endpoint có PKCE)
+type fakeIssuer struct {
+	server     *httptest.Server
+	signingKey *rsa.PrivateKey
+	// tokenKey dùng để ký id_token, đổi sang key khác để giả lập chữ ký giả
+	tokenKey *rsa.PrivateKey
+	audience string
+
+	mu    sync.Mutex
+	codes map[string]pendingCode
+}
+
+type pendingCode struct {
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fakeIssuer{signingKey: key, tokenKey: key, audience: testClientID, codes: map[string]pendingCode{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{
+			"issuer":                                f.server.URL,
+			"authorization_endpoint":                f.server.URL + "/authorize",
+			"token_endpoint":                        f.server.URL + "/token",
+			"jwks_uri":                              f.server.URL + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		pub := f.signingKey.PublicKey
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test-key",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", f.handleToken)
+
+	f.server = httptest.NewServer(mux)
+	t.Cleanup(f.server.Close)
+	return f
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// authorize giả lập bước người dùng đăng nhập ở provider: đọc URL do client tạo và cấp code
+func (f *fakeIssuer) authorize(t *testing.T, authURL string, claims jwt.MapClaims) string {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("code_challenge_method") != "S256" {
+		t.Fatalf("code_challenge_method = %q, muốn S256", q.Get("code_challenge_method"))
+	}
+	code := "code-" + q.Get("state")
+	f.mu.Lock()
+	f.codes[code] = pendingCode{challenge: q.Get("code_challenge"), nonce: q.Get("nonce"), claims: claims}
+	f.mu.Unlock()
+	return code
+}
+
+func (f *fakeIssuer) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+	clientID, clientSecret, ok := r.BasicAuth()
+	if !ok {
+		clientID, clientSecret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != testClientID || clientSecret != testClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	f.mu.Lock()
+	pending, ok := f.codes[r.PostForm.Get("code")]
+	delete(f.codes, r.PostForm.Get("code"))
+	f.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != pending.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":   f.server.URL,
+		"aud":   f.audience,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"nonce": pending.nonce,
+	}
+	for k, v := range pending.claims {
+		claims[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test-key"
+	idToken, err := token.SignedString(f.tokenKey)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+func newTestProvider(t *testing.T, f *fakeIssuer) *Provider {
+	t.Helper()
+	p, err := NewProvider(context.Background(), ProviderConfig{
+		Name:         "fake",
+		IssuerURL:    f.server.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+		Scopes:       []string{"email", "profile"},
+	})
+	if err != nil {
+		t.Fatalf("NewProvider: %v", err)
+	}
+	return p
+}
+
+func userClaims() jwt.MapClaims {
+	return jwt.MapClaims{
+		"sub":                "user-123",
+		"email":              "Reader@Example.com",
+		"email_verified":     true,
+		"name":               "Người Đọc",
+		"preferred_username": "reader",
+	}
+}
+
+func TestAuthCodeURLUsesPKCEAndNonce(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestProvider(t, f)
+
+	verifier := GenerateCodeVerifier()
+	u, err := url.Parse(p.AuthCodeURL("state-1", "nonce-1", verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+
+	sum := sha256.Sum256([]byte(verifier))
+	if got, want := q.Get("code_challenge"), base64.RawURLEncoding.EncodeToString(sum[:]); got != want {
+		t.Errorf("code_challenge = %q, muốn %q", got, want)
+	}
+	if q.Get("code_verifier") != "" {
+		t.Error("code_verifier không được lộ trên URL")
+	}
+	if q.Get("state") != "state-1" || q.Get("nonce") != "nonce-1" {
+		t.Errorf("state/nonce sai: %v", q)
+	}
+	if q.Get("scope") != "openid email profile" {
+		t.Errorf("scope = %q", q.Get("scope"))
+	}
+	if q.Get("redirect_uri") != testRedirectURL || q.Get("client_id") != testClientID {
+		t.Errorf("redirect_uri/client_id sai: %v", q)
+	}
+}
+
+func TestExchangeReturnsVerifiedIdentity(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestProvider(t, f)
+
+	verifier := GenerateCodeVerifier()
+	code := f.authorize(t, p.AuthCodeURL("s", "n", verifier), userClaims())
+
+	identity, err := p.Exchange(context.Background(), code, verifier, "n")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Identity{
+		Provider:          "fake",
+		Subject:           "user-123",
+		Email:             "Reader@Example.com",
+		EmailVerified:     true,
+		Name:              "Người Đọc",
+		PreferredUsername: "reader",
+	}
+	if *identity != want {
+		t.Errorf("identity = %+v, muốn %+v", *identity, want)
+	}
+}
+
+func TestExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestProvider(t, f)
+
+	code := f.authorize(t, p.AuthCodeURL("s", "n", GenerateCodeVerifier()), userClaims())
+	if _, err := p.Exchange(context.Background(), code, GenerateCodeVerifier(), "n"); err == nil {
+		t.Fatal("Exchange với code_verifier sai phải lỗi")
+	}
+}
+
+func TestExchangeRejectsNonceMismatch(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestProvider(t, f)
+
+	verifier := GenerateCodeVerifier()
+	code := f.authorize(t, p.AuthCodeURL("s", "nonce-goc", verifier), userClaims())
+	_, err := p.Exchange(context.Background(), code, verifier, "nonce-khac")
+	if !errors.Is(err, ErrNonceMismatch) {
+		t.Fatalf("err = %v, muốn ErrNonceMismatch", err)
+	}
+}
+
+func TestExchangeRejectsTokenNotSignedByIssuer(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestProvider(t, f)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.tokenKey = otherKey
+
+	verifier := GenerateCodeVerifier()
+	code := f.authorize(t, p.AuthCodeURL("s", "n", verifier), userClaims())
+	if _, err := p.Exchange(context.Background(), code, verifier, "n"); err == nil {
+		t.Fatal("id_token ký bằng key lạ phải bị từ chối")
+	}
+}
+
+func TestExchangeRejectsWrongAudience(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestProvider(t, f)
+	f.audience = "ung-dung-khac"
+
+	verifier := GenerateCodeVerifier()
+	code := f.authorize(t, p.AuthCodeURL("s", "n", verifier), userClaims())
+	if _, err := p.Exchange(context.Background(), code, verifier, "n"); err == nil {
+		t.Fatal("id_token cấp cho client khác phải bị từ chối")
+	}
+}
+
+func TestExchangeRejectsExpiredToken(t *testing.T) {
+	f := newFakeIssuer(t)
+	p := newTestProvider(t, f)
+
+	claims := userClaims()
+	claims["exp"] = time.Now().Add(-time.Hour).Unix()
+
+	verifier := GenerateCodeVerifier()
+	code := f.authorize(t, p.AuthCodeURL("s", "n", verifier), claims)
+	if _, err := p.Exchange(context.Background(), code, verifier, "n"); err == nil {
+		t.Fatal("id_token hết hạn phải bị từ chối")
+	}
+}
+
+func TestLoadConfigsFromEnv(t *testing.T) {
+	t.Setenv("OIDC_PROVIDERS", " Google , keycloak,")
+	t.Setenv("OIDC_GOOGLE_ISSUER_URL", "https://accounts.google.com")
+	t.Setenv("OIDC_GOOGLE_CLIENT_ID", "gid")
+	t.Setenv("OIDC_GOOGLE_CLIENT_SECRET", "gsecret")
+	t.Setenv("OIDC_GOOGLE_REDIRECT_URL", "https://truyen.example/auth/google")
+	t.Setenv("OIDC_KEYCLOAK_ISSUER_URL", "https://sso.example/realms/truyen")
+	t.Setenv("OIDC_KEYCLOAK_SCOPES", "email, groups")
+
+	configs := LoadConfigsFromEnv()
+	if len(configs) != 2 {
+		t.Fatalf("len(configs) = %d, muốn 2", len(configs))
+	}
+	google := configs[0]
+	if google.Name != "google" || google.ClientID != "gid" || google.ClientSecret != "gsecret" ||
+		google.RedirectURL != "https://truyen.example/auth/google" {
+		t.Errorf("google = %+v", google)
+	}
+	if len(google.Scopes) != 2 || google.Scopes[0] != "email" || google.Scopes[1] != "profile" {
+		t.Errorf("scope mặc định = %v", google.Scopes)
+	}
+	if keycloak := configs[1]; len(keycloak.Scopes) != 2 || keycloak.Scopes[1] != "groups" {
+		t.Errorf("keycloak scopes = %v", keycloak.Scopes)
+	}
+}
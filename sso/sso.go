@@ -0,0 +1,98 @@
+package sso
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu        sync.RWMutex
+	providers = map[string]*Provider{}
+)
+
+// LoadConfigsFromEnv đọc danh sách provider từ env:
+//
+//	OIDC_PROVIDERS=google,keycloak
+//	OIDC_GOOGLE_ISSUER_URL, OIDC_GOOGLE_CLIENT_ID, OIDC_GOOGLE_CLIENT_SECRET,
+//	OIDC_GOOGLE_REDIRECT_URL, OIDC_GOOGLE_SCOPES (mặc định "email,profile")
+func LoadConfigsFromEnv() []ProviderConfig {
+	var configs []ProviderConfig
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+
+		scopes := []string{"email", "profile"}
+		if raw := os.Getenv(prefix + "SCOPES"); raw != "" {
+			scopes = nil
+			for _, scope := range strings.Split(raw, ",") {
+				if scope = strings.TrimSpace(scope); scope != "" {
+					scopes = append(scopes, scope)
+				}
+			}
+		}
+
+		configs = append(configs, ProviderConfig{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER_URL"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       scopes,
+		})
+	}
+	return configs
+}
+
+// Init khởi tạo các provider cấu hình trong env. Provider lỗi (thiếu cấu hình,
+// issuer không phản hồi) bị bỏ qua để không chặn đăng nhập bằng mật khẩu.
+func Init() {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	for _, cfg := range LoadConfigsFromEnv() {
+		if cfg.IssuerURL == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+			log.Printf("⚠️ Bỏ qua OIDC provider %s: thiếu ISSUER_URL, CLIENT_ID hoặc REDIRECT_URL", cfg.Name)
+			continue
+		}
+		provider, err := NewProvider(ctx, cfg)
+		if err != nil {
+			log.Printf("⚠️ Bỏ qua OIDC provider %s: %v", cfg.Name, err)
+			continue
+		}
+		Register(provider)
+		log.Printf("✅ Đã bật đăng nhập qua %s", cfg.Name)
+	}
+}
+
+func Register(p *Provider) {
+	mu.Lock()
+	defer mu.Unlock()
+	providers[p.Name] = p
+}
+
+func Get(name string) (*Provider, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	p, ok := providers[name]
+	return p, ok
+}
+
+// Names trả về tên các provider đang bật (để frontend hiển thị nút đăng nhập)
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
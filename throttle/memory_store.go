@@ -0,0 +1,76 @@
+package throttle
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type memoryEntry struct {
+	record    Record
+	expiresAt time.Time
+}
+
+// MemoryStore lưu bộ đếm trong RAM, mất khi restart
+type MemoryStore struct {
+	mu      sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string, now time.Time) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		delete(s.entries, key)
+		return Record{}, nil
+	}
+	return entry.record, nil
+}
+
+func (s *MemoryStore) RecordFailure(_ context.Context, key string, now time.Time, window time.Duration) (Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || now.After(entry.expiresAt) {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	entry.record.Failures++
+	entry.expiresAt = now.Add(window)
+
+	// Dọn các entry hết hạn để map không phình mãi
+	for k, e := range s.entries {
+		if now.After(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	return entry.record, nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok {
+		entry.record.LockedUntil = until
+		if until.After(entry.expiresAt) {
+			entry.expiresAt = until
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.entries, key)
+	return nil
+}
@@ -0,0 +1,114 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	const window = time.Minute
+
+	for _, tc := range []struct {
+		name  string
+		setup func(s *MemoryStore)
+		at    time.Duration // thời điểm Get, tính từ start
+		want  Record
+	}{
+		{
+			name:  "key chưa có",
+			setup: func(s *MemoryStore) {},
+			want:  Record{},
+		},
+		{
+			name: "đếm dồn trong window",
+			setup: func(s *MemoryStore) {
+				s.RecordFailure(ctx, "k", start, window)
+				s.RecordFailure(ctx, "k", start.Add(50*time.Second), window)
+			},
+			// window tính từ lần sai cuối nên vẫn còn sau 1 phút kể từ lần đầu
+			at:   90 * time.Second,
+			want: Record{Failures: 2},
+		},
+		{
+			name: "hết window thì mất",
+			setup: func(s *MemoryStore) {
+				s.RecordFailure(ctx, "k", start, window)
+			},
+			at:   window + time.Second,
+			want: Record{},
+		},
+		{
+			name: "sai lại sau window đếm từ đầu",
+			setup: func(s *MemoryStore) {
+				s.RecordFailure(ctx, "k", start, window)
+				s.RecordFailure(ctx, "k", start.Add(2*window), window)
+			},
+			at:   2 * window,
+			want: Record{Failures: 1},
+		},
+		{
+			name: "khoá dài hơn window giữ entry tới hết khoá",
+			setup: func(s *MemoryStore) {
+				s.RecordFailure(ctx, "k", start, window)
+				s.Lock(ctx, "k", start.Add(10*time.Minute))
+			},
+			at:   5 * time.Minute,
+			want: Record{Failures: 1, LockedUntil: start.Add(10 * time.Minute)},
+		},
+		{
+			name: "khoá hết hạn thì mất",
+			setup: func(s *MemoryStore) {
+				s.RecordFailure(ctx, "k", start, window)
+				s.Lock(ctx, "k", start.Add(10*time.Minute))
+			},
+			at:   10*time.Minute + time.Second,
+			want: Record{},
+		},
+		{
+			name: "Lock key chưa có thì bỏ qua",
+			setup: func(s *MemoryStore) {
+				s.Lock(ctx, "k", start.Add(10*time.Minute))
+			},
+			want: Record{},
+		},
+		{
+			name: "Reset xoá bộ đếm",
+			setup: func(s *MemoryStore) {
+				s.RecordFailure(ctx, "k", start, window)
+				s.Lock(ctx, "k", start.Add(10*time.Minute))
+				s.Reset(ctx, "k")
+			},
+			want: Record{},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			tc.setup(store)
+			got, err := store.Get(ctx, "k", start.Add(tc.at))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("Get = %+v, muốn %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreDropsExpiredEntries(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+
+	store.RecordFailure(ctx, "cũ", start, time.Minute)
+	store.RecordFailure(ctx, "mới", start.Add(2*time.Minute), time.Minute)
+	if _, ok := store.entries["cũ"]; ok {
+		t.Error("entry hết hạn phải được dọn khi ghi lần sai mới")
+	}
+	if _, ok := store.entries["mới"]; !ok {
+		t.Error("entry còn hạn bị xoá nhầm")
+	}
+}
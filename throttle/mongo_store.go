@@ -0,0 +1,73 @@
+package throttle
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoStore lưu bộ đếm trong collection LoginAttempts (TTL index trên expires_at)
+// để nhiều instance cùng chia sẻ trạng thái
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoStore(collection *mongo.Collection) *MongoStore {
+	return &MongoStore{collection: collection}
+}
+
+type mongoAttempt struct {
+	Key         string    `bson:"_id"`
+	Failures    int       `bson:"failures"`
+	LockedUntil time.Time `bson:"locked_until,omitempty"`
+	ExpiresAt   time.Time `bson:"expires_at"`
+}
+
+func (s *MongoStore) Get(ctx context.Context, key string, now time.Time) (Record, error) {
+	var attempt mongoAttempt
+	// TTL monitor của MongoDB chạy mỗi ~60s nên vẫn phải tự lọc document đã hết hạn
+	err := s.collection.FindOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$gt": now}}).Decode(&attempt)
+	if err == mongo.ErrNoDocuments {
+		return Record{}, nil
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Failures: attempt.Failures, LockedUntil: attempt.LockedUntil}, nil
+}
+
+func (s *MongoStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Record, error) {
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": key, "expires_at": bson.M{"$lte": now}}); err != nil {
+		return Record{}, err
+	}
+
+	var attempt mongoAttempt
+	err := s.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": key},
+		bson.M{
+			"$inc": bson.M{"failures": 1},
+			"$max": bson.M{"expires_at": now.Add(window)},
+		},
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&attempt)
+	if err != nil {
+		return Record{}, err
+	}
+	return Record{Failures: attempt.Failures, LockedUntil: attempt.LockedUntil}, nil
+}
+
+func (s *MongoStore) Lock(ctx context.Context, key string, until time.Time) error {
+	_, err := s.collection.UpdateOne(ctx,
+		bson.M{"_id": key},
+		bson.M{"$max": bson.M{"locked_until": until, "expires_at": until}},
+	)
+	return err
+}
+
+func (s *MongoStore) Reset(ctx context.Context, key string) error {
+	_, err := s.collection.DeleteOne(ctx, bson.M{"_id": key})
+	return err
+}
@@ -0,0 +1,130 @@
+package throttle
+
+import (
+	"Truyen_BE/config"
+	"context"
+	"log"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Record: trạng thái đăng nhập sai của một key (tài khoản hoặc IP)
+type Record struct {
+	Failures    int
+	LockedUntil time.Time
+}
+
+// Store: nơi lưu bộ đếm. Bản in-memory cho 1 instance/dev, bản MongoDB để nhiều instance dùng chung.
+type Store interface {
+	Get(ctx context.Context, key string, now time.Time) (Record, error)
+	// RecordFailure tăng bộ đếm; bộ đếm tự hết hạn sau window kể từ lần sai cuối
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (Record, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Guard áp dụng chính sách: cho phép một số lần sai miễn phí, sau đó khoá tạm với thời gian tăng gấp đôi
+type Guard struct {
+	Store              Store
+	MaxAccountFailures int              // số lần sai miễn phí cho mỗi tài khoản
+	MaxIPFailures      int              // số lần sai miễn phí cho mỗi IP (nhiều tài khoản)
+	BaseDelay          time.Duration    // thời gian khoá lần đầu
+	MaxDelay           time.Duration    // trần thời gian khoá
+	Window             time.Duration    // bộ đếm reset nếu không sai thêm trong khoảng này
+	Now                func() time.Time // đồng hồ, test thay để không phải chờ thật
+}
+
+func NewGuard(store Store) *Guard {
+	return &Guard{
+		Store:              store,
+		MaxAccountFailures: 5,
+		MaxIPFailures:      20,
+		BaseDelay:          30 * time.Second,
+		MaxDelay:           15 * time.Minute,
+		Window:             time.Hour,
+		Now:                time.Now,
+	}
+}
+
+// Default dùng chung cho controllers, được khởi tạo trong Init()
+var Default = NewGuard(NewMemoryStore())
+
+// Init chọn store theo LOGIN_THROTTLE_STORE: "mongo" (mặc định) hoặc "memory"
+func Init(collection *mongo.Collection) {
+	if config.App.Auth.LoginThrottleStore == "memory" {
+		Default = NewGuard(NewMemoryStore())
+		log.Println("⚠️ Chống brute-force: lưu bộ đếm trong bộ nhớ (chỉ đúng khi chạy 1 instance)")
+		return
+	}
+	Default = NewGuard(NewMongoStore(collection))
+}
+
+func AccountKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
+
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Check trả về thời gian phải chờ (> 0 nghĩa là đang bị khoá)
+func (g *Guard) Check(ctx context.Context, username, ip string) (time.Duration, error) {
+	now := g.Now()
+	var wait time.Duration
+	for _, key := range []string{AccountKey(username), IPKey(ip)} {
+		record, err := g.Store.Get(ctx, key, now)
+		if err != nil {
+			return 0, err
+		}
+		if d := record.LockedUntil.Sub(now); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// Fail ghi nhận một lần sai, khoá tạm nếu vượt ngưỡng; trả về thời gian bị khoá (nếu có)
+func (g *Guard) Fail(ctx context.Context, username, ip string) (time.Duration, error) {
+	now := g.Now()
+	var wait time.Duration
+	limits := map[string]int{
+		AccountKey(username): g.MaxAccountFailures,
+		IPKey(ip):            g.MaxIPFailures,
+	}
+	for key, free := range limits {
+		record, err := g.Store.RecordFailure(ctx, key, now, g.Window)
+		if err != nil {
+			return 0, err
+		}
+		if record.Failures < free {
+			continue
+		}
+		delay := g.delay(record.Failures - free)
+		if err := g.Store.Lock(ctx, key, now.Add(delay)); err != nil {
+			return 0, err
+		}
+		if delay > wait {
+			wait = delay
+		}
+	}
+	return wait, nil
+}
+
+// Succeed xoá bộ đếm của tài khoản (bộ đếm IP giữ nguyên để chặn dò nhiều tài khoản)
+func (g *Guard) Succeed(ctx context.Context, username string) error {
+	return g.Store.Reset(ctx, AccountKey(username))
+}
+
+// delay = BaseDelay * 2^n, tối đa MaxDelay
+func (g *Guard) delay(n int) time.Duration {
+	d := g.BaseDelay
+	for i := 0; i < n && d < g.MaxDelay; i++ {
+		d *= 2
+	}
+	if d > g.MaxDelay {
+		d = g.MaxDelay
+	}
+	return d
+}
@@ -0,0 +1,122 @@
+package throttle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock: đồng hồ test tự tua, không phải chờ thật
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestGuard() (*Guard, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+	guard := NewGuard(NewMemoryStore())
+	guard.Now = clock.Now
+	return guard, clock
+}
+
+func TestGuardFailBackoff(t *testing.T) {
+	ctx := context.Background()
+	guard, _ := newTestGuard()
+
+	// 5 lần sai miễn phí, sau đó khoá 30s và gấp đôi mỗi lần, trần 15 phút
+	for i, want := range []time.Duration{
+		0, 0, 0, 0,
+		30 * time.Second,
+		time.Minute,
+		2 * time.Minute,
+		4 * time.Minute,
+		8 * time.Minute,
+		15 * time.Minute,
+		15 * time.Minute,
+	} {
+		wait, err := guard.Fail(ctx, "Alice", "1.2.3.4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if wait != want {
+			t.Errorf("lần sai thứ %d: wait = %v, muốn %v", i+1, wait, want)
+		}
+		check, err := guard.Check(ctx, "alice", "1.2.3.4")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if check != want {
+			t.Errorf("Check sau lần sai thứ %d = %v, muốn %v", i+1, check, want)
+		}
+	}
+}
+
+func TestGuardLockoutExpiry(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		name     string
+		advance  time.Duration
+		wantWait time.Duration
+		nextFail time.Duration // thời gian khoá của lần sai kế tiếp
+	}{
+		{"vẫn đang khoá", 10 * time.Second, 20 * time.Second, time.Minute},
+		{"vừa hết khoá", 30 * time.Second, 0, time.Minute},
+		{"hết khoá, bộ đếm còn trong window", 59 * time.Minute, 0, time.Minute},
+		{"quá window, bộ đếm reset", time.Hour + time.Second, 0, 0},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			guard, clock := newTestGuard()
+			for i := 0; i < guard.MaxAccountFailures; i++ {
+				if _, err := guard.Fail(ctx, "alice", "1.2.3.4"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			clock.Advance(tc.advance)
+			wait, err := guard.Check(ctx, "alice", "1.2.3.4")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wait != tc.wantWait {
+				t.Errorf("Check = %v, muốn %v", wait, tc.wantWait)
+			}
+
+			wait, err = guard.Fail(ctx, "alice", "1.2.3.4")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wait != tc.nextFail {
+				t.Errorf("Fail kế tiếp = %v, muốn %v", wait, tc.nextFail)
+			}
+		})
+	}
+}
+
+func TestGuardIPLimitAndSucceed(t *testing.T) {
+	ctx := context.Background()
+	guard, _ := newTestGuard()
+	guard.MaxIPFailures = 3
+
+	// Dò nhiều tài khoản từ một IP: mỗi tài khoản chưa tới ngưỡng nhưng IP bị khoá
+	for _, username := range []string{"a", "b", "c"} {
+		if _, err := guard.Fail(ctx, username, "1.2.3.4"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if wait, _ := guard.Check(ctx, "d", "1.2.3.4"); wait != 30*time.Second {
+		t.Errorf("tài khoản khác cùng IP: Check = %v, muốn 30s", wait)
+	}
+	if wait, _ := guard.Check(ctx, "a", "5.6.7.8"); wait != 0 {
+		t.Errorf("IP khác: Check = %v, muốn 0", wait)
+	}
+
+	// Đăng nhập đúng chỉ xoá bộ đếm tài khoản, IP vẫn bị khoá
+	if err := guard.Succeed(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if wait, _ := guard.Check(ctx, "a", "1.2.3.4"); wait != 30*time.Second {
+		t.Errorf("sau Succeed: Check = %v, muốn 30s", wait)
+	}
+}
@@ -0,0 +1,21 @@
+package utils
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// NormalizeEmail chuẩn hoá email (trim + lowercase), trả về false nếu sai cú pháp.
+// Chỉ chấp nhận địa chỉ trần "a@b.c", không nhận dạng "Tên <a@b.c>".
+func NormalizeEmail(email string) (string, bool) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email || addr.Name != "" {
+		return "", false
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 1 || !strings.Contains(email[at+1:], ".") {
+		return "", false
+	}
+	return email, true
+}
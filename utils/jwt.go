@@ -1,21 +1,91 @@
 package utils
 
 import (
-	"os"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(userID string, role string) (string, error) {
-	jwtSecret := []byte(os.Getenv("JWT_SECRET"))
+const (
+	AccessTokenTTL  = 15 * time.Minute    // access token sống ngắn
+	RefreshTokenTTL = 30 * 24 * time.Hour // refresh token gia hạn mỗi lần xoay vòng
+)
 
+func GenerateToken(userID string, role string, sessionID string) (string, error) {
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"user_id": userID,
 		"role":    role,
-		"exp":     time.Now().Add(24 * time.Hour).Unix(),
+		"sid":     sessionID,
+		"iat":     now.Unix(),
+		"exp":     now.Add(AccessTokenTTL).Unix(),
+	}
+
+	return signJWT(claims)
+}
+
+// ParseAccessToken kiểm tra chữ ký, hạn dùng và trả claims của access token
+func ParseAccessToken(tokenString string) (jwt.MapClaims, error) {
+	claims, err := parseJWT(tokenString)
+	if err != nil {
+		return nil, err
+	}
+	// Token tạm của bước 2FA không được dùng như access token
+	if claims["typ"] == "mfa_pending" {
+		return nil, ErrMFAPending
 	}
+	return claims, nil
+}
+
+// GenerateRandomToken tạo chuỗi ngẫu nhiên (opaque) dùng cho refresh token, link đặt lại mật khẩu...
+func GenerateRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken băm token trước khi lưu DB để lộ DB cũng không dùng lại được
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+const MFATokenTTL = 5 * time.Minute
+
+var ErrMFAPending = errors.New("chưa hoàn tất xác thực 2 bước")
+
+// GenerateMFAToken: token tạm sau khi đúng mật khẩu, chỉ dùng để đổi lấy token thật ở bước 2FA
+func GenerateMFAToken(userID string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"user_id": userID,
+		"typ":     "mfa_pending",
+		"iat":     now.Unix(),
+		"exp":     now.Add(MFATokenTTL).Unix(),
+	}
+
+	return signJWT(claims)
+}
+
+// ParseMFAToken kiểm tra token "mfa pending" và trả về user_id
+func ParseMFAToken(tokenString string) (string, error) {
+	claims, err := parseJWT(tokenString)
+	if err != nil {
+		return "", err
+	}
+	if claims["typ"] != "mfa_pending" {
+		return "", errors.New("không phải token 2FA")
+	}
+	userID, ok := claims["user_id"].(string)
+	if !ok {
+		return "", errors.New("token thiếu user_id")
+	}
+	return userID, nil
 }
@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"Truyen_BE/config"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const minRSAKeyBits = 2048
+
+// jwtKey: một khoá ký/kiểm tra token. kid là JWK thumbprint (RFC 7638) của public key,
+// nên cùng một file khoá luôn cho cùng kid trên mọi instance.
+type jwtKey struct {
+	ID      string
+	Method  jwt.SigningMethod
+	Private crypto.Signer // nil nếu khoá chỉ dùng để kiểm tra (khoá cũ khi xoay vòng)
+	Public  crypto.PublicKey
+}
+
+var (
+	keysMu     sync.RWMutex
+	signingKey *jwtKey
+	verifyKeys = map[string]*jwtKey{}
+)
+
+// LoadJWTKeys nạp khoá ký theo cấu hình:
+//
+//	JWT_SIGNING_KEY_FILE  private key PEM (RSA ≥ 2048 bit → RS256, Ed25519 → EdDSA)
+//	JWT_VERIFY_KEY_FILES  danh sách file (cách nhau bởi dấu phẩy) chứa khoá cũ vẫn được chấp nhận
+//
+// Xoay khoá: đưa khoá mới vào JWT_SIGNING_KEY_FILE, chuyển khoá cũ sang JWT_VERIFY_KEY_FILES,
+// đợi hết AccessTokenTTL rồi mới gỡ khoá cũ → không ai bị đăng xuất.
+// Không cấu hình khoá thì sinh khoá Ed25519 tạm (chỉ dùng khi dev, token mất hiệu lực khi khởi động lại).
+func LoadJWTKeys(cfg config.JWTConfig) error {
+	var current *jwtKey
+	if path := cfg.SigningKeyFile; path != "" {
+		key, err := loadJWTKeyFile(path)
+		if err != nil {
+			return err
+		}
+		if key.Private == nil {
+			return fmt.Errorf("JWT_SIGNING_KEY_FILE %s: cần private key", path)
+		}
+		current = key
+	} else {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return err
+		}
+		current, err = newJWTKey(private.Public(), private)
+		if err != nil {
+			return err
+		}
+		log.Println("⚠️ Chưa cấu hình JWT_SIGNING_KEY_FILE, dùng khoá ký tạm thời (không dùng cho production)")
+	}
+
+	keys := map[string]*jwtKey{current.ID: current}
+	for _, path := range cfg.VerifyKeyFiles {
+		key, err := loadJWTKeyFile(path)
+		if err != nil {
+			return err
+		}
+		keys[key.ID] = key
+	}
+
+	keysMu.Lock()
+	defer keysMu.Unlock()
+	signingKey = current
+	verifyKeys = keys
+	log.Printf("✅ Đã nạp %d khoá JWT, khoá ký hiện tại kid=%s (%s)", len(keys), current.ID, current.Method.Alg())
+	return nil
+}
+
+func loadJWTKeyFile(path string) (*jwtKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("đọc khoá JWT %s: %w", path, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("khoá JWT %s: không phải PEM", path)
+	}
+
+	var parsed any
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("khoá JWT %s: không hỗ trợ PEM %q", path, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("khoá JWT %s: %w", path, err)
+	}
+
+	var key *jwtKey
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		key, err = newJWTKey(&k.PublicKey, k)
+	case ed25519.PrivateKey:
+		key, err = newJWTKey(k.Public(), k)
+	default:
+		key, err = newJWTKey(k, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("khoá JWT %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// newJWTKey chọn thuật toán theo loại khoá: mỗi khoá chỉ được dùng với đúng một thuật toán
+func newJWTKey(public crypto.PublicKey, private crypto.Signer) (*jwtKey, error) {
+	key := &jwtKey{Public: public, Private: private}
+	switch pub := public.(type) {
+	case *rsa.PublicKey:
+		if pub.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("khoá RSA phải có ít nhất %d bit", minRSAKeyBits)
+		}
+		key.Method = jwt.SigningMethodRS256
+	case ed25519.PublicKey:
+		key.Method = jwt.SigningMethodEdDSA
+	default:
+		return nil, errors.New("chỉ hỗ trợ khoá RSA hoặc Ed25519")
+	}
+
+	thumbprintInput, err := json.Marshal(publicJWK(key, true))
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(thumbprintInput)
+	key.ID = base64.RawURLEncoding.EncodeToString(sum[:])
+	return key, nil
+}
+
+// publicJWK: public key dạng JWK. thumbprintOnly chỉ giữ các trường bắt buộc
+// (encoding/json sắp key theo thứ tự chữ cái, đúng yêu cầu của RFC 7638).
+func publicJWK(key *jwtKey, thumbprintOnly bool) map[string]string {
+	jwk := map[string]string{}
+	switch pub := key.Public.(type) {
+	case *rsa.PublicKey:
+		jwk["kty"] = "RSA"
+		jwk["n"] = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk["kty"] = "OKP"
+		jwk["crv"] = "Ed25519"
+		jwk["x"] = base64.RawURLEncoding.EncodeToString(pub)
+	}
+	if !thumbprintOnly {
+		jwk["kid"] = key.ID
+		jwk["alg"] = key.Method.Alg()
+		jwk["use"] = "sig"
+	}
+	return jwk
+}
+
+// JWKS trả về public key của mọi khoá đang được chấp nhận (cho GET /.well-known/jwks.json)
+func JWKS() map[string]any {
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+
+	ids := make([]string, 0, len(verifyKeys))
+	for id := range verifyKeys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	keys := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, publicJWK(verifyKeys[id], false))
+	}
+	return map[string]any{"keys": keys}
+}
+
+// signJWT ký claims bằng khoá hiện tại, kèm kid trong header
+func signJWT(claims jwt.MapClaims) (string, error) {
+	keysMu.RLock()
+	key := signingKey
+	keysMu.RUnlock()
+	if key == nil {
+		return "", errors.New("chưa nạp khoá JWT")
+	}
+
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Private)
+}
+
+// parseJWT kiểm tra chữ ký theo kid. Thuật toán bị ghim theo khoá: token khai báo
+// alg khác với alg của khoá (vd. HS256 dùng public key làm secret, hay "none") đều bị từ chối.
+func parseJWT(tokenString string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		keysMu.RLock()
+		key, ok := verifyKeys[kid]
+		keysMu.RUnlock()
+		if !ok {
+			return nil, errors.New("kid không xác định")
+		}
+		if t.Method.Alg() != key.Method.Alg() {
+			return nil, errors.New("thuật toán không khớp với khoá")
+		}
+		return key.Public, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil || !token.Valid {
+		return nil, errors.New("token không hợp lệ")
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("token lỗi claims")
+	}
+	return claims, nil
+}
@@ -0,0 +1,218 @@
+package utils
+
+import (
+	"Truyen_BE/config"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Các test ở đây thay khoá JWT toàn cục nên không chạy song song
+
+func generateRSAKey(t *testing.T, bits int) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, bits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// writePEM ghi private key (PKCS#8) hoặc public key (PKIX) ra file PEM trong thư mục tạm
+func writePEM(t *testing.T, name string, key any) string {
+	t.Helper()
+	var block *pem.Block
+	switch k := key.(type) {
+	case *rsa.PublicKey, ed25519.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PUBLIC KEY", Bytes: der}
+	default:
+		der, err := x509.MarshalPKCS8PrivateKey(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block = &pem.Block{Type: "PRIVATE KEY", Bytes: der}
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(block), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func loadKeys(t *testing.T, cfg config.JWTConfig) {
+	t.Helper()
+	if err := LoadJWTKeys(cfg); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testClaims() jwt.MapClaims {
+	return jwt.MapClaims{"user_id": "u1", "exp": time.Now().Add(time.Minute).Unix()}
+}
+
+func currentKeyID(t *testing.T) string {
+	t.Helper()
+	keysMu.RLock()
+	defer keysMu.RUnlock()
+	if signingKey == nil {
+		t.Fatal("chưa nạp khoá ký")
+	}
+	return signingKey.ID
+}
+
+func TestParseJWTPinsAlgorithmToKey(t *testing.T) {
+	rsaKey := generateRSAKey(t, 2048)
+	loadKeys(t, config.JWTConfig{SigningKeyFile: writePEM(t, "signing.pem", rsaKey)})
+	kid := currentKeyID(t)
+
+	valid, err := signJWT(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseJWT(valid); err != nil {
+		t.Fatalf("token hợp lệ bị từ chối: %v", err)
+	}
+
+	sign := func(method jwt.SigningMethod, kid string, claims jwt.MapClaims, key any) string {
+		t.Helper()
+		token := jwt.NewWithClaims(method, claims)
+		token.Header["kid"] = kid
+		signed, err := token.SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+
+	// HS256 lấy chính public key (dạng PEM công khai) làm secret
+	publicPEM, err := os.ReadFile(writePEM(t, "public.pem", &rsaKey.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, otherEd, _ := ed25519.GenerateKey(rand.Reader)
+	expired := testClaims()
+	expired["exp"] = time.Now().Add(-time.Minute).Unix()
+	noExp := jwt.MapClaims{"user_id": "u1"}
+
+	cases := map[string]string{
+		"HS256 ký bằng public key":   sign(jwt.SigningMethodHS256, kid, testClaims(), publicPEM),
+		"alg none":                   sign(jwt.SigningMethodNone, kid, testClaims(), jwt.UnsafeAllowNoneSignatureType),
+		"EdDSA với kid của khoá RSA": sign(jwt.SigningMethodEdDSA, kid, testClaims(), otherEd),
+		"kid không xác định":         sign(jwt.SigningMethodRS256, "khong-ton-tai", testClaims(), rsaKey),
+		"thiếu kid":                  sign(jwt.SigningMethodRS256, "", testClaims(), rsaKey),
+		"khoá RSA lạ":                sign(jwt.SigningMethodRS256, kid, testClaims(), generateRSAKey(t, 2048)),
+		"hết hạn":                    sign(jwt.SigningMethodRS256, kid, expired, rsaKey),
+		"không có exp":               sign(jwt.SigningMethodRS256, kid, noExp, rsaKey),
+	}
+	for name, token := range cases {
+		if _, err := parseJWT(token); err == nil {
+			t.Errorf("%s: token phải bị từ chối", name)
+		}
+	}
+}
+
+func TestRotatedKeyStillVerifies(t *testing.T) {
+	oldKey := generateRSAKey(t, 2048)
+	loadKeys(t, config.JWTConfig{SigningKeyFile: writePEM(t, "old.pem", oldKey)})
+	oldKid := currentKeyID(t)
+	oldToken, err := signJWT(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Xoay khoá: khoá mới ký, khoá cũ (chỉ public key) chuyển sang JWT_VERIFY_KEY_FILES
+	_, newKey, _ := ed25519.GenerateKey(rand.Reader)
+	rotated := config.JWTConfig{
+		SigningKeyFile: writePEM(t, "new.pem", newKey),
+		VerifyKeyFiles: []string{writePEM(t, "old.pub.pem", &oldKey.PublicKey)},
+	}
+	loadKeys(t, rotated)
+	if currentKeyID(t) == oldKid {
+		t.Fatal("khoá ký phải là khoá mới")
+	}
+	if _, err := parseJWT(oldToken); err != nil {
+		t.Fatalf("token ký bằng khoá cũ phải còn hợp lệ: %v", err)
+	}
+	newToken, err := signJWT(testClaims())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseJWT(newToken); err != nil {
+		t.Fatalf("token ký bằng khoá mới bị từ chối: %v", err)
+	}
+
+	// Gỡ khoá cũ → token cũ hết hiệu lực
+	rotated.VerifyKeyFiles = nil
+	loadKeys(t, rotated)
+	if _, err := parseJWT(oldToken); err == nil {
+		t.Fatal("token ký bằng khoá đã gỡ phải bị từ chối")
+	}
+}
+
+func TestJWKSListsEveryAcceptedKey(t *testing.T) {
+	rsaKey := generateRSAKey(t, 2048)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	loadKeys(t, config.JWTConfig{
+		SigningKeyFile: writePEM(t, "signing.pem", rsaKey),
+		VerifyKeyFiles: []string{writePEM(t, "ed.pem", edKey)},
+	})
+	signingKid := currentKeyID(t)
+
+	keys := JWKS()["keys"].([]map[string]string)
+	if len(keys) != 2 {
+		t.Fatalf("JWKS có %d khoá, muốn 2", len(keys))
+	}
+	algs := map[string]map[string]string{}
+	for _, jwk := range keys {
+		if jwk["kid"] == "" || jwk["use"] != "sig" {
+			t.Errorf("JWK thiếu kid/use: %v", jwk)
+		}
+		algs[jwk["alg"]] = jwk
+	}
+
+	rs := algs["RS256"]
+	if rs == nil || rs["kty"] != "RSA" || rs["n"] == "" || rs["e"] == "" || rs["kid"] != signingKid {
+		t.Errorf("JWK RS256 sai: %v", rs)
+	}
+	ed := algs["EdDSA"]
+	if ed == nil || ed["kty"] != "OKP" || ed["crv"] != "Ed25519" || ed["x"] == "" {
+		t.Errorf("JWK EdDSA sai: %v", ed)
+	}
+	for _, jwk := range keys {
+		for _, private := range []string{"d", "p", "q"} {
+			if _, leaked := jwk[private]; leaked {
+				t.Errorf("JWKS lộ trường private %q", private)
+			}
+		}
+	}
+}
+
+func TestLoadJWTKeysRejectsInvalidKeys(t *testing.T) {
+	strongKey := generateRSAKey(t, 2048)
+	strong := writePEM(t, "strong.pem", strongKey)
+	weak := generateRSAKey(t, 1024)
+
+	cases := map[string]config.JWTConfig{
+		"RSA 1024 bit để ký":       {SigningKeyFile: writePEM(t, "weak.pem", weak)},
+		"RSA 1024 bit để kiểm tra": {SigningKeyFile: strong, VerifyKeyFiles: []string{writePEM(t, "weak.pub.pem", &weak.PublicKey)}},
+		"public key làm khoá ký":   {SigningKeyFile: writePEM(t, "pub.pem", &strongKey.PublicKey)},
+		"file không tồn tại":       {SigningKeyFile: filepath.Join(t.TempDir(), "missing.pem")},
+	}
+	for name, cfg := range cases {
+		if err := LoadJWTKeys(cfg); err == nil {
+			t.Errorf("%s: phải báo lỗi", name)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret là khoá SHA1 "12345678901234567890" của RFC 6238 Appendix B, mã hoá base32
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestValidateTOTPRFC6238Vectors(t *testing.T) {
+	// Mã 8 chữ số của Appendix B, server dùng 6 chữ số cuối
+	for _, tc := range []struct {
+		unix int64
+		code string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	} {
+		now := time.Unix(tc.unix, 0)
+		want := tc.code[2:]
+		if got, err := TOTPCode(rfc6238Secret, now); err != nil || got != want {
+			t.Errorf("TOTPCode(T=%d) = %q, %v; muốn %q", tc.unix, got, err, want)
+		}
+		step, ok := ValidateTOTP(rfc6238Secret, want, now, 0)
+		if !ok || step != tc.unix/30 {
+			t.Errorf("ValidateTOTP(T=%d) = %d, %v; muốn %d, true", tc.unix, step, ok, tc.unix/30)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / 30
+
+	for _, tc := range []struct {
+		offset time.Duration
+		ok     bool
+	}{
+		{-60 * time.Second, false},
+		{-30 * time.Second, true},
+		{0, true},
+		{30 * time.Second, true},
+		{60 * time.Second, false},
+	} {
+		code, err := TOTPCode(rfc6238Secret, now.Add(tc.offset))
+		if err != nil {
+			t.Fatal(err)
+		}
+		step, ok := ValidateTOTP(rfc6238Secret, code, now, 0)
+		if ok != tc.ok {
+			t.Errorf("mã lệch %v: ok = %v, muốn %v", tc.offset, ok, tc.ok)
+		}
+		if want := current + int64(tc.offset/(30*time.Second)); ok && step != want {
+			t.Errorf("mã lệch %v: step = %d, muốn %d", tc.offset, step, want)
+		}
+	}
+
+	if _, ok := ValidateTOTP(rfc6238Secret, "12345", now, 0); ok {
+		t.Error("mã 5 chữ số không được hợp lệ")
+	}
+	if _, ok := ValidateTOTP("không-phải-base32", "123456", now, 0); ok {
+		t.Error("secret hỏng không được hợp lệ")
+	}
+}
+
+func TestValidateTOTPRejectsReplay(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	code, err := TOTPCode(rfc6238Secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	step, ok := ValidateTOTP(rfc6238Secret, code, now, 0)
+	if !ok {
+		t.Fatal("mã hiện tại phải hợp lệ")
+	}
+	// TOTPLastStep đã ghi bước này → cùng mã, kể cả trong cửa sổ lệch, bị từ chối
+	if _, ok := ValidateTOTP(rfc6238Secret, code, now, step); ok {
+		t.Error("mã đã dùng không được hợp lệ lần hai")
+	}
+	if _, ok := ValidateTOTP(rfc6238Secret, code, now.Add(30*time.Second), step); ok {
+		t.Error("mã đã dùng không được hợp lệ ở bước kế tiếp")
+	}
+
+	// Mã của bước trước bước đã dùng cũng bị từ chối dù còn trong cửa sổ ±1
+	previous, _ := TOTPCode(rfc6238Secret, now.Add(-30*time.Second))
+	if _, ok := ValidateTOTP(rfc6238Secret, previous, now, step); ok {
+		t.Error("mã cũ hơn bước đã dùng không được hợp lệ")
+	}
+
+	next, _ := TOTPCode(rfc6238Secret, now.Add(30*time.Second))
+	if got, ok := ValidateTOTP(rfc6238Secret, next, now.Add(30*time.Second), step); !ok || got != step+1 {
+		t.Errorf("mã bước kế tiếp = %d, %v; muốn %d, true", got, ok, step+1)
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := codes[0]
+	issued := NormalizeRecoveryCode(code)
+	if len(issued) != 10 || strings.Contains(issued, "-") {
+		t.Fatalf("NormalizeRecoveryCode(%q) = %q", code, issued)
+	}
+
+	for _, typed := range []string{
+		code,
+		code[:5] + code[6:],
+		strings.ToUpper(code),
+		"  " + code + "\n",
+		code[:5] + " " + code[6:],
+	} {
+		if got := NormalizeRecoveryCode(typed); got != issued {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, muốn %q", typed, got, issued)
+		}
+	}
+}
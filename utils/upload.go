@@ -1,6 +1,9 @@
 package utils
 
 import (
+	"Truyen_BE/apperror"
+	"Truyen_BE/config"
+	"errors"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -10,35 +13,65 @@ import (
 	"github.com/google/uuid"
 )
 
+var allowedImageExts = map[string]bool{
+	".jpg":  true,
+	".jpeg": true,
+	".png":  true,
+	".webp": true,
+}
+
+// IsUploadedImage kiểm tra url có đúng dạng do UploadImage trả về ("/static/<uuid>.<ext>") và file còn tồn tại
+func IsUploadedImage(url string) bool {
+	filename, ok := strings.CutPrefix(url, "/static/")
+	if !ok || filename != filepath.Base(filename) {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(filename))
+	if !allowedImageExts[ext] {
+		return false
+	}
+	if _, err := uuid.Parse(strings.TrimSuffix(filename, filepath.Ext(filename))); err != nil {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(config.App.Upload.Dir, filename))
+	return err == nil && !info.IsDir()
+}
+
 func UploadImage(c *gin.Context) {
+	maxBytes := config.App.Upload.MaxBytes
+	// Chặn ngay khi đọc body, cộng thêm 1MB cho phần header multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+1<<20)
+
 	file, err := c.FormFile("file")
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No file uploaded"})
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			apperror.Abort(c, apperror.FileTooLarge)
+			return
+		}
+		apperror.Abort(c, apperror.Validation(apperror.Field("file", apperror.Required)))
+		return
+	}
+	if file.Size > maxBytes {
+		apperror.Abort(c, apperror.FileTooLarge)
 		return
 	}
 
 	ext := strings.ToLower(filepath.Ext(file.Filename))
 
-	allowed := map[string]bool{
-		".jpg":  true,
-		".jpeg": true,
-		".png":  true,
-		".webp": true,
-	}
-
-	if !allowed[ext] {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid file"})
+	if !allowedImageExts[ext] {
+		apperror.Abort(c, apperror.Validation(apperror.Field("file", apperror.NotAllowed)))
 		return
 	}
 
 	filename := uuid.New().String() + ext
 
-	os.MkdirAll("./uploads", os.ModePerm)
+	os.MkdirAll(config.App.Upload.Dir, os.ModePerm)
 
-	savePath := "./uploads/" + filename
+	savePath := filepath.Join(config.App.Upload.Dir, filename)
 
 	if err := c.SaveUploadedFile(file, savePath); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Upload failed"})
+		apperror.Abort(c, apperror.Internal(err))
 		return
 	}
 